@@ -155,6 +155,7 @@ function main() {
   export CONFIGURATION_CHECKSUM="{{ .configurationChecksum | default "" }}"
   export FIRST_BASHIBLE_RUN="no"
   export NODE_GROUP="{{ .nodeGroup.name }}"
+  export REBOOT_REQUESTED="no"
   export TMPDIR="/opt/deckhouse/tmp"
 {{- if .registry }}
   export REGISTRY_ADDRESS="{{ .registry.address }}"
@@ -180,11 +181,14 @@ function main() {
 {{- end }}
 
   if type kubectl >/dev/null 2>&1 && test -f /etc/kubernetes/kubelet.conf ; then
-    if tmp="$(kubectl_exec get node $(hostname -s) -o json | jq -r '.metadata.labels."node.deckhouse.io/group"')" ; then
-      NODE_GROUP="$tmp"
+    if node="$(kubectl_exec get node $(hostname -s) -o json)" ; then
+      NODE_GROUP="$(jq -r '.metadata.labels."node.deckhouse.io/group"' <<< "$node")"
       if [ "${NODE_GROUP}" == "null" ] ; then
         >&2 echo "failed to get node group. Forgot set label 'node.deckhouse.io/group'"
       fi
+      if jq -e '.metadata.annotations | has("node.deckhouse.io/reboot-requested")' <<< "$node" >/dev/null ; then
+        REBOOT_REQUESTED="yes"
+      fi
     fi
   fi
 
@@ -217,7 +221,7 @@ function main() {
   fi
 
 {{ if eq .runType "Normal" }}
-  if [[ -f $CONFIGURATION_CHECKSUM_FILE ]] && [[ "$(<$CONFIGURATION_CHECKSUM_FILE)" == "$CONFIGURATION_CHECKSUM" ]] && [[ -f $UPTIME_FILE ]] && [[ "$(<$UPTIME_FILE)" < "$(current_uptime)" ]] 2>/dev/null; then
+  if [[ -f $CONFIGURATION_CHECKSUM_FILE ]] && [[ "$(<$CONFIGURATION_CHECKSUM_FILE)" == "$CONFIGURATION_CHECKSUM" ]] && [[ -f $UPTIME_FILE ]] && [[ "$(<$UPTIME_FILE)" < "$(current_uptime)" ]] 2>/dev/null && [[ "$REBOOT_REQUESTED" == "no" ]]; then
     echo "Configuration is in sync, nothing to do."
     annotate_node node.deckhouse.io/configuration-checksum=${CONFIGURATION_CHECKSUM}
     current_uptime > $UPTIME_FILE
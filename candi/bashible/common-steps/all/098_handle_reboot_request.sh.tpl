@@ -0,0 +1,22 @@
+# Copyright 2023 Flant JSC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+{{- if eq .runType "Normal" }}
+# The node-manager remediation requests a reboot with the node annotation, the reboot itself is done by the 099_reboot step.
+if bb-kubectl --kubeconfig=/etc/kubernetes/kubelet.conf get node "$(hostname -s)" -o json | jq -e '.metadata.annotations | has("node.deckhouse.io/reboot-requested")' >/dev/null; then
+  bb-log-info "Reboot is requested by the node remediation."
+  bb-flag-set reboot
+  bb-kubectl --kubeconfig=/etc/kubernetes/kubelet.conf annotate node "$(hostname -s)" node.deckhouse.io/reboot-requested-
+fi
+{{- end }}
@@ -66,7 +66,10 @@ rm -rf /opt/cni
 rm -rf /opt/deckhouse
 rm -rf /var/lib/bashible
 
-# run reboot in the background to normally ends cleanup script and ssh session from client
-(sleep 5 && shutdown -r now) &
+# the caller reboots the host itself if it has to do something after the cleanup (e.g., wipe devices)
+if [ "$2" != "--no-reboot" ]; then
+  # run reboot in the background to normally ends cleanup script and ssh session from client
+  (sleep 5 && shutdown -r now) &
+fi
 EOF
 {{- end }}
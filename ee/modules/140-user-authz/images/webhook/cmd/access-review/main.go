@@ -0,0 +1,100 @@
+/*
+Copyright 2023 Flant JSC
+Licensed under the Deckhouse Platform Enterprise Edition (EE) license. See https://github.com/deckhouse/deckhouse/blob/main/ee/LICENSE
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"user-authz-webhook/web"
+	"user-authz-webhook/web/hook"
+)
+
+// Asks the webhook why the user is allowed or denied to make a request, or who can make it.
+//
+//	access-review -user jane -groups dev,ops -verb get -resource pods -namespace app
+//	access-review -who-can -verb delete -resource deployments -group apps -namespace app
+func main() {
+	var (
+		user      = flag.String("user", "", "user name or service account in the system:serviceaccount:<namespace>:<name> form")
+		groups    = flag.String("groups", "", "comma-separated list of user groups")
+		verb      = flag.String("verb", "get", "verb of the request")
+		resource  = flag.String("resource", "", "resource of the request, e.g., pods")
+		group     = flag.String("group", "", "API group of the resource, empty for the core group")
+		version   = flag.String("version", "", "API version of the resource")
+		namespace = flag.String("namespace", "", "namespace of the request, empty for cluster-scoped requests")
+		whoCan    = flag.Bool("who-can", false, "list subjects allowed to make the request instead of reviewing the user access")
+	)
+	flag.Parse()
+
+	attributes := hook.WebhookResourceAttributes{
+		Group:     *group,
+		Version:   *version,
+		Namespace: *namespace,
+		Resource:  *resource,
+		Verb:      *verb,
+	}
+
+	path := "access-review"
+	var request interface{} = hook.AccessReviewRequest{
+		User:               *user,
+		Groups:             splitGroups(*groups),
+		ResourceAttributes: attributes,
+	}
+	if *whoCan {
+		path = "who-can"
+		request = hook.WhoCanRequest{ResourceAttributes: attributes}
+	}
+
+	body, err := json.Marshal(request)
+	check(err)
+
+	client, err := web.NewClient()
+	check(err)
+
+	addr := url.URL{
+		Scheme: "https",
+		Host:   web.ListenAddr,
+		Path:   path,
+	}
+	response, err := client.Post(addr.String(), "application/json", bytes.NewReader(body))
+	check(err)
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		io.Copy(log.Writer(), response.Body)
+		log.Fatalln()
+	}
+
+	var out bytes.Buffer
+	data, err := io.ReadAll(response.Body)
+	check(err)
+	check(json.Indent(&out, data, "", "  "))
+	out.WriteByte('\n')
+	out.WriteTo(os.Stdout)
+}
+
+func splitGroups(groups string) []string {
+	if groups == "" {
+		return nil
+	}
+
+	return strings.Split(groups, ",")
+}
+
+func check(err error) {
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2023 Flant JSC
+Licensed under the Deckhouse Platform Enterprise Edition (EE) license. See https://github.com/deckhouse/deckhouse/blob/main/ee/LICENSE
+*/
+
+package hook
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	AuditRecordKind    = "UserAuthzAuditRecord"
+	AuditRecordVersion = "v1"
+
+	AuditDecisionDenied    = "Denied"
+	AuditDecisionNoOpinion = "NoOpinion"
+
+	// noOpinionReason is used for requests passed to the next authorizer (RBAC)
+	noOpinionReason = "passed to RBAC"
+
+	// otherReasonLabel is used for reasons which are not known in advance, e.g., errors of the Kubernetes API
+	otherReasonLabel = "Other"
+)
+
+// reasonLabels map reasons to values of the reason label of metrics, so the number of series is bounded.
+// The original reason is written to the audit record.
+var reasonLabels = map[string]string{
+	noOpinionReason:              "PassedToRBAC",
+	noNamespaceAccessReason:      "NoNamespaceAccess",
+	namespaceLimitedAccessReason: "NamespaceLimitedAccess",
+	internalErrorReason:          "InternalError",
+}
+
+func reasonLabel(reason string) string {
+	if label, ok := reasonLabels[reason]; ok {
+		return label
+	}
+	return otherReasonLabel
+}
+
+// AuditRecord is a single line of the audit log. Fields must not be renamed or removed, it is a stable schema.
+type AuditRecord struct {
+	Kind               string                    `json:"kind"`
+	Version            string                    `json:"version"`
+	Time               time.Time                 `json:"time"`
+	Decision           string                    `json:"decision"`
+	Reason             string                    `json:"reason"`
+	User               string                    `json:"user"`
+	Groups             []string                  `json:"groups"`
+	ResourceAttributes WebhookResourceAttributes `json:"resourceAttributes"`
+	Rules              []string                  `json:"rules"`
+	// Sampled is true if not every record of this decision is written
+	Sampled bool `json:"sampled"`
+}
+
+// Auditor writes denied and sampled passed requests as JSON lines and counts decisions
+type Auditor struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+
+	allowedSampleRate float64
+	random            func() float64
+
+	decisions     *prometheus.CounterVec
+	deniedByUser  *prometheus.CounterVec
+	deniedByGroup *prometheus.CounterVec
+}
+
+// NewAuditor creates an auditor writing to out. Passed requests are written with the allowedSampleRate probability.
+func NewAuditor(out io.Writer, allowedSampleRate float64, reg prometheus.Registerer) *Auditor {
+	a := &Auditor{
+		enc:               json.NewEncoder(out),
+		allowedSampleRate: allowedSampleRate,
+		random:            rand.Float64,
+
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_authz_webhook_decisions_total",
+			Help: "Number of authorization decisions by decision and reason.",
+		}, []string{"decision", "reason"}),
+		deniedByUser: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_authz_webhook_denied_requests_by_user_total",
+			Help: "Number of denied requests by user and reason.",
+		}, []string{"user", "reason"}),
+		deniedByGroup: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "user_authz_webhook_denied_requests_by_group_total",
+			Help: "Number of denied requests by group and reason. A request is counted for each group of the user.",
+		}, []string{"group", "reason"}),
+	}
+
+	reg.MustRegister(a.decisions, a.deniedByUser, a.deniedByGroup)
+
+	return a
+}
+
+// Record counts the decision made for the request and writes it to the audit log
+func (a *Auditor) Record(request *WebhookRequest, rules []string) {
+	decision, reason := AuditDecisionNoOpinion, noOpinionReason
+	if request.Status.Denied {
+		decision, reason = AuditDecisionDenied, request.Status.Reason
+	}
+
+	label := reasonLabel(reason)
+	a.decisions.WithLabelValues(decision, label).Inc()
+
+	if request.Status.Denied {
+		a.deniedByUser.WithLabelValues(request.Spec.User, label).Inc()
+		for _, group := range request.Spec.Group {
+			a.deniedByGroup.WithLabelValues(group, label).Inc()
+		}
+	} else if a.allowedSampleRate <= 0 || a.random() >= a.allowedSampleRate {
+		return
+	}
+
+	record := AuditRecord{
+		Kind:               AuditRecordKind,
+		Version:            AuditRecordVersion,
+		Time:               time.Now().UTC(),
+		Decision:           decision,
+		Reason:             reason,
+		User:               request.Spec.User,
+		Groups:             request.Spec.Group,
+		ResourceAttributes: request.Spec.ResourceAttributes,
+		Rules:              rules,
+		Sampled:            !request.Status.Denied && a.allowedSampleRate < 1,
+	}
+	if record.Groups == nil {
+		record.Groups = []string{}
+	}
+	if record.Rules == nil {
+		record.Rules = []string{}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// an error of writing to stdout cannot be handled
+	_ = a.enc.Encode(record)
+}
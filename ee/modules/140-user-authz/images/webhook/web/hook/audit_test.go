@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Flant JSC
+Licensed under the Deckhouse Platform Enterprise Edition (EE) license. See https://github.com/deckhouse/deckhouse/blob/main/ee/LICENSE
+*/
+
+package hook
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAuditor(t *testing.T) {
+	var out bytes.Buffer
+	auditor := NewAuditor(&out, 0.5, prometheus.NewRegistry())
+
+	random := []float64{0.7, 0.2}
+	auditor.random = func() float64 {
+		r := random[0]
+		random = random[1:]
+		return r
+	}
+
+	denied := &WebhookRequest{
+		Spec: WebhookResourceSpec{
+			User:               "jane",
+			Group:              []string{"dev", "ops"},
+			ResourceAttributes: WebhookResourceAttributes{Resource: "pods", Verb: "get", Namespace: "prod"},
+		},
+		Status: WebhookRequestStatus{Denied: true, Reason: noNamespaceAccessReason},
+	}
+	passed := &WebhookRequest{
+		Spec: WebhookResourceSpec{
+			User:               "john",
+			ResourceAttributes: WebhookResourceAttributes{Resource: "pods", Verb: "list", Namespace: "app"},
+		},
+	}
+
+	auditor.Record(denied, []string{"developers"})
+	auditor.Record(passed, nil) // not sampled
+	auditor.Record(passed, nil) // sampled
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d: %s", len(lines), out.String())
+	}
+
+	var records []AuditRecord
+	for _, line := range lines {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		record.Time = record.Time.UTC().Truncate(0)
+		records = append(records, record)
+	}
+
+	expected := []AuditRecord{
+		{
+			Decision:           AuditDecisionDenied,
+			Reason:             noNamespaceAccessReason,
+			User:               "jane",
+			Groups:             []string{"dev", "ops"},
+			ResourceAttributes: denied.Spec.ResourceAttributes,
+			Rules:              []string{"developers"},
+		},
+		{
+			Decision:           AuditDecisionNoOpinion,
+			Reason:             noOpinionReason,
+			User:               "john",
+			Groups:             []string{},
+			ResourceAttributes: passed.Spec.ResourceAttributes,
+			Rules:              []string{},
+			Sampled:            true,
+		},
+	}
+	for i := range expected {
+		if records[i].Kind != AuditRecordKind || records[i].Version != AuditRecordVersion || records[i].Time.IsZero() {
+			t.Errorf("record %d: unexpected header %+v", i, records[i])
+		}
+		expected[i].Kind, expected[i].Version, expected[i].Time = records[i].Kind, records[i].Version, records[i].Time
+		if !reflect.DeepEqual(records[i], expected[i]) {
+			t.Errorf("record %d: got %+v | expected %+v", i, records[i], expected[i])
+		}
+	}
+
+	if v := testutil.ToFloat64(auditor.decisions.WithLabelValues(AuditDecisionNoOpinion, "PassedToRBAC")); v != 2 {
+		t.Errorf("passed decisions: got %v | expected 2", v)
+	}
+	if v := testutil.ToFloat64(auditor.deniedByUser.WithLabelValues("jane", "NoNamespaceAccess")); v != 1 {
+		t.Errorf("denied by user: got %v | expected 1", v)
+	}
+	if n := testutil.CollectAndCount(auditor.deniedByGroup); n != 2 {
+		t.Errorf("denied by group series: got %v | expected 2", n)
+	}
+}
+
+func TestAuditorReasonLabels(t *testing.T) {
+	var out bytes.Buffer
+	auditor := NewAuditor(&out, 0, prometheus.NewRegistry())
+
+	for _, reason := range []string{`namespaces "prod" is forbidden`, `namespaces "dev" is forbidden`} {
+		auditor.Record(&WebhookRequest{
+			Spec:   WebhookResourceSpec{User: "jane"},
+			Status: WebhookRequestStatus{Denied: true, Reason: reason},
+		}, nil)
+	}
+
+	if n := testutil.CollectAndCount(auditor.decisions); n != 1 {
+		t.Errorf("decision series: got %v | expected 1", n)
+	}
+	if v := testutil.ToFloat64(auditor.deniedByUser.WithLabelValues("jane", otherReasonLabel)); v != 2 {
+		t.Errorf("denied by user with the %s reason: got %v | expected 2", otherReasonLabel, v)
+	}
+
+	var record AuditRecord
+	if err := json.Unmarshal([]byte(strings.SplitN(out.String(), "\n", 2)[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Reason != `namespaces "prod" is forbidden` {
+		t.Errorf("the audit record must keep the original reason, got %q", record.Reason)
+	}
+}
@@ -42,14 +42,18 @@ type Handler struct {
 
 	cache cache.Cache
 
+	audit *Auditor
+
 	kubeclient kubernetes.Interface
 
 	//        [user type] [user name]
 	mu        sync.RWMutex
 	directory map[string]map[string]DirectoryEntry
+	// rules are kept to explain decisions in access reviews
+	rules []AuthorizationRule
 }
 
-func NewHandler(logger *log.Logger, discoveryCache cache.Cache) (*Handler, error) {
+func NewHandler(logger *log.Logger, discoveryCache cache.Cache, auditor *Auditor) (*Handler, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
@@ -63,6 +67,7 @@ func NewHandler(logger *log.Logger, discoveryCache cache.Cache) (*Handler, error
 	return &Handler{
 		logger:     logger,
 		cache:      discoveryCache,
+		audit:      auditor,
 		kubeclient: clientSet,
 	}, nil
 }
@@ -91,7 +96,9 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(respData)
 
-	h.logger.Printf("response body: %s", respData)
+	if h.audit != nil {
+		h.audit.Record(&request, h.affectedRules(&request))
+	}
 }
 
 func (h *Handler) authorizeNamespacedRequest(request *WebhookRequest, entry *DirectoryEntry) *WebhookRequest {
@@ -253,6 +260,18 @@ func (h *Handler) renewDirectories() {
 		return
 	}
 
+	directory := newDirectory(config.CRDs)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.directory = directory
+	h.rules = config.CRDs
+	h.logger.Println("configuration was reloaded successfully")
+}
+
+// newDirectory composes namespace options of rules by subjects kinds/names
+func newDirectory(rules []AuthorizationRule) map[string]map[string]DirectoryEntry {
 	directory := map[string]map[string]DirectoryEntry{
 		"User":           make(map[string]DirectoryEntry),
 		"Group":          make(map[string]DirectoryEntry),
@@ -260,50 +279,56 @@ func (h *Handler) renewDirectories() {
 	}
 
 	// fill limited namespaces by subjects kinds/names
-	for _, crd := range config.CRDs {
+	for _, crd := range rules {
 		for _, subject := range crd.Spec.Subjects {
-			name := subject.Name
-			namespace := subject.Namespace
 			kind := subject.Kind
-
-			if kind == "ServiceAccount" {
-				name = "system:serviceaccount:" + namespace + ":" + name
-			}
+			name := subjectName(subject)
 
 			dirEntry, ok := directory[kind][name]
 			if !ok {
 				dirEntry = DirectoryEntry{}
 			}
 
-			// If there are neither LimitNamespaces nor NamespaceSelector options, it means all non-system namespaces are allowed.
-			// We need to know whether we have at least one such a CR for the user in a cluster.
-			dirEntry.NamespaceFiltersAbsent = dirEntry.NamespaceFiltersAbsent || (len(crd.Spec.LimitNamespaces) == 0 && !isLabelSelectorApplied(crd.Spec.NamespaceSelector))
-
-			// if the NamespaceSelector field is empty - take the limitNamespaces entries and check the allowAccessToSystemNamespaces flag
-			if crd.Spec.NamespaceSelector == nil {
-				// This is an important thing! All regular expressions is wrapped in the ^...$
-				for _, ln := range crd.Spec.LimitNamespaces {
-					r, _ := regexp.Compile(wrapRegex(ln))
-					dirEntry.LimitNamespaces = append(dirEntry.LimitNamespaces, r)
-				}
-
-				if !dirEntry.AllowAccessToSystemNamespaces {
-					dirEntry.AllowAccessToSystemNamespaces = crd.Spec.AllowAccessToSystemNamespaces
-				}
-				// if the NamespaceSelector field isn't empty - ignore limitNamespaces and allowAccessToSystemNamespaces in this entry
-			} else {
-				dirEntry.NamespaceSelectors = append(dirEntry.NamespaceSelectors, crd.Spec.NamespaceSelector)
-			}
-
-			directory[kind][name] = dirEntry
+			dirEntry.Rules = append(dirEntry.Rules, crd.Name)
+			directory[kind][name] = appendRuleToEntry(dirEntry, &crd.Spec)
 		}
 	}
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	return directory
+}
 
-	h.directory = directory
-	h.logger.Println("configuration was reloaded successfully")
+// subjectName returns the name of the subject as it is passed in the review request
+func subjectName(subject AuthorizationRuleSubject) string {
+	if subject.Kind == "ServiceAccount" {
+		return "system:serviceaccount:" + subject.Namespace + ":" + subject.Name
+	}
+
+	return subject.Name
+}
+
+// appendRuleToEntry merges namespace options of the rule into the directory entry
+func appendRuleToEntry(dirEntry DirectoryEntry, spec *AuthorizationRuleSpec) DirectoryEntry {
+	// If there are neither LimitNamespaces nor NamespaceSelector options, it means all non-system namespaces are allowed.
+	// We need to know whether we have at least one such a CR for the user in a cluster.
+	dirEntry.NamespaceFiltersAbsent = dirEntry.NamespaceFiltersAbsent || (len(spec.LimitNamespaces) == 0 && !isLabelSelectorApplied(spec.NamespaceSelector))
+
+	// if the NamespaceSelector field is empty - take the limitNamespaces entries and check the allowAccessToSystemNamespaces flag
+	if spec.NamespaceSelector == nil {
+		// This is an important thing! All regular expressions is wrapped in the ^...$
+		for _, ln := range spec.LimitNamespaces {
+			r, _ := regexp.Compile(wrapRegex(ln))
+			dirEntry.LimitNamespaces = append(dirEntry.LimitNamespaces, r)
+		}
+
+		if !dirEntry.AllowAccessToSystemNamespaces {
+			dirEntry.AllowAccessToSystemNamespaces = spec.AllowAccessToSystemNamespaces
+		}
+		// if the NamespaceSelector field isn't empty - ignore limitNamespaces and allowAccessToSystemNamespaces in this entry
+	} else {
+		dirEntry.NamespaceSelectors = append(dirEntry.NamespaceSelectors, spec.NamespaceSelector)
+	}
+
+	return dirEntry
 }
 
 func isLabelSelectorApplied(namespaceSelector *NamespaceSelector) bool {
@@ -356,6 +381,24 @@ func (h *Handler) affectedDirs(r *WebhookRequest) []DirectoryEntry {
 	return dirEntriesAffected
 }
 
+// affectedRules returns names of rules affecting the request
+func (h *Handler) affectedRules(r *WebhookRequest) []string {
+	var rules []string
+	seen := make(map[string]struct{})
+
+	for _, dirEntry := range h.affectedDirs(r) {
+		for _, rule := range dirEntry.Rules {
+			if _, ok := seen[rule]; ok {
+				continue
+			}
+			seen[rule] = struct{}{}
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
 // checks if labels of a namespace match provided labelselector
 func (h *Handler) namespaceLabelsMatchSelector(namespaceName string, namespaceSelectors []*NamespaceSelector) (bool, error) {
 	var labelsSet labels.Set
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 Flant JSC
+Licensed under the Deckhouse Platform Enterprise Edition (EE) license. See https://github.com/deckhouse/deckhouse/blob/main/ee/LICENSE
+*/
+
+package hook
+
+import (
+	"strings"
+	"unicode"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// ruleRoles returns names of ClusterRoles bound by the rule (see templates/cluster-role-bindings.yaml of the user-authz module).
+// Custom cluster roles of access levels are not included.
+func ruleRoles(spec *AuthorizationRuleSpec) []RoleReview {
+	var roles []RoleReview
+
+	if spec.AccessLevel != "" {
+		roles = append(roles, RoleReview{Name: "user-authz:" + kebabCase(spec.AccessLevel)})
+	}
+	if spec.PortForwarding {
+		roles = append(roles, RoleReview{Name: "user-authz:port-forward"})
+	}
+	if spec.AllowScale {
+		roles = append(roles, RoleReview{Name: "user-authz:scale"})
+	}
+	for _, role := range spec.AdditionalRoles {
+		roles = append(roles, RoleReview{Name: role.Name})
+	}
+
+	return roles
+}
+
+// policyRulesAllow checks whether any of the rules allows the verb on the resource.
+// Rules with resourceNames are skipped because the webhook request has no resource name.
+func policyRulesAllow(rules []rbacv1.PolicyRule, attributes *WebhookResourceAttributes) bool {
+	for _, rule := range rules {
+		if len(rule.ResourceNames) > 0 {
+			continue
+		}
+
+		if has(rule.Verbs, attributes.Verb) && has(rule.APIGroups, attributes.Group) && has(rule.Resources, attributes.Resource) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func has(list []string, item string) bool {
+	for _, s := range list {
+		if s == rbacv1.VerbAll || s == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+// kebabCase converts access levels to role names, e.g., PrivilegedUser -> privileged-user
+func kebabCase(s string) string {
+	var b strings.Builder
+
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2023 Flant JSC
+Licensed under the Deckhouse Platform Enterprise Edition (EE) license. See https://github.com/deckhouse/deckhouse/blob/main/ee/LICENSE
+*/
+
+package hook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	noMatchingRulesReason = "no ClusterAuthorizationRule matches the user or its groups"
+	noMatchingRolesReason = "roles of the matching ClusterAuthorizationRules do not allow the request"
+)
+
+// AccessReviewRequest asks why the user is allowed or denied to make the request
+type AccessReviewRequest struct {
+	User               string                    `json:"user"`
+	Groups             []string                  `json:"groups,omitempty"`
+	ResourceAttributes WebhookResourceAttributes `json:"resourceAttributes"`
+}
+
+// AccessReviewResponse is the decision with the rules and the roles involved.
+// Only roles bound by ClusterAuthorizationRules are taken into account, other RBAC bindings are not checked.
+type AccessReviewResponse struct {
+	Allowed bool          `json:"allowed"`
+	Reason  string        `json:"reason,omitempty"`
+	Rules   []MatchedRule `json:"rules"`
+}
+
+type MatchedRule struct {
+	Name    string                   `json:"name"`
+	Subject AuthorizationRuleSubject `json:"subject"`
+
+	AccessLevel                   string             `json:"accessLevel,omitempty"`
+	LimitNamespaces               []string           `json:"limitNamespaces,omitempty"`
+	NamespaceSelector             *NamespaceSelector `json:"namespaceSelector,omitempty"`
+	AllowAccessToSystemNamespaces bool               `json:"allowAccessToSystemNamespaces"`
+
+	Roles []RoleReview `json:"roles"`
+}
+
+type RoleReview struct {
+	Name    string `json:"name"`
+	Allowed bool   `json:"allowed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// WhoCanRequest asks which subjects of ClusterAuthorizationRules are allowed to make the request
+type WhoCanRequest struct {
+	ResourceAttributes WebhookResourceAttributes `json:"resourceAttributes"`
+}
+
+type WhoCanResponse struct {
+	Subjects []AllowedSubject `json:"subjects"`
+}
+
+type AllowedSubject struct {
+	AuthorizationRuleSubject
+	Rules []string `json:"rules"`
+}
+
+// ServeAccessReview handles AccessReviewRequests
+func (h *Handler) ServeAccessReview(w http.ResponseWriter, r *http.Request) {
+	var request AccessReviewRequest
+	if !h.decodeReviewRequest(w, r, &request) {
+		return
+	}
+
+	h.writeReviewResponse(w, h.reviewAccess(&request))
+}
+
+// ServeWhoCan handles WhoCanRequests
+func (h *Handler) ServeWhoCan(w http.ResponseWriter, r *http.Request) {
+	var request WhoCanRequest
+	if !h.decodeReviewRequest(w, r, &request) {
+		return
+	}
+
+	h.writeReviewResponse(w, h.whoCan(&request))
+}
+
+func (h *Handler) decodeReviewRequest(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is supported.", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		h.logger.Printf("cannot unmarshal review request: %v", err)
+		http.Error(w, "Invalid json request", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+func (h *Handler) writeReviewResponse(w http.ResponseWriter, response interface{}) {
+	respData, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respData)
+}
+
+// reviewAccess repeats the webhook decision and checks whether the roles granted by the matching rules allow the request.
+func (h *Handler) reviewAccess(request *AccessReviewRequest) *AccessReviewResponse {
+	response := &AccessReviewResponse{Rules: h.matchingRules(request.User, request.Groups)}
+	if len(response.Rules) == 0 {
+		response.Reason = noMatchingRulesReason
+		return response
+	}
+
+	webhookRequest := h.authorizeRequest(&WebhookRequest{
+		Spec: WebhookResourceSpec{
+			ResourceAttributes: request.ResourceAttributes,
+			Group:              request.Groups,
+			User:               request.User,
+		},
+	})
+	if webhookRequest.Status.Denied {
+		response.Reason = webhookRequest.Status.Reason
+		return response
+	}
+
+	roles := make(map[string]RoleReview)
+	for i := range response.Rules {
+		rule := &response.Rules[i]
+		for j := range rule.Roles {
+			review, ok := roles[rule.Roles[j].Name]
+			if !ok {
+				review = h.reviewRole(rule.Roles[j].Name, &request.ResourceAttributes)
+				roles[review.Name] = review
+			}
+
+			rule.Roles[j] = review
+			response.Allowed = response.Allowed || review.Allowed
+		}
+	}
+
+	if !response.Allowed {
+		response.Reason = noMatchingRolesReason
+	}
+
+	return response
+}
+
+// whoCan reviews access of every subject mentioned in the rules
+func (h *Handler) whoCan(request *WhoCanRequest) *WhoCanResponse {
+	h.mu.RLock()
+	rules := h.rules
+	h.mu.RUnlock()
+
+	seen := make(map[AuthorizationRuleSubject]struct{})
+	response := &WhoCanResponse{Subjects: make([]AllowedSubject, 0)}
+
+	for _, rule := range rules {
+		for _, subject := range rule.Spec.Subjects {
+			if _, ok := seen[subject]; ok {
+				continue
+			}
+			seen[subject] = struct{}{}
+
+			review := &AccessReviewRequest{ResourceAttributes: request.ResourceAttributes}
+			if subject.Kind == "Group" {
+				review.Groups = []string{subject.Name}
+			} else {
+				review.User = subjectName(subject)
+			}
+
+			result := h.reviewAccess(review)
+			if !result.Allowed {
+				continue
+			}
+
+			allowed := AllowedSubject{AuthorizationRuleSubject: subject}
+			for _, matched := range result.Rules {
+				allowed.Rules = append(allowed.Rules, matched.Name)
+			}
+			response.Subjects = append(response.Subjects, allowed)
+		}
+	}
+
+	sort.Slice(response.Subjects, func(i, j int) bool {
+		a, b := response.Subjects[i], response.Subjects[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	return response
+}
+
+// matchingRules returns rules bound to the user, its groups or the service account
+func (h *Handler) matchingRules(user string, groups []string) []MatchedRule {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var matched []MatchedRule
+
+	for _, rule := range h.rules {
+		for _, subject := range rule.Spec.Subjects {
+			if !subjectMatches(subject, user, groups) {
+				continue
+			}
+
+			matched = append(matched, MatchedRule{
+				Name:                          rule.Name,
+				Subject:                       subject,
+				AccessLevel:                   rule.Spec.AccessLevel,
+				LimitNamespaces:               rule.Spec.LimitNamespaces,
+				NamespaceSelector:             rule.Spec.NamespaceSelector,
+				AllowAccessToSystemNamespaces: rule.Spec.AllowAccessToSystemNamespaces,
+				Roles:                         ruleRoles(&rule.Spec),
+			})
+			break
+		}
+	}
+
+	return matched
+}
+
+func subjectMatches(subject AuthorizationRuleSubject, user string, groups []string) bool {
+	if subject.Kind == "Group" {
+		for _, group := range groups {
+			if group == subject.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	return user != "" && subjectName(subject) == user
+}
+
+// reviewRole checks whether the ClusterRole allows the request
+func (h *Handler) reviewRole(name string, attributes *WebhookResourceAttributes) RoleReview {
+	review := RoleReview{Name: name}
+
+	role, err := h.kubeclient.RbacV1().ClusterRoles().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		review.Error = err.Error()
+		return review
+	}
+
+	review.Allowed = policyRulesAllow(role.Rules, attributes)
+	return review
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2023 Flant JSC
+Licensed under the Deckhouse Platform Enterprise Edition (EE) license. See https://github.com/deckhouse/deckhouse/blob/main/ee/LICENSE
+*/
+
+package hook
+
+import (
+	"io"
+	"log"
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newReviewHandler() *Handler {
+	rules := []AuthorizationRule{
+		{
+			Name: "developers",
+			Spec: AuthorizationRuleSpec{
+				AccessLevel:     "User",
+				LimitNamespaces: []string{"app-.*"},
+				Subjects:        []AuthorizationRuleSubject{{Kind: "Group", Name: "dev"}},
+			},
+		},
+		{
+			Name: "jane",
+			Spec: AuthorizationRuleSpec{
+				AccessLevel: "Editor",
+				AllowScale:  true,
+				Subjects:    []AuthorizationRuleSubject{{Kind: "User", Name: "jane"}},
+			},
+		},
+		{
+			Name: "ci",
+			Spec: AuthorizationRuleSpec{
+				AccessLevel:     "Editor",
+				LimitNamespaces: []string{"ci"},
+				Subjects:        []AuthorizationRuleSubject{{Kind: "ServiceAccount", Name: "runner", Namespace: "ci"}},
+			},
+		},
+	}
+
+	clusterRole := func(name string, verbs ...string) *rbacv1.ClusterRole {
+		return &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Rules: []rbacv1.PolicyRule{{
+				APIGroups: []string{"", "apps"},
+				Resources: []string{"pods", "deployments"},
+				Verbs:     verbs,
+			}},
+		}
+	}
+
+	return &Handler{
+		logger: log.New(io.Discard, "", 0),
+		kubeclient: fake.NewSimpleClientset(
+			clusterRole("user-authz:user", "get", "list", "watch"),
+			clusterRole("user-authz:editor", "*"),
+		),
+		cache:     &dummyCache{},
+		directory: newDirectory(rules),
+		rules:     rules,
+	}
+}
+
+func TestReviewAccess(t *testing.T) {
+	tc := []struct {
+		Name     string
+		Request  AccessReviewRequest
+		Allowed  bool
+		Reason   string
+		Rules    []string
+		Roles    map[string]bool
+		RoleErrs []string
+	}{
+		{
+			Name: "Allowed by group",
+			Request: AccessReviewRequest{
+				User:               "john",
+				Groups:             []string{"dev"},
+				ResourceAttributes: WebhookResourceAttributes{Verb: "get", Resource: "pods", Namespace: "app-front"},
+			},
+			Allowed: true,
+			Rules:   []string{"developers"},
+			Roles:   map[string]bool{"user-authz:user": true},
+		},
+		{
+			Name: "Denied by limitNamespaces",
+			Request: AccessReviewRequest{
+				User:               "john",
+				Groups:             []string{"dev"},
+				ResourceAttributes: WebhookResourceAttributes{Verb: "get", Resource: "pods", Namespace: "prod"},
+			},
+			Reason: noNamespaceAccessReason,
+			Rules:  []string{"developers"},
+			Roles:  map[string]bool{"user-authz:user": false},
+		},
+		{
+			Name: "Denied by roles",
+			Request: AccessReviewRequest{
+				User:               "john",
+				Groups:             []string{"dev"},
+				ResourceAttributes: WebhookResourceAttributes{Verb: "delete", Group: "apps", Resource: "deployments", Namespace: "app-front"},
+			},
+			Reason: noMatchingRolesReason,
+			Rules:  []string{"developers"},
+			Roles:  map[string]bool{"user-authz:user": false},
+		},
+		{
+			Name: "User and group rules, missing role",
+			Request: AccessReviewRequest{
+				User:               "jane",
+				Groups:             []string{"dev"},
+				ResourceAttributes: WebhookResourceAttributes{Verb: "delete", Group: "apps", Resource: "deployments", Namespace: "app-front"},
+			},
+			Allowed:  true,
+			Rules:    []string{"developers", "jane"},
+			Roles:    map[string]bool{"user-authz:user": false, "user-authz:editor": true, "user-authz:scale": false},
+			RoleErrs: []string{"user-authz:scale"},
+		},
+		{
+			Name: "Service account",
+			Request: AccessReviewRequest{
+				User:               "system:serviceaccount:ci:runner",
+				ResourceAttributes: WebhookResourceAttributes{Verb: "create", Resource: "pods", Namespace: "ci"},
+			},
+			Allowed: true,
+			Rules:   []string{"ci"},
+			Roles:   map[string]bool{"user-authz:editor": true},
+		},
+		{
+			Name: "No rules",
+			Request: AccessReviewRequest{
+				User:               "bob",
+				ResourceAttributes: WebhookResourceAttributes{Verb: "get", Resource: "pods", Namespace: "app-front"},
+			},
+			Reason: noMatchingRulesReason,
+		},
+	}
+
+	handler := newReviewHandler()
+
+	for _, testCase := range tc {
+		t.Run(testCase.Name, func(t *testing.T) {
+			res := handler.reviewAccess(&testCase.Request)
+
+			if res.Allowed != testCase.Allowed {
+				t.Errorf("allowed: got %v | expected %v", res.Allowed, testCase.Allowed)
+			}
+			if res.Reason != testCase.Reason {
+				t.Errorf("reason: got %q | expected %q", res.Reason, testCase.Reason)
+			}
+
+			var rules []string
+			roles := make(map[string]bool)
+			var roleErrs []string
+			for _, rule := range res.Rules {
+				rules = append(rules, rule.Name)
+				for _, role := range rule.Roles {
+					roles[role.Name] = role.Allowed
+					if role.Error != "" {
+						roleErrs = append(roleErrs, role.Name)
+					}
+				}
+			}
+
+			if !reflect.DeepEqual(rules, testCase.Rules) {
+				t.Errorf("rules: got %v | expected %v", rules, testCase.Rules)
+			}
+			if testCase.Roles == nil {
+				testCase.Roles = map[string]bool{}
+			}
+			if !reflect.DeepEqual(roles, testCase.Roles) {
+				t.Errorf("roles: got %v | expected %v", roles, testCase.Roles)
+			}
+			if !reflect.DeepEqual(roleErrs, testCase.RoleErrs) {
+				t.Errorf("role errors: got %v | expected %v", roleErrs, testCase.RoleErrs)
+			}
+		})
+	}
+}
+
+func TestWhoCan(t *testing.T) {
+	handler := newReviewHandler()
+
+	res := handler.whoCan(&WhoCanRequest{
+		ResourceAttributes: WebhookResourceAttributes{Verb: "get", Resource: "pods", Namespace: "app-front"},
+	})
+
+	expected := []AllowedSubject{
+		{AuthorizationRuleSubject: AuthorizationRuleSubject{Kind: "Group", Name: "dev"}, Rules: []string{"developers"}},
+		{AuthorizationRuleSubject: AuthorizationRuleSubject{Kind: "User", Name: "jane"}, Rules: []string{"jane"}},
+	}
+	if !reflect.DeepEqual(res.Subjects, expected) {
+		t.Errorf("got %+v | expected %+v", res.Subjects, expected)
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"User":           "user",
+		"PrivilegedUser": "privileged-user",
+		"ClusterAdmin":   "cluster-admin",
+	} {
+		if res := kebabCase(in); res != out {
+			t.Errorf("%s: got %q | expected %q", in, res, out)
+		}
+	}
+}
@@ -12,8 +12,12 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
 	"user-authz-webhook/cache"
 	"user-authz-webhook/web/hook"
 )
@@ -29,8 +33,27 @@ const (
 	authClientCA = "/etc/ssl/apiserver-authentication-requestheader-client-ca/ca.crt"
 
 	ListenAddr = "127.0.0.1:40443"
+	// MetricsListenAddr is exposed by the kube-rbac-proxy sidecar
+	MetricsListenAddr = "127.0.0.1:40444"
+
+	// defaultAuditAllowedSampleRate is a share of passed requests written to the audit log, all denied requests are written.
+	// It is overridden by the AUDIT_ALLOWED_SAMPLE_RATE environment variable.
+	defaultAuditAllowedSampleRate = 0.01
 )
 
+func auditAllowedSampleRate() (float64, error) {
+	value := os.Getenv("AUDIT_ALLOWED_SAMPLE_RATE")
+	if value == "" {
+		return defaultAuditAllowedSampleRate, nil
+	}
+
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("AUDIT_ALLOWED_SAMPLE_RATE must be a number from 0 to 1, got %q", value)
+	}
+	return rate, nil
+}
+
 func buildTLSConfig() (*tls.Config, error) {
 	clientCertPool := x509.NewCertPool()
 
@@ -56,24 +79,33 @@ func buildTLSConfig() (*tls.Config, error) {
 }
 
 type Server struct {
-	cache   cache.Cache
-	handler *hook.Handler
-	logger  *log.Logger
+	cache    cache.Cache
+	handler  *hook.Handler
+	logger   *log.Logger
+	registry *prometheus.Registry
 }
 
 func NewServer(l *log.Logger) (*Server, error) {
+	sampleRate, err := auditAllowedSampleRate()
+	if err != nil {
+		return nil, err
+	}
+
+	reg := prometheus.NewRegistry()
 	c := cache.NewNamespacedDiscoveryCache(l)
-	h, err := hook.NewHandler(l, c)
+	h, err := hook.NewHandler(l, c, hook.NewAuditor(os.Stdout, sampleRate, reg))
 	if err != nil {
 		return nil, err
 	}
-	return &Server{logger: l, cache: c, handler: h}, nil
+	return &Server{logger: l, cache: c, handler: h, registry: reg}, nil
 }
 
 func (s *Server) prepareHTTPServer() (*http.Server, error) {
 	router := http.NewServeMux()
 
 	router.Handle("/", s.handler)
+	router.HandleFunc("/access-review", s.handler.ServeAccessReview)
+	router.HandleFunc("/who-can", s.handler.ServeWhoCan)
 	router.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		err := s.cache.Check()
 		if err == nil {
@@ -104,6 +136,19 @@ func (s *Server) prepareHTTPServer() (*http.Server, error) {
 	return srv, nil
 }
 
+func (s *Server) prepareMetricsServer() *http.Server {
+	router := http.NewServeMux()
+	router.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{ErrorLog: s.logger}))
+
+	return &http.Server{
+		Addr:         MetricsListenAddr,
+		Handler:      router,
+		ErrorLog:     s.logger,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+}
+
 // Run starts webhook server and its configuration renewal. It will exit only if the webserver stops listening.
 func (s *Server) Run() error {
 	httpServer, err := s.prepareHTTPServer()
@@ -121,6 +166,16 @@ func (s *Server) Run() error {
 		stopCh <- struct{}{}
 	})
 
+	metricsServer := s.prepareMetricsServer()
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Printf("could not listen on %s: %v", MetricsListenAddr, err)
+		}
+	}()
+	httpServer.RegisterOnShutdown(func() {
+		metricsServer.Close()
+	})
+
 	if err := httpServer.ListenAndServeTLS(sslListenCert, sslListenKey); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("could not listen on %s: %v", ListenAddr, err)
 	}
@@ -65,6 +65,7 @@ require (
 	github.com/slok/kubewebhook/v2 v2.5.0
 	golang.org/x/mod v0.12.0
 	golang.org/x/time v0.5.0
+	gopkg.in/robfig/cron.v2 v2.0.0-20150107220207-be2e0b0deed5
 	k8s.io/cli-runtime v0.28.4
 	k8s.io/code-generator v0.28.4
 	k8s.io/klog/v2 v2.100.1
@@ -214,7 +215,6 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230711160842-782d3b101e98 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
 	gopkg.in/inf.v0 v0.9.1 // indirect
-	gopkg.in/robfig/cron.v2 v2.0.0-20150107220207-be2e0b0deed5 // indirect
 	gopkg.in/satori/go.uuid.v1 v1.2.0 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
 	k8s.io/component-base v0.28.4 // indirect
@@ -84,6 +84,35 @@ func (uw Window) IsAllowed(now time.Time) bool {
 	return false
 }
 
+// Covers returns if specified time gets into windows including the whole end minute,
+// so a window ending at 23:59 covers the day till its very end
+func (ws Windows) Covers(t time.Time) bool {
+	for _, window := range ws {
+		if window.Covers(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Covers check if specified time is within [from, to] of the window, the end minute is included
+func (uw Window) Covers(now time.Time) bool {
+	now = now.UTC()
+
+	if !uw.isTodayAllowed(now, uw.Days) {
+		return false
+	}
+
+	fromInput, _ := time.Parse(hh_mm, uw.From)
+	toInput, _ := time.Parse(hh_mm, uw.To)
+
+	fromTime := time.Date(now.Year(), now.Month(), now.Day(), fromInput.Hour(), fromInput.Minute(), 0, 0, time.UTC)
+	toTime := time.Date(now.Year(), now.Month(), now.Day(), toInput.Hour(), toInput.Minute(), 0, 0, time.UTC).Add(time.Minute)
+
+	return !now.Before(fromTime) && now.Before(toTime)
+}
+
 // NextAllowedTime calculates next update window with respect on minimalTime
 // if minimal time is out of window - this function checks next days to find the nearest one
 func (ws Windows) NextAllowedTime(min time.Time) time.Time {
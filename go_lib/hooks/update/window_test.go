@@ -182,3 +182,35 @@ func TestNextAllowedWindow(t *testing.T) {
 		assert.Equal(t, time.Sunday, res.Weekday())
 	})
 }
+
+func TestWindowsCovers(t *testing.T) {
+	t.Run("the whole day", func(t *testing.T) {
+		ws := Windows{{From: "00:00", To: "23:59"}}
+
+		assert.True(t, ws.Covers(time.Date(2021, 10, 13, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, ws.Covers(time.Date(2021, 10, 13, 12, 30, 0, 0, time.UTC)))
+		assert.True(t, ws.Covers(time.Date(2021, 10, 13, 23, 59, 59, 0, time.UTC)))
+	})
+
+	t.Run("the end minute is included", func(t *testing.T) {
+		ws := Windows{{From: "16:00", To: "18:00"}}
+
+		assert.False(t, ws.Covers(time.Date(2021, 10, 13, 15, 59, 59, 0, time.UTC)))
+		assert.True(t, ws.Covers(time.Date(2021, 10, 13, 16, 0, 0, 0, time.UTC)))
+		assert.True(t, ws.Covers(time.Date(2021, 10, 13, 18, 0, 30, 0, time.UTC)))
+		assert.False(t, ws.Covers(time.Date(2021, 10, 13, 18, 1, 0, 0, time.UTC)))
+	})
+
+	t.Run("days", func(t *testing.T) {
+		ws := Windows{{From: "00:00", To: "23:59", Days: []string{"Sat", "Sun"}}}
+
+		// wednesday
+		assert.False(t, ws.Covers(time.Date(2021, 10, 13, 12, 0, 0, 0, time.UTC)))
+		// saturday
+		assert.True(t, ws.Covers(time.Date(2021, 10, 16, 23, 59, 30, 0, time.UTC)))
+	})
+
+	t.Run("no windows", func(t *testing.T) {
+		assert.False(t, Windows{}.Covers(time.Date(2021, 10, 13, 12, 0, 0, 0, time.UTC)))
+	})
+}
@@ -17,24 +17,41 @@ limitations under the License.
 package hooks
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
 	"github.com/flant/addon-operator/sdk"
 	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"github.com/flant/shell-operator/pkg/kube_events_manager/types"
+	"gopkg.in/robfig/cron.v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
 
+	"github.com/deckhouse/deckhouse/go_lib/hooks/update"
 	"github.com/deckhouse/deckhouse/modules/040-node-manager/hooks/internal/mcm/v1alpha1"
 	ngv1 "github.com/deckhouse/deckhouse/modules/040-node-manager/hooks/internal/v1"
 )
 
+const (
+	chaosMonkeyNamespace       = "d8-cloud-instance-manager"
+	chaosMonkeyScenarioLabel   = "node.deckhouse.io/chaos-monkey-scenario"
+	chaosMonkeyUntilAnnotation = "node.deckhouse.io/chaos-monkey-until"
+	chaosMonkeySchedulesState  = "chaos-monkey-schedules"
+
+	chaosDefaultScenarioDuration = 5 * time.Minute
+)
+
 var _ = sdk.RegisterFunc(&go_hook.HookConfig{
 	Settings: &go_hook.HookConfigSettings{
 		ExecutionMinInterval: 5 * time.Second,
@@ -77,6 +94,42 @@ var _ = sdk.RegisterFunc(&go_hook.HookConfig{
 			ExecuteHookOnSynchronization: pointer.Bool(false),
 			FilterFunc:                   chaosFilterMachine,
 		},
+		{
+			Name:       "jobs",
+			ApiVersion: "batch/v1",
+			Kind:       "Job",
+			NamespaceSelector: &types.NamespaceSelector{
+				NameSelector: &types.NameSelector{
+					MatchNames: []string{chaosMonkeyNamespace},
+				},
+			},
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": "chaos-monkey",
+				},
+			},
+			WaitForSynchronization:       pointer.Bool(false),
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   chaosFilterJob,
+		},
+		{
+			Name:       "schedules",
+			ApiVersion: "v1",
+			Kind:       "ConfigMap",
+			NamespaceSelector: &types.NamespaceSelector{
+				NameSelector: &types.NameSelector{
+					MatchNames: []string{chaosMonkeyNamespace},
+				},
+			},
+			NameSelector: &types.NameSelector{
+				MatchNames: []string{chaosMonkeySchedulesState},
+			},
+			WaitForSynchronization:       pointer.Bool(false),
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   chaosFilterSchedules,
+		},
 	},
 	Schedule: []go_hook.ScheduleConfig{
 		{
@@ -86,6 +139,9 @@ var _ = sdk.RegisterFunc(&go_hook.HookConfig{
 	},
 }, handleChaosMonkey)
 
+// chaosNow is replaced in tests to run the hook at the specified time
+var chaosNow = time.Now
+
 func handleChaosMonkey(input *go_hook.HookInput) error {
 	random := time.Now().UnixNano()
 	testRandomSeed := os.Getenv("D8_TEST_RANDOM_SEED")
@@ -95,28 +151,53 @@ func handleChaosMonkey(input *go_hook.HookInput) error {
 	}
 	randomizer := rand.New(rand.NewSource(random))
 
-	nodeGroups, machines, nodes, err := prepareChaosData(input)
+	now := chaosNow().UTC()
+
+	nodeGroups, machines, nodes, err := prepareChaosData(input, now)
 	if err != nil {
 		input.LogEntry.Infof(err.Error()) // just info message, already have a victim
 		return nil
 	}
 
+	schedules := newChaosSchedulesState(input.Snapshots["schedules"], input.Snapshots["ngs"])
+	defer func() {
+		if schedules.changed {
+			input.PatchCollector.Create(schedules.configMap(), object_patch.UpdateIfExists())
+		}
+	}()
+
 	// preparation complete, main hook logic goes here
 	for _, ng := range nodeGroups {
-		if ng.ChaosMode != "DrainAndDelete" {
-			continue
-		}
+		// schedules are checked before the exclusion windows, so the runs falling into the windows are skipped
+		scenario, ok := ng.scheduledScenario(input, schedules, now)
 
-		chaosPeriod, err := time.ParseDuration(ng.ChaosPeriod)
-		if err != nil {
-			input.LogEntry.Warnf("chaos period (%s) for NodeGroup:%s is invalid", ng.ChaosPeriod, ng.Name)
+		if ng.ExclusionWindows.Covers(now) {
 			continue
 		}
 
-		run := randomizer.Uint32() % uint32(chaosPeriod.Milliseconds()/1000/60)
-
-		if run != 0 {
-			continue
+		// nil scenario means DrainAndDelete
+		if !ok {
+			actions := ng.randomActions()
+			if len(actions) == 0 {
+				continue
+			}
+
+			chaosPeriod, err := time.ParseDuration(ng.ChaosPeriod)
+			if err != nil {
+				input.LogEntry.Warnf("chaos period (%s) for NodeGroup:%s is invalid", ng.ChaosPeriod, ng.Name)
+				continue
+			}
+
+			run := randomizer.Uint32() % uint32(chaosPeriod.Milliseconds()/1000/60)
+
+			if run != 0 {
+				continue
+			}
+
+			scenario = actions[0]
+			if len(actions) > 1 {
+				scenario = actions[randomizer.Intn(len(actions))]
+			}
 		}
 
 		nodeGroupNodes := nodes[ng.Name]
@@ -126,20 +207,49 @@ func handleChaosMonkey(input *go_hook.HookInput) error {
 
 		victimNode := nodeGroupNodes[randomizer.Intn(len(nodeGroupNodes))]
 
-		victimMachine, ok := machines[victimNode.Name]
-		if !ok {
+		if scenario == nil {
+			victimMachine, ok := machines[victimNode.Name]
+			if !ok {
+				continue
+			}
+
+			input.PatchCollector.MergePatch(victimAnnotationPatch, "machine.sapcloud.io/v1alpha1", "Machine", "d8-cloud-instance-manager", victimMachine.Name)
+
+			input.PatchCollector.Delete("machine.sapcloud.io/v1alpha1", "Machine", "d8-cloud-instance-manager", victimMachine.Name, object_patch.InBackground())
+
+			input.PatchCollector.Create(chaosEvent(ng, fmt.Sprintf("Node %s: machine %s is drained and deleted", victimNode.Name, victimMachine.Name), now))
 			continue
 		}
 
-		input.PatchCollector.MergePatch(victimAnnotationPatch, "machine.sapcloud.io/v1alpha1", "Machine", "d8-cloud-instance-manager", victimMachine.Name)
+		image := chaosMonkeyImage(input)
+		if image == "" {
+			input.LogEntry.Warnf("image for chaos scenarios is not found, skipping %s scenario for NodeGroup:%s", scenario.Type, ng.Name)
+			continue
+		}
+
+		duration := chaosDefaultScenarioDuration
+		if scenario.Duration != "" {
+			duration, err = time.ParseDuration(scenario.Duration)
+			if err != nil {
+				input.LogEntry.Warnf("duration (%s) of %s chaos scenario for NodeGroup:%s is invalid", scenario.Duration, scenario.Type, ng.Name)
+				continue
+			}
+		}
+
+		job := chaosScenarioJob(image, ng.Name, victimNode.Name, *scenario, duration, now)
+		input.PatchCollector.Create(job, object_patch.IgnoreIfExists())
 
-		input.PatchCollector.Delete("machine.sapcloud.io/v1alpha1", "Machine", "d8-cloud-instance-manager", victimMachine.Name, object_patch.InBackground())
+		msg := fmt.Sprintf("Node %s: %s scenario is started by job %s", victimNode.Name, scenario.Type, job.Name)
+		if chaosScenarioHasDuration(scenario.Type) {
+			msg = fmt.Sprintf("Node %s: %s scenario for %s is started by job %s", victimNode.Name, scenario.Type, duration, job.Name)
+		}
+		input.PatchCollector.Create(chaosEvent(ng, msg, now))
 	}
 
 	return nil
 }
 
-func prepareChaosData(input *go_hook.HookInput) ([]chaosNodeGroup, map[string]chaosMachine, map[string][]chaosNode, error) {
+func prepareChaosData(input *go_hook.HookInput, now time.Time) ([]chaosNodeGroup, map[string]chaosMachine, map[string][]chaosNode, error) {
 	snap := input.Snapshots["machines"]
 	machines := make(map[string]chaosMachine, len(snap)) // map by node name
 	for _, sn := range snap {
@@ -150,13 +260,21 @@ func prepareChaosData(input *go_hook.HookInput) ([]chaosNodeGroup, map[string]ch
 		machines[machine.Node] = machine
 	}
 
+	// do nothing while a chaos scenario is in progress
+	for _, sn := range input.Snapshots["jobs"] {
+		job := sn.(chaosJob)
+		if job.Until.After(now) {
+			return nil, nil, nil, fmt.Errorf("chaos scenario job %s is in progress. Exiting", job.Name)
+		}
+	}
+
 	// collect NodeGroup with Enabled chaos monkey
 	snap = input.Snapshots["ngs"]
 	nodeGroups := make([]chaosNodeGroup, 0)
 	for _, sn := range snap {
 		ng := sn.(chaosNodeGroup)
-		// if chaos mode is empty - it's disabled
-		if ng.ChaosMode == "" || !ng.IsReadyForChaos {
+		// if chaos mode is empty and there are no scenarios - it's disabled
+		if ng.ChaosMode == "Disabled" || (ng.ChaosMode == "" && len(ng.Scenarios) == 0) || !ng.IsReadyForChaos {
 			continue
 		}
 		nodeGroups = append(nodeGroups, ng)
@@ -237,18 +355,198 @@ func chaosFilterNodeGroup(obj *unstructured.Unstructured) (go_hook.FilterResult,
 	}
 
 	return chaosNodeGroup{
-		Name:            ng.Name,
-		ChaosMode:       ng.Spec.Chaos.Mode,
-		ChaosPeriod:     period,
-		IsReadyForChaos: isReadyForChaos,
+		Name:             ng.Name,
+		UID:              ng.UID,
+		ChaosMode:        ng.Spec.Chaos.Mode,
+		ChaosPeriod:      period,
+		Scenarios:        ng.Spec.Chaos.Scenarios,
+		ExclusionWindows: ng.Spec.Chaos.ExclusionWindows,
+		IsReadyForChaos:  isReadyForChaos,
+	}, nil
+}
+
+func chaosFilterJob(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var job batchv1.Job
+
+	err := sdk.FromUnstructured(obj, &job)
+	if err != nil {
+		return nil, err
+	}
+
+	// a job without the annotation is considered to be finished
+	until, _ := time.Parse(time.RFC3339, job.Annotations[chaosMonkeyUntilAnnotation])
+
+	return chaosJob{
+		Name:  job.Name,
+		Until: until,
 	}, nil
 }
 
+func chaosFilterSchedules(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var cm corev1.ConfigMap
+
+	err := sdk.FromUnstructured(obj, &cm)
+	if err != nil {
+		return nil, err
+	}
+
+	lastRuns := make(map[string]time.Time, len(cm.Data))
+	for key, value := range cm.Data {
+		lastRun, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			// the schedule is considered to be never checked
+			continue
+		}
+		lastRuns[key] = lastRun
+	}
+
+	return lastRuns, nil
+}
+
 type chaosNodeGroup struct {
-	Name            string
-	ChaosMode       string
-	ChaosPeriod     string // default 6h
-	IsReadyForChaos bool
+	Name             string
+	UID              k8stypes.UID
+	ChaosMode        string
+	ChaosPeriod      string // default 6h
+	Scenarios        []ngv1.ChaosScenario
+	ExclusionWindows update.Windows
+	IsReadyForChaos  bool
+}
+
+// scheduledScenario returns the first scenario which schedule has come since the previous due run of the scenario.
+// All due scenarios are marked as run, so a run missed by the hook is started only once.
+func (ng chaosNodeGroup) scheduledScenario(input *go_hook.HookInput, schedules *chaosSchedulesState, now time.Time) (*ngv1.ChaosScenario, bool) {
+	var due *ngv1.ChaosScenario
+
+	for i, scenario := range ng.Scenarios {
+		if scenario.Schedule == "" {
+			continue
+		}
+
+		schedule, err := cron.Parse("TZ=UTC " + scenario.Schedule)
+		if err != nil {
+			input.LogEntry.Warnf("schedule (%s) of %s chaos scenario for NodeGroup:%s is invalid: %v", scenario.Schedule, scenario.Type, ng.Name, err)
+			continue
+		}
+
+		key := chaosScheduleKey(ng.Name, scenario)
+		lastRun, checked := schedules.lastRun(key)
+		if !checked {
+			// the schedule was never checked, only the current minute counts
+			lastRun = now.Truncate(time.Minute).Add(-time.Second)
+		}
+
+		if schedule.Next(lastRun).After(now) {
+			if !checked {
+				schedules.setLastRun(key, now)
+			}
+			continue
+		}
+
+		schedules.setLastRun(key, now)
+		if due == nil {
+			due = &ng.Scenarios[i]
+		}
+	}
+
+	return due, due != nil
+}
+
+// chaosScheduleKey identifies a scheduled scenario of the NodeGroup, a changed schedule is treated as a new one
+func chaosScheduleKey(nodeGroup string, scenario ngv1.ChaosScenario) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", scenario.Type, scenario.Schedule)))
+	return fmt.Sprintf("%s.%x", nodeGroup, sum[:4])
+}
+
+// chaosSchedulesState keeps the last due runs of the scheduled scenarios in a ConfigMap,
+// it is written only when a run is due or a schedule is checked for the first time.
+type chaosSchedulesState struct {
+	lastRuns map[string]time.Time
+	// scheduled scenarios of all NodeGroups, the state of the removed ones is dropped
+	existing map[string]struct{}
+	changed  bool
+}
+
+func newChaosSchedulesState(schedulesSnap, ngsSnap []go_hook.FilterResult) *chaosSchedulesState {
+	state := &chaosSchedulesState{
+		lastRuns: make(map[string]time.Time),
+		existing: make(map[string]struct{}),
+	}
+
+	for _, sn := range schedulesSnap {
+		for key, lastRun := range sn.(map[string]time.Time) {
+			state.lastRuns[key] = lastRun
+		}
+	}
+
+	for _, sn := range ngsSnap {
+		ng := sn.(chaosNodeGroup)
+		for _, scenario := range ng.Scenarios {
+			if scenario.Schedule != "" {
+				state.existing[chaosScheduleKey(ng.Name, scenario)] = struct{}{}
+			}
+		}
+	}
+
+	return state
+}
+
+func (s *chaosSchedulesState) lastRun(key string) (time.Time, bool) {
+	lastRun, ok := s.lastRuns[key]
+	return lastRun, ok
+}
+
+func (s *chaosSchedulesState) setLastRun(key string, now time.Time) {
+	s.lastRuns[key] = now
+	s.changed = true
+}
+
+func (s *chaosSchedulesState) configMap() *corev1.ConfigMap {
+	data := make(map[string]string, len(s.lastRuns))
+	for key, lastRun := range s.lastRuns {
+		if _, ok := s.existing[key]; ok {
+			data[key] = lastRun.Format(time.RFC3339)
+		}
+	}
+
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      chaosMonkeySchedulesState,
+			Namespace: chaosMonkeyNamespace,
+			Labels: map[string]string{
+				"app":      "chaos-monkey",
+				"heritage": "deckhouse",
+			},
+		},
+		Data: data,
+	}
+}
+
+// randomActions returns actions which are triggered randomly once per chaos period, nil means DrainAndDelete.
+func (ng chaosNodeGroup) randomActions() []*ngv1.ChaosScenario {
+	actions := make([]*ngv1.ChaosScenario, 0, len(ng.Scenarios)+1)
+
+	if ng.ChaosMode == "DrainAndDelete" {
+		actions = append(actions, nil)
+	}
+
+	for i, scenario := range ng.Scenarios {
+		if scenario.Schedule != "" {
+			continue
+		}
+		actions = append(actions, &ng.Scenarios[i])
+	}
+
+	return actions
+}
+
+type chaosJob struct {
+	Name  string
+	Until time.Time
 }
 
 type chaosMachine struct {
@@ -271,3 +569,158 @@ var (
 		},
 	}
 )
+
+func chaosMonkeyImage(input *go_hook.HookInput) string {
+	registry := input.Values.Get("global.modulesImages.registry.base").String()
+	digest := input.Values.Get("global.modulesImages.digests.common.alpine").String()
+	if registry == "" || digest == "" {
+		return ""
+	}
+
+	return registry + "@" + digest
+}
+
+func chaosScenarioHasDuration(scenarioType ngv1.ChaosScenarioType) bool {
+	return scenarioType != ngv1.ChaosScenarioContainerRuntimeRestart
+}
+
+// chaosScenarioScript returns a script executed in the host namespaces of the node.
+// Recovery is delegated to systemd timers, so the node recovers even if the job Pod is gone.
+func chaosScenarioScript(unit string, scenario ngv1.ChaosScenario, duration time.Duration) string {
+	seconds := int64(duration.Seconds())
+
+	switch scenario.Type {
+	case ngv1.ChaosScenarioKubeletStop:
+		return fmt.Sprintf("systemd-run --unit=%s --on-active=%d systemctl start kubelet\nsystemctl stop kubelet", unit, seconds)
+
+	case ngv1.ChaosScenarioNetworkPartition:
+		return strings.Join([]string{
+			fmt.Sprintf("systemd-run --unit=%s --on-active=%d bash -c 'iptables -w -D INPUT -j D8-CHAOS-MONKEY-IN; iptables -w -D OUTPUT -j D8-CHAOS-MONKEY-OUT; iptables -w -F D8-CHAOS-MONKEY-IN; iptables -w -F D8-CHAOS-MONKEY-OUT; iptables -w -X D8-CHAOS-MONKEY-IN; iptables -w -X D8-CHAOS-MONKEY-OUT'", unit, seconds),
+			"iptables -w -N D8-CHAOS-MONKEY-IN",
+			"iptables -w -A D8-CHAOS-MONKEY-IN -i lo -j ACCEPT",
+			"iptables -w -A D8-CHAOS-MONKEY-IN -p tcp --dport 22 -j ACCEPT",
+			"iptables -w -A D8-CHAOS-MONKEY-IN -j DROP",
+			"iptables -w -N D8-CHAOS-MONKEY-OUT",
+			"iptables -w -A D8-CHAOS-MONKEY-OUT -o lo -j ACCEPT",
+			"iptables -w -A D8-CHAOS-MONKEY-OUT -p tcp --sport 22 -j ACCEPT",
+			"iptables -w -A D8-CHAOS-MONKEY-OUT -j DROP",
+			"iptables -w -I INPUT 1 -j D8-CHAOS-MONKEY-IN",
+			"iptables -w -I OUTPUT 1 -j D8-CHAOS-MONKEY-OUT",
+		}, "\n")
+
+	case ngv1.ChaosScenarioStress:
+		cpu := int32(1)
+		var memory int64
+		if scenario.Stress != nil {
+			if scenario.Stress.CPU != nil {
+				cpu = *scenario.Stress.CPU
+			}
+			if scenario.Stress.Memory != nil {
+				memory = scenario.Stress.Memory.Value()
+			}
+		}
+
+		workers := make([]string, 0, 2)
+		if cpu > 0 {
+			workers = append(workers, fmt.Sprintf("for i in $(seq 1 %d); do (while :; do :; done) & done", cpu))
+		}
+		if memory > 0 {
+			workers = append(workers, fmt.Sprintf("(head -c %d /dev/zero | tail) &", memory))
+		}
+		workers = append(workers, "wait")
+
+		return fmt.Sprintf("systemd-run --unit=%s --property=RuntimeMaxSec=%d bash -c '%s'", unit, seconds, strings.Join(workers, "; "))
+
+	case ngv1.ChaosScenarioContainerRuntimeRestart:
+		return "if systemctl is-active -q containerd; then systemctl restart containerd; else systemctl restart docker; fi"
+	}
+
+	return ""
+}
+
+func chaosScenarioJob(image, nodeGroup, nodeName string, scenario ngv1.ChaosScenario, duration time.Duration, now time.Time) *batchv1.Job {
+	name := fmt.Sprintf("chaos-monkey-%x", sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%d", nodeName, scenario.Type, now.Unix()))))[:23]
+
+	until := now
+	if chaosScenarioHasDuration(scenario.Type) {
+		until = now.Add(duration)
+	}
+
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: chaosMonkeyNamespace,
+			Labels: map[string]string{
+				"app":                     "chaos-monkey",
+				"node.deckhouse.io/group": nodeGroup,
+				chaosMonkeyScenarioLabel:  string(scenario.Type),
+			},
+			Annotations: map[string]string{
+				chaosMonkeyUntilAnnotation: until.Format(time.RFC3339),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(0),
+			// job is kept for a day to be used as a chaos log
+			TTLSecondsAfterFinished: pointer.Int32(int32((24 * time.Hour).Seconds())),
+			ActiveDeadlineSeconds:   pointer.Int64(int64((duration + 10*time.Minute).Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app": "chaos-monkey",
+					},
+				},
+				Spec: corev1.PodSpec{
+					NodeName:                     nodeName,
+					HostPID:                      true,
+					HostNetwork:                  true,
+					RestartPolicy:                corev1.RestartPolicyNever,
+					AutomountServiceAccountToken: pointer.Bool(false),
+					ImagePullSecrets:             []corev1.LocalObjectReference{{Name: "deckhouse-registry"}},
+					Tolerations:                  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+					Containers: []corev1.Container{
+						{
+							Name:    "chaos-monkey",
+							Image:   image,
+							Command: []string{"nsenter", "-t", "1", "-m", "-u", "-i", "-n", "-p", "--", "bash", "-ec", chaosScenarioScript(name, scenario, duration)},
+							SecurityContext: &corev1.SecurityContext{
+								Privileged: pointer.Bool(true),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func chaosEvent(ng chaosNodeGroup, msg string, now time.Time) *eventsv1.Event {
+	return &eventsv1.Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: "events.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			// 'default' namespace is used for linking this event with a NodeGroup object, which is global
+			Namespace:    "default",
+			GenerateName: "ng-" + ng.Name + "-",
+		},
+		Regarding: corev1.ObjectReference{
+			Kind:       "NodeGroup",
+			Name:       ng.Name,
+			UID:        ng.UID,
+			APIVersion: "deckhouse.io/v1",
+		},
+		Reason:              "ChaosMonkey",
+		Note:                msg,
+		Type:                corev1.EventTypeNormal,
+		EventTime:           metav1.MicroTime{Time: now},
+		Action:              "Binding",
+		ReportingInstance:   "deckhouse",
+		ReportingController: "deckhouse",
+	}
+}
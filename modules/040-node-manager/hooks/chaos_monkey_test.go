@@ -17,11 +17,16 @@ limitations under the License.
 package hooks
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	ngv1 "github.com/deckhouse/deckhouse/modules/040-node-manager/hooks/internal/v1"
 	. "github.com/deckhouse/deckhouse/testing/hooks"
 )
 
@@ -297,3 +302,341 @@ metadata:
 		})
 	}
 })
+
+var _ = Describe("Modules :: node-manager :: hooks :: chaos_monkey :: scenarios ::", func() {
+	const (
+		stateNodes = `
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: node1
+  labels:
+    node.deckhouse.io/group: largeng
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: node2
+  labels:
+    node.deckhouse.io/group: largeng
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: node3
+  labels:
+    node.deckhouse.io/group: largeng
+`
+		stateMachines = `
+---
+apiVersion: machine.sapcloud.io/v1alpha1
+kind: Machine
+metadata:
+  name: node1
+  namespace: d8-cloud-instance-manager
+  labels:
+    node: node1
+---
+apiVersion: machine.sapcloud.io/v1alpha1
+kind: Machine
+metadata:
+  name: node2
+  namespace: d8-cloud-instance-manager
+  labels:
+    node: node2
+---
+apiVersion: machine.sapcloud.io/v1alpha1
+kind: Machine
+metadata:
+  name: node3
+  namespace: d8-cloud-instance-manager
+  labels:
+    node: node3
+`
+		stateNGScheduledScenario = `
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: largeng
+spec:
+  nodeType: CloudEphemeral
+  chaos:
+    scenarios:
+    - type: KubeletStop
+      duration: 10m
+      schedule: "* * * * *"
+status:
+  desired: 3
+  ready: 3
+`
+		stateNGMissedScenario = `
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: largeng
+spec:
+  nodeType: CloudEphemeral
+  chaos:
+    scenarios:
+    - type: KubeletStop
+      schedule: "0 0 1 1 *"
+status:
+  desired: 3
+  ready: 3
+`
+		stateNGAlreadyRunScenario = `
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: largeng
+spec:
+  nodeType: CloudEphemeral
+  chaos:
+    scenarios:
+    - type: KubeletStop
+      schedule: "* * * * *"
+status:
+  desired: 3
+  ready: 3
+`
+		stateNGExcludedScenario = `
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: largeng
+spec:
+  nodeType: CloudEphemeral
+  chaos:
+    scenarios:
+    - type: KubeletStop
+      schedule: "* * * * *"
+    exclusionWindows:
+    - from: "00:00"
+      to: "23:59"
+status:
+  desired: 3
+  ready: 3
+`
+		stateNGDisabledScenario = `
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: largeng
+spec:
+  nodeType: CloudEphemeral
+  chaos:
+    mode: Disabled
+    scenarios:
+    - type: KubeletStop
+      schedule: "* * * * *"
+status:
+  desired: 3
+  ready: 3
+`
+		stateNGDrainAndDelete = `
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: largeng
+spec:
+  nodeType: CloudEphemeral
+  chaos:
+    mode: DrainAndDelete
+    period: 5m
+status:
+  desired: 3
+  ready: 3
+`
+		stateSchedules = `
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: chaos-monkey-schedules
+  namespace: d8-cloud-instance-manager
+data:
+  %s: "%s"
+`
+		stateJobInProgress = `
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: chaos-monkey-0123456789
+  namespace: d8-cloud-instance-manager
+  labels:
+    app: chaos-monkey
+  annotations:
+    node.deckhouse.io/chaos-monkey-until: "2999-01-01T00:00:00Z"
+`
+	)
+
+	f := HookExecutionConfigInit(`{"global":{"modulesImages":{"registry":{"base":"registry.example.com/deckhouse"},"digests":{"common":{"alpine":"sha256:0123"}}}},"nodeManager":{"internal": {}}}`, `{}`)
+	f.RegisterCRD("deckhouse.io", "v1", "NodeGroup", false)
+	f.RegisterCRD("machine.sapcloud.io", "v1alpha1", "Machine", true)
+
+	// Wednesday, the middle of the minute
+	testNow := time.Date(2023, time.June, 14, 10, 0, 30, 0, time.UTC)
+
+	BeforeEach(func() {
+		chaosNow = func() time.Time { return testNow }
+	})
+
+	AfterEach(func() {
+		chaosNow = time.Now
+	})
+
+	chaosJobs := func() []map[string]interface{} {
+		gvr := schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+		list, err := f.KubeClient().Dynamic().Resource(gvr).Namespace("d8-cloud-instance-manager").List(context.TODO(), metav1.ListOptions{LabelSelector: "app=chaos-monkey"})
+		Expect(err).ToNot(HaveOccurred())
+
+		jobs := make([]map[string]interface{}, 0, len(list.Items))
+		for _, item := range list.Items {
+			jobs = append(jobs, item.Object)
+		}
+		return jobs
+	}
+
+	Context("NodeGroup with scheduled scenario", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateNGScheduledScenario + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Job for the scenario must be created", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			jobs := chaosJobs()
+			Expect(jobs).To(HaveLen(1))
+
+			job := f.KubernetesResource("Job", "d8-cloud-instance-manager", jobs[0]["metadata"].(map[string]interface{})["name"].(string))
+			Expect(job.Field(`metadata.labels.node\.deckhouse\.io/chaos-monkey-scenario`).String()).To(Equal("KubeletStop"))
+			Expect(job.Field(`metadata.labels.node\.deckhouse\.io/group`).String()).To(Equal("largeng"))
+			Expect(job.Field(`metadata.annotations.node\.deckhouse\.io/chaos-monkey-until`).Exists()).To(BeTrue())
+			Expect(job.Field("spec.template.spec.nodeName").String()).To(BeElementOf("node1", "node2", "node3"))
+			Expect(job.Field("spec.template.spec.containers.0.image").String()).To(Equal("registry.example.com/deckhouse@sha256:0123"))
+			Expect(job.Field("spec.template.spec.containers.0.command.11").String()).To(ContainSubstring("--on-active=600 systemctl start kubelet"))
+
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "node1").Exists()).To(BeTrue())
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "node2").Exists()).To(BeTrue())
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "node3").Exists()).To(BeTrue())
+
+			key := chaosScheduleKey("largeng", ngv1.ChaosScenario{Type: ngv1.ChaosScenarioKubeletStop, Schedule: "* * * * *"})
+			schedules := f.KubernetesResource("ConfigMap", "d8-cloud-instance-manager", "chaos-monkey-schedules")
+			Expect(schedules.Field("data").Map()).To(HaveLen(1))
+			Expect(schedules.Field("data").Map()[key].String()).To(Equal("2023-06-14T10:00:30Z"))
+
+			Expect(f.KubernetesGlobalResource("NodeGroup", "largeng").Field("metadata.annotations").Exists()).To(BeFalse())
+		})
+	})
+
+	Context("NodeGroup with scheduled scenario missed since the last run", func() {
+		BeforeEach(func() {
+			key := chaosScheduleKey("largeng", ngv1.ChaosScenario{Type: ngv1.ChaosScenarioKubeletStop, Schedule: "0 0 1 1 *"})
+			f.KubeStateSet(stateNGMissedScenario + stateNodes + stateMachines + fmt.Sprintf(stateSchedules, key, "2000-01-01T00:00:00Z"))
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Job for the scenario must be created and the last run must be updated", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(chaosJobs()).To(HaveLen(1))
+
+			key := chaosScheduleKey("largeng", ngv1.ChaosScenario{Type: ngv1.ChaosScenarioKubeletStop, Schedule: "0 0 1 1 *"})
+			schedules := f.KubernetesResource("ConfigMap", "d8-cloud-instance-manager", "chaos-monkey-schedules")
+			Expect(schedules.Field("data").Map()[key].String()).To(Equal("2023-06-14T10:00:30Z"))
+		})
+	})
+
+	Context("NodeGroup with scheduled scenario which has already run", func() {
+		BeforeEach(func() {
+			key := chaosScheduleKey("largeng", ngv1.ChaosScenario{Type: ngv1.ChaosScenarioKubeletStop, Schedule: "* * * * *"})
+			f.KubeStateSet(stateNGAlreadyRunScenario + stateNodes + stateMachines + fmt.Sprintf(stateSchedules, key, "2023-06-14T10:00:05Z"))
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Job must not be created and the state must be kept", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(chaosJobs()).To(BeEmpty())
+
+			key := chaosScheduleKey("largeng", ngv1.ChaosScenario{Type: ngv1.ChaosScenarioKubeletStop, Schedule: "* * * * *"})
+			schedules := f.KubernetesResource("ConfigMap", "d8-cloud-instance-manager", "chaos-monkey-schedules")
+			Expect(schedules.Field("data").Map()[key].String()).To(Equal("2023-06-14T10:00:05Z"))
+		})
+	})
+
+	Context("NodeGroup with scheduled scenario in exclusion window", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateNGExcludedScenario + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Job must not be created", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(chaosJobs()).To(BeEmpty())
+		})
+	})
+
+	Context("NodeGroup with scheduled scenario in the last minute of exclusion window", func() {
+		BeforeEach(func() {
+			// the window ends at 23:59, the whole minute is excluded
+			chaosNow = func() time.Time { return time.Date(2023, time.June, 14, 23, 59, 30, 0, time.UTC) }
+			f.KubeStateSet(stateNGExcludedScenario + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Job must not be created", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(chaosJobs()).To(BeEmpty())
+		})
+	})
+
+	Context("NodeGroup with disabled chaos monkey and scenario", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateNGDisabledScenario + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Job must not be created", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(chaosJobs()).To(BeEmpty())
+		})
+	})
+
+	Context("Chaos scenario is in progress", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateNGDrainAndDelete + stateNodes + stateMachines + stateJobInProgress)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.AddHookEnv("D8_TEST_RANDOM_SEED=11")
+			f.RunHook()
+		})
+
+		It("Hook is lucky to run monkey. All machines must survive.", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "node1").Exists()).To(BeTrue())
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "node2").Exists()).To(BeTrue())
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "node3").Exists()).To(BeTrue())
+			Expect(chaosJobs()).To(HaveLen(1))
+		})
+	})
+})
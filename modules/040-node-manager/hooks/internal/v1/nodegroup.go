@@ -93,6 +93,9 @@ type NodeGroupSpec struct {
 
 	// Kubelet settings for nodes. Optional.
 	Kubelet Kubelet `json:"kubelet,omitempty"`
+
+	// Remediation settings for nodes with persistent problems. Optional.
+	Remediation Remediation `json:"remediation,omitempty"`
 }
 
 type CRI struct {
@@ -239,10 +242,50 @@ type Chaos struct {
 
 	// Chaos monkey wake up period. Default is 6h.
 	Period string `json:"period,omitempty"`
+
+	// Chaos scenarios executed on nodes. Optional.
+	Scenarios []ChaosScenario `json:"scenarios,omitempty"`
+
+	// Time windows when chaos monkey does nothing. Optional.
+	ExclusionWindows update.Windows `json:"exclusionWindows,omitempty"`
 }
 
 func (c Chaos) IsEmpty() bool {
-	return c.Mode == "" && c.Period == ""
+	return c.Mode == "" && c.Period == "" && len(c.Scenarios) == 0 && len(c.ExclusionWindows) == 0
+}
+
+// ChaosScenarioType is a type of chaos scenario.
+type ChaosScenarioType string
+
+const (
+	ChaosScenarioKubeletStop             ChaosScenarioType = "KubeletStop"
+	ChaosScenarioNetworkPartition        ChaosScenarioType = "NetworkPartition"
+	ChaosScenarioStress                  ChaosScenarioType = "Stress"
+	ChaosScenarioContainerRuntimeRestart ChaosScenarioType = "ContainerRuntimeRestart"
+)
+
+// ChaosScenario is a chaos scenario executed on a random node of the NodeGroup.
+type ChaosScenario struct {
+	// Type of scenario: KubeletStop, NetworkPartition, Stress or ContainerRuntimeRestart.
+	Type ChaosScenarioType `json:"type"`
+
+	// Duration of the scenario. Default is 5m.
+	Duration string `json:"duration,omitempty"`
+
+	// Cron schedule (UTC) of the scenario. If empty, the scenario runs randomly once per chaos period.
+	Schedule string `json:"schedule,omitempty"`
+
+	// Stress scenario settings.
+	Stress *ChaosStress `json:"stress,omitempty"`
+}
+
+// ChaosStress is a settings of the Stress chaos scenario.
+type ChaosStress struct {
+	// Number of CPU workers.
+	CPU *int32 `json:"cpu,omitempty"`
+
+	// Amount of memory to allocate.
+	Memory *resource.Quantity `json:"memory,omitempty"`
 }
 
 type OperatingSystem struct {
@@ -263,10 +306,21 @@ type Disruptions struct {
 	Automatic AutomaticDisruptions `json:"automatic,omitempty"`
 	// Extra settings for RolloutRestart mode.
 	RollingUpdate RollingUpdateDisruptions `json:"rollingUpdate,omitempty"`
+	// Limits for nodes disrupted simultaneously.
+	Budget *DisruptionBudget `json:"budget,omitempty"`
 }
 
 func (d Disruptions) IsEmpty() bool {
-	return d.ApprovalMode == "" && d.Automatic.IsEmpty()
+	return d.ApprovalMode == "" && d.Automatic.IsEmpty() && d.Budget == nil
+}
+
+type DisruptionBudget struct {
+	// Maximum amount of unavailable nodes in the group: a number or a percentage.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// Maximum amount of unavailable nodes in each zone of the group: a number or a percentage.
+	MaxUnavailablePerZone *intstr.IntOrString `json:"maxUnavailablePerZone,omitempty"`
+	// Indicates if PodDisruptionBudgets of Pods on the node should be checked before disruption approval.
+	RespectPodDisruptionBudgets bool `json:"respectPodDisruptionBudgets,omitempty"`
 }
 
 type Update struct {
@@ -293,6 +347,59 @@ func (r RollingUpdateDisruptions) IsEmpty() bool {
 	return len(r.Windows) == 0
 }
 
+// RemediationCondition is a node problem to remediate.
+type RemediationCondition string
+
+const (
+	RemediationConditionNotReady       RemediationCondition = "NotReady"
+	RemediationConditionDiskPressure   RemediationCondition = "DiskPressure"
+	RemediationConditionMemoryPressure RemediationCondition = "MemoryPressure"
+	RemediationConditionPIDPressure    RemediationCondition = "PIDPressure"
+	RemediationConditionKernelDeadlock RemediationCondition = "KernelDeadlock"
+)
+
+// RemediationAction is an action taken on a node with a problem.
+type RemediationAction string
+
+const (
+	RemediationActionCordon         RemediationAction = "Cordon"
+	RemediationActionDrain          RemediationAction = "Drain"
+	RemediationActionReboot         RemediationAction = "Reboot"
+	RemediationActionReplaceMachine RemediationAction = "ReplaceMachine"
+)
+
+// Remediation is a node remediation settings.
+type Remediation struct {
+	// Remediation rules.
+	Rules []RemediationRule `json:"rules,omitempty"`
+
+	// Remediation rate limit for the NodeGroup.
+	RateLimit RemediationRateLimit `json:"rateLimit,omitempty"`
+}
+
+func (r Remediation) IsEmpty() bool {
+	return len(r.Rules) == 0
+}
+
+type RemediationRule struct {
+	// Node problem: NotReady, DiskPressure, MemoryPressure, PIDPressure or KernelDeadlock.
+	Condition RemediationCondition `json:"condition"`
+
+	// How long the problem must persist before the action is taken. Default is 10m.
+	For string `json:"for,omitempty"`
+
+	// Action: Cordon, Drain, Reboot or ReplaceMachine.
+	Action RemediationAction `json:"action"`
+}
+
+type RemediationRateLimit struct {
+	// Maximum number of remediations during the period. Default is 1.
+	MaxRemediations *int32 `json:"maxRemediations,omitempty"`
+
+	// Rate limit period. Default is 1h.
+	Period string `json:"period,omitempty"`
+}
+
 type Kubelet struct {
 	// Set the max count of pods per node. Default: 110
 	MaxPods *int32 `json:"maxPods,omitempty"`
@@ -424,6 +531,40 @@ type NodeGroupStatus struct {
 
 	// Current nodegroup conditions
 	Conditions []NodeGroupCondition `json:"conditions,omitempty"`
+
+	// Nodes waiting for update in the expected update order.
+	UpdatePlan []UpdatePlanNode `json:"updatePlan,omitempty"`
+
+	// Last remediations taken on nodes of the group.
+	Remediations []RemediationRecord `json:"remediations,omitempty"`
+}
+
+type RemediationRecord struct {
+	// Node's name.
+	Node string `json:"node"`
+
+	// Node problem.
+	Condition RemediationCondition `json:"condition"`
+
+	// Action taken.
+	Action RemediationAction `json:"action"`
+
+	// Time of the remediation.
+	Time metav1.Time `json:"time"`
+}
+
+type UpdatePlanNode struct {
+	// Node's name.
+	Name string `json:"name"`
+
+	// Node's zone.
+	Zone string `json:"zone,omitempty"`
+
+	// Node's update status.
+	Status string `json:"status"`
+
+	// The reason why the node update is postponed.
+	BlockedBy string `json:"blockedBy,omitempty"`
 }
 
 type MachineFailure struct {
@@ -81,6 +81,14 @@ func (in *CRI) DeepCopy() *CRI {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Chaos) DeepCopyInto(out *Chaos) {
 	*out = *in
+	if in.Scenarios != nil {
+		in, out := &in.Scenarios, &out.Scenarios
+		*out = make([]ChaosScenario, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.ExclusionWindows = in.ExclusionWindows.DeepCopy()
 	return
 }
 
@@ -94,6 +102,53 @@ func (in *Chaos) DeepCopy() *Chaos {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChaosScenario) DeepCopyInto(out *ChaosScenario) {
+	*out = *in
+	if in.Stress != nil {
+		in, out := &in.Stress, &out.Stress
+		*out = new(ChaosStress)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChaosScenario.
+func (in *ChaosScenario) DeepCopy() *ChaosScenario {
+	if in == nil {
+		return nil
+	}
+	out := new(ChaosScenario)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChaosStress) DeepCopyInto(out *ChaosStress) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChaosStress.
+func (in *ChaosStress) DeepCopy() *ChaosStress {
+	if in == nil {
+		return nil
+	}
+	out := new(ChaosStress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClassReference) DeepCopyInto(out *ClassReference) {
 	*out = *in
@@ -210,6 +265,11 @@ func (in *Disruptions) DeepCopyInto(out *Disruptions) {
 	*out = *in
 	in.Automatic.DeepCopyInto(&out.Automatic)
 	in.RollingUpdate.DeepCopyInto(&out.RollingUpdate)
+	if in.Budget != nil {
+		in, out := &in.Budget, &out.Budget
+		*out = new(DisruptionBudget)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -223,6 +283,32 @@ func (in *Disruptions) DeepCopy() *Disruptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DisruptionBudget) DeepCopyInto(out *DisruptionBudget) {
+	*out = *in
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailablePerZone != nil {
+		in, out := &in.MaxUnavailablePerZone, &out.MaxUnavailablePerZone
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DisruptionBudget.
+func (in *DisruptionBudget) DeepCopy() *DisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(DisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Docker) DeepCopyInto(out *Docker) {
 	*out = *in
@@ -387,11 +473,12 @@ func (in *NodeGroupSpec) DeepCopyInto(out *NodeGroupSpec) {
 	in.CRI.DeepCopyInto(&out.CRI)
 	in.CloudInstances.DeepCopyInto(&out.CloudInstances)
 	in.NodeTemplate.DeepCopyInto(&out.NodeTemplate)
-	out.Chaos = in.Chaos
+	in.Chaos.DeepCopyInto(&out.Chaos)
 	in.OperatingSystem.DeepCopyInto(&out.OperatingSystem)
 	in.Disruptions.DeepCopyInto(&out.Disruptions)
 	in.Update.DeepCopyInto(&out.Update)
 	in.Kubelet.DeepCopyInto(&out.Kubelet)
+	in.Remediation.DeepCopyInto(&out.Remediation)
 	return
 }
 
@@ -421,6 +508,18 @@ func (in *NodeGroupStatus) DeepCopyInto(out *NodeGroupStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.UpdatePlan != nil {
+		in, out := &in.UpdatePlan, &out.UpdatePlan
+		*out = make([]UpdatePlanNode, len(*in))
+		copy(*out, *in)
+	}
+	if in.Remediations != nil {
+		in, out := &in.Remediations, &out.Remediations
+		*out = make([]RemediationRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -494,6 +593,82 @@ func (in *Resources) DeepCopy() *Resources {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Remediation) DeepCopyInto(out *Remediation) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RemediationRule, len(*in))
+		copy(*out, *in)
+	}
+	in.RateLimit.DeepCopyInto(&out.RateLimit)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Remediation.
+func (in *Remediation) DeepCopy() *Remediation {
+	if in == nil {
+		return nil
+	}
+	out := new(Remediation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationRateLimit) DeepCopyInto(out *RemediationRateLimit) {
+	*out = *in
+	if in.MaxRemediations != nil {
+		in, out := &in.MaxRemediations, &out.MaxRemediations
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationRateLimit.
+func (in *RemediationRateLimit) DeepCopy() *RemediationRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationRecord) DeepCopyInto(out *RemediationRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationRecord.
+func (in *RemediationRecord) DeepCopy() *RemediationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemediationRule) DeepCopyInto(out *RemediationRule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemediationRule.
+func (in *RemediationRule) DeepCopy() *RemediationRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RemediationRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpdateDisruptions) DeepCopyInto(out *RollingUpdateDisruptions) {
 	*out = *in
@@ -553,3 +728,19 @@ func (in *Update) DeepCopy() *Update {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdatePlanNode) DeepCopyInto(out *UpdatePlanNode) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdatePlanNode.
+func (in *UpdatePlanNode) DeepCopy() *UpdatePlanNode {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdatePlanNode)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,503 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
+	"github.com/flant/addon-operator/sdk"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	"github.com/deckhouse/deckhouse/modules/040-node-manager/hooks/internal/mcm/v1alpha1"
+	ngv1 "github.com/deckhouse/deckhouse/modules/040-node-manager/hooks/internal/v1"
+)
+
+const (
+	// remediationAnnotationKey keeps the last rule applied to the node as "<condition>/<action>".
+	remediationAnnotationKey     = "node.deckhouse.io/remediation"
+	rebootRequestedAnnotationKey = "node.deckhouse.io/reboot-requested"
+	// remediationCordonedAnnotationKey marks nodes cordoned by the remediation, only they are uncordoned on recovery.
+	remediationCordonedAnnotationKey = "node.deckhouse.io/remediation-cordoned"
+	remediationDrainingSource        = "remediation"
+	remediationDefaultFor            = 10 * time.Minute
+	remediationDefaultPeriod         = time.Hour
+	remediationRecordsMaxAge         = 24 * time.Hour
+	remediationRecordsMaxCount       = 10
+	remediationDefaultMaxPerPeriod   = 1
+)
+
+var _ = sdk.RegisterFunc(&go_hook.HookConfig{
+	Queue: "/modules/node-manager/remediation",
+	Kubernetes: []go_hook.KubernetesConfig{
+		{
+			Name:                         "ngs",
+			ApiVersion:                   "deckhouse.io/v1",
+			Kind:                         "NodeGroup",
+			WaitForSynchronization:       pointer.Bool(false),
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   remediationFilterNodeGroup,
+		},
+		{
+			Name:       "nodes",
+			ApiVersion: "v1",
+			Kind:       "Node",
+			LabelSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "node.deckhouse.io/group",
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			},
+			WaitForSynchronization:       pointer.Bool(false),
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   remediationFilterNode,
+		},
+		{
+			Name:                         "machines",
+			ApiVersion:                   "machine.sapcloud.io/v1alpha1",
+			Kind:                         "Machine",
+			WaitForSynchronization:       pointer.Bool(false),
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   remediationFilterMachine,
+		},
+	},
+	Schedule: []go_hook.ScheduleConfig{
+		{
+			Name:    "remediation",
+			Crontab: "* * * * *",
+		},
+	},
+}, handleNodeRemediation)
+
+func handleNodeRemediation(input *go_hook.HookInput) error {
+	now := time.Now().UTC()
+
+	nodes := make(map[string][]remediationNode)
+	for _, sn := range input.Snapshots["nodes"] {
+		node := sn.(remediationNode)
+		nodes[node.NodeGroup] = append(nodes[node.NodeGroup], node)
+	}
+
+	machines := make(map[string]string) // machine name by node name
+	for _, sn := range input.Snapshots["machines"] {
+		machine := sn.(remediationMachine)
+		machines[machine.Node] = machine.Name
+	}
+
+	for _, sn := range input.Snapshots["ngs"] {
+		ng := sn.(remediationNodeGroup)
+
+		ngNodes := nodes[ng.Name]
+		sort.Slice(ngNodes, func(i, j int) bool { return ngNodes[i].Name < ngNodes[j].Name })
+
+		period, maxRemediations := ng.rateLimit(input)
+		records := ng.actualRecords(now, period)
+		recordsChanged := len(records) != len(ng.Records)
+
+		for _, node := range ngNodes {
+			if !node.hasProblem(ng.Rules) {
+				if node.Remediation != "" {
+					recoverNode(input, ng, node, now)
+				}
+				continue
+			}
+
+			rule := node.matchRule(input, ng.Rules, now)
+			if rule == nil || node.Remediation == remediationKey(*rule) {
+				continue
+			}
+
+			// node is being updated, problems are expected
+			if node.IsDisruptionApproved {
+				continue
+			}
+
+			if countRecordsSince(records, now.Add(-period)) >= maxRemediations {
+				input.LogEntry.Infof("remediation of Node %s (%s/%s) is postponed: NodeGroup %s reached the limit of %d remediations per %s",
+					node.Name, rule.Condition, rule.Action, ng.Name, maxRemediations, period)
+				continue
+			}
+
+			if !applyRemediation(input, ng, node, *rule, machines) {
+				continue
+			}
+
+			records = append(records, ngv1.RemediationRecord{
+				Node:      node.Name,
+				Condition: rule.Condition,
+				Action:    rule.Action,
+				Time:      metav1.NewTime(now),
+			})
+			recordsChanged = true
+
+			msg := fmt.Sprintf("Node %s: %s for %s, action %s is taken", node.Name, rule.Condition, now.Sub(node.problemSince(rule.Condition)).Truncate(time.Second), rule.Action)
+			input.PatchCollector.Create(remediationEvent(ng, node.Name, corev1.EventTypeNormal, "NodeRemediation", msg, now))
+		}
+
+		if !recordsChanged {
+			continue
+		}
+
+		if len(records) > remediationRecordsMaxCount {
+			records = records[len(records)-remediationRecordsMaxCount:]
+		}
+
+		patch := map[string]interface{}{
+			"status": map[string]interface{}{
+				"remediations": records,
+			},
+		}
+		input.PatchCollector.MergePatch(patch, "deckhouse.io/v1", "NodeGroup", "", ng.Name, object_patch.WithSubresource("/status"))
+	}
+
+	return nil
+}
+
+// applyRemediation returns false if the action could not be taken.
+func applyRemediation(input *go_hook.HookInput, ng remediationNodeGroup, node remediationNode, rule ngv1.RemediationRule, machines map[string]string) bool {
+	annotations := map[string]interface{}{
+		remediationAnnotationKey: remediationKey(rule),
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+
+	// the node is cordoned both by the cordon and by the drain, a node cordoned by someone else must stay cordoned after recovery
+	if (rule.Action == ngv1.RemediationActionCordon || rule.Action == ngv1.RemediationActionDrain) && !node.Unschedulable {
+		annotations[remediationCordonedAnnotationKey] = ""
+	}
+
+	switch rule.Action {
+	case ngv1.RemediationActionCordon:
+		patch["spec"] = map[string]interface{}{
+			"unschedulable": true,
+		}
+
+	case ngv1.RemediationActionDrain:
+		annotations[drainingAnnotationKey] = remediationDrainingSource
+
+	case ngv1.RemediationActionReboot:
+		// reboot is performed by bashible with disruption approval
+		annotations[rebootRequestedAnnotationKey] = ""
+
+	case ngv1.RemediationActionReplaceMachine:
+		if ng.NodeType != ngv1.NodeTypeCloudEphemeral {
+			input.LogEntry.Warnf("remediation action %s is not supported for NodeGroup %s with nodeType %s", rule.Action, ng.Name, ng.NodeType)
+			return false
+		}
+
+		machine, ok := machines[node.Name]
+		if !ok {
+			input.LogEntry.Warnf("machine for Node %s is not found, remediation action %s is skipped", node.Name, rule.Action)
+			return false
+		}
+
+		input.PatchCollector.Delete("machine.sapcloud.io/v1alpha1", "Machine", "d8-cloud-instance-manager", machine, object_patch.InBackground())
+
+	default:
+		return false
+	}
+
+	input.PatchCollector.MergePatch(patch, "v1", "Node", "", node.Name)
+
+	return true
+}
+
+// recoverNode reverts cordon and drain made by the remediation.
+func recoverNode(input *go_hook.HookInput, ng remediationNodeGroup, node remediationNode, now time.Time) {
+	annotations := map[string]interface{}{
+		remediationAnnotationKey: nil,
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	}
+
+	action := ngv1.RemediationAction(node.Remediation[strings.LastIndex(node.Remediation, "/")+1:])
+	if node.IsCordonedByRemediation {
+		annotations[remediationCordonedAnnotationKey] = nil
+		patch["spec"] = map[string]interface{}{
+			"unschedulable": nil,
+		}
+	}
+	if action == ngv1.RemediationActionReboot {
+		annotations[rebootRequestedAnnotationKey] = nil
+	}
+	if node.DrainingSource == remediationDrainingSource {
+		annotations[drainingAnnotationKey] = nil
+	}
+	if node.DrainedSource == remediationDrainingSource {
+		annotations[drainedAnnotationKey] = nil
+	}
+
+	input.PatchCollector.MergePatch(patch, "v1", "Node", "", node.Name)
+
+	msg := fmt.Sprintf("Node %s: problem is gone, remediation %s is finished", node.Name, node.Remediation)
+	input.PatchCollector.Create(remediationEvent(ng, node.Name, corev1.EventTypeNormal, "NodeRecovered", msg, now))
+}
+
+func remediationKey(rule ngv1.RemediationRule) string {
+	return fmt.Sprintf("%s/%s", rule.Condition, rule.Action)
+}
+
+func countRecordsSince(records []ngv1.RemediationRecord, since time.Time) int32 {
+	var count int32
+	for _, record := range records {
+		if record.Time.Time.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
+func remediationFilterNodeGroup(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var ng ngv1.NodeGroup
+
+	err := sdk.FromUnstructured(obj, &ng)
+	if err != nil {
+		return nil, err
+	}
+
+	return remediationNodeGroup{
+		Name:      ng.Name,
+		UID:       ng.UID,
+		NodeType:  ng.Spec.NodeType,
+		Rules:     ng.Spec.Remediation.Rules,
+		RateLimit: ng.Spec.Remediation.RateLimit,
+		Records:   ng.Status.Remediations,
+	}, nil
+}
+
+func remediationFilterNode(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var node corev1.Node
+
+	err := sdk.FromUnstructured(obj, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make(map[corev1.NodeConditionType]remediationNodeCondition, len(node.Status.Conditions))
+	for _, cond := range node.Status.Conditions {
+		conditions[cond.Type] = remediationNodeCondition{
+			Status:             cond.Status,
+			LastTransitionTime: cond.LastTransitionTime.Time,
+		}
+	}
+
+	_, isDisruptionApproved := node.Annotations["update.node.deckhouse.io/disruption-approved"]
+	_, isCordonedByRemediation := node.Annotations[remediationCordonedAnnotationKey]
+
+	return remediationNode{
+		Name:                    node.Name,
+		NodeGroup:               node.Labels["node.deckhouse.io/group"],
+		Conditions:              conditions,
+		Remediation:             node.Annotations[remediationAnnotationKey],
+		DrainingSource:          node.Annotations[drainingAnnotationKey],
+		DrainedSource:           node.Annotations[drainedAnnotationKey],
+		Unschedulable:           node.Spec.Unschedulable,
+		IsDisruptionApproved:    isDisruptionApproved,
+		IsCordonedByRemediation: isCordonedByRemediation,
+	}, nil
+}
+
+func remediationFilterMachine(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var machine v1alpha1.Machine
+
+	err := sdk.FromUnstructured(obj, &machine)
+	if err != nil {
+		return nil, err
+	}
+
+	return remediationMachine{
+		Name: machine.Name,
+		Node: machine.Labels["node"],
+	}, nil
+}
+
+type remediationNodeGroup struct {
+	Name      string
+	UID       k8stypes.UID
+	NodeType  ngv1.NodeType
+	Rules     []ngv1.RemediationRule
+	RateLimit ngv1.RemediationRateLimit
+	Records   []ngv1.RemediationRecord
+}
+
+func (ng remediationNodeGroup) rateLimit(input *go_hook.HookInput) (time.Duration, int32) {
+	period := remediationDefaultPeriod
+	if ng.RateLimit.Period != "" {
+		p, err := time.ParseDuration(ng.RateLimit.Period)
+		if err != nil {
+			input.LogEntry.Warnf("remediation rate limit period (%s) for NodeGroup:%s is invalid", ng.RateLimit.Period, ng.Name)
+		} else {
+			period = p
+		}
+	}
+
+	maxRemediations := int32(remediationDefaultMaxPerPeriod)
+	if ng.RateLimit.MaxRemediations != nil {
+		maxRemediations = *ng.RateLimit.MaxRemediations
+	}
+
+	return period, maxRemediations
+}
+
+// actualRecords drops records which are not needed for the rate limit and history anymore.
+func (ng remediationNodeGroup) actualRecords(now time.Time, period time.Duration) []ngv1.RemediationRecord {
+	maxAge := remediationRecordsMaxAge
+	if period > maxAge {
+		maxAge = period
+	}
+
+	records := make([]ngv1.RemediationRecord, 0, len(ng.Records))
+	for _, record := range ng.Records {
+		if record.Time.Time.After(now.Add(-maxAge)) {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+type remediationNodeCondition struct {
+	Status             corev1.ConditionStatus
+	LastTransitionTime time.Time
+}
+
+type remediationNode struct {
+	Name                    string
+	NodeGroup               string
+	Conditions              map[corev1.NodeConditionType]remediationNodeCondition
+	Remediation             string
+	DrainingSource          string
+	DrainedSource           string
+	Unschedulable           bool
+	IsDisruptionApproved    bool
+	IsCordonedByRemediation bool
+}
+
+// problemSince returns zero time if the node doesn't have the problem.
+func (n remediationNode) problemSince(condition ngv1.RemediationCondition) time.Time {
+	if condition == ngv1.RemediationConditionNotReady {
+		cond, ok := n.Conditions[corev1.NodeReady]
+		if !ok || cond.Status == corev1.ConditionTrue {
+			return time.Time{}
+		}
+		return cond.LastTransitionTime
+	}
+
+	cond, ok := n.Conditions[corev1.NodeConditionType(condition)]
+	if !ok || cond.Status != corev1.ConditionTrue {
+		return time.Time{}
+	}
+	return cond.LastTransitionTime
+}
+
+func (n remediationNode) hasProblem(rules []ngv1.RemediationRule) bool {
+	for _, rule := range rules {
+		if !n.problemSince(rule.Condition).IsZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRule returns the rule with the longest satisfied duration, so rules can escalate actions.
+func (n remediationNode) matchRule(input *go_hook.HookInput, rules []ngv1.RemediationRule, now time.Time) *ngv1.RemediationRule {
+	var (
+		matched    *ngv1.RemediationRule
+		matchedFor time.Duration
+	)
+
+	for i, rule := range rules {
+		since := n.problemSince(rule.Condition)
+		if since.IsZero() {
+			continue
+		}
+
+		ruleFor := remediationDefaultFor
+		if rule.For != "" {
+			d, err := time.ParseDuration(rule.For)
+			if err != nil {
+				input.LogEntry.Warnf("remediation duration (%s) for %s condition is invalid", rule.For, rule.Condition)
+				continue
+			}
+			ruleFor = d
+		}
+
+		if now.Sub(since) < ruleFor {
+			continue
+		}
+
+		if matched == nil || ruleFor > matchedFor {
+			matched = &rules[i]
+			matchedFor = ruleFor
+		}
+	}
+
+	return matched
+}
+
+type remediationMachine struct {
+	Name string
+	Node string
+}
+
+// remediationEvent is named like events of client-go recorders, a node gets at most one event per run.
+func remediationEvent(ng remediationNodeGroup, nodeName, eventType, reason, msg string, now time.Time) *eventsv1.Event {
+	return &eventsv1.Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: "events.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			// 'default' namespace is used for linking this event with a NodeGroup object, which is global
+			Namespace: "default",
+			Name:      fmt.Sprintf("%s.%s.%x", ng.Name, nodeName, now.UnixNano()),
+		},
+		Regarding: corev1.ObjectReference{
+			Kind:       "NodeGroup",
+			Name:       ng.Name,
+			UID:        ng.UID,
+			APIVersion: "deckhouse.io/v1",
+		},
+		Reason:              reason,
+		Note:                msg,
+		Type:                eventType,
+		EventTime:           metav1.MicroTime{Time: now},
+		Action:              "Binding",
+		ReportingInstance:   "deckhouse",
+		ReportingController: "deckhouse",
+	}
+}
@@ -0,0 +1,472 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	. "github.com/deckhouse/deckhouse/testing/hooks"
+)
+
+var _ = Describe("Modules :: node-manager :: hooks :: node_remediation ::", func() {
+	const (
+		stateNodes = `
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-1
+  labels:
+    node.deckhouse.io/group: worker
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-2
+  labels:
+    node.deckhouse.io/group: worker
+status:
+  conditions:
+  - type: Ready
+    status: "Unknown"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+  - type: KernelDeadlock
+    status: "True"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-3
+  labels:
+    node.deckhouse.io/group: worker
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+`
+		stateMachines = `
+---
+apiVersion: machine.sapcloud.io/v1alpha1
+kind: Machine
+metadata:
+  name: worker-1
+  namespace: d8-cloud-instance-manager
+  labels:
+    node: worker-1
+---
+apiVersion: machine.sapcloud.io/v1alpha1
+kind: Machine
+metadata:
+  name: worker-2
+  namespace: d8-cloud-instance-manager
+  labels:
+    node: worker-2
+`
+	)
+
+	f := HookExecutionConfigInit(`{"nodeManager":{"internal": {}}}`, `{}`)
+	f.RegisterCRD("deckhouse.io", "v1", "NodeGroup", false)
+	f.RegisterCRD("machine.sapcloud.io", "v1alpha1", "Machine", true)
+
+	Context("Empty cluster", func() {
+		BeforeEach(func() {
+			f.BindingContexts.Set(f.KubeStateSet(``))
+			f.RunHook()
+		})
+
+		It("Hook must not fail", func() {
+			Expect(f).To(ExecuteSuccessfully())
+		})
+	})
+
+	Context("NodeGroup without remediation rules", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+` + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Nodes must be untouched", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			Expect(f.KubernetesGlobalResource("Node", "worker-1").Field("spec.unschedulable").Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("Node", "worker-1").Field(`metadata.annotations.node\.deckhouse\.io/remediation`).Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.remediations").Exists()).To(BeFalse())
+		})
+	})
+
+	Context("NodeGroup with rate limit of two remediations", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: NotReady
+      for: 10m
+      action: Cordon
+    - condition: KernelDeadlock
+      for: 5m
+      action: Reboot
+    rateLimit:
+      maxRemediations: 2
+` + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Nodes must be remediated with the matched rules", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			worker1 := f.KubernetesGlobalResource("Node", "worker-1")
+			Expect(worker1.Field("spec.unschedulable").Bool()).To(BeTrue())
+			Expect(worker1.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).String()).To(Equal("NotReady/Cordon"))
+			Expect(worker1.Field(`metadata.annotations.node\.deckhouse\.io/remediation-cordoned`).Exists()).To(BeTrue())
+
+			// the longest satisfied rule wins
+			worker2 := f.KubernetesGlobalResource("Node", "worker-2")
+			Expect(worker2.Field("spec.unschedulable").Bool()).To(BeTrue())
+			Expect(worker2.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).String()).To(Equal("NotReady/Cordon"))
+			Expect(worker2.Field(`metadata.annotations.node\.deckhouse\.io/reboot-requested`).Exists()).To(BeFalse())
+
+			worker3 := f.KubernetesGlobalResource("Node", "worker-3")
+			Expect(worker3.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).Exists()).To(BeFalse())
+
+			ng := f.KubernetesGlobalResource("NodeGroup", "worker")
+			Expect(ng.Field("status.remediations.#").Int()).To(Equal(int64(2)))
+			Expect(ng.Field("status.remediations.0.node").String()).To(Equal("worker-1"))
+			Expect(ng.Field("status.remediations.0.condition").String()).To(Equal("NotReady"))
+			Expect(ng.Field("status.remediations.0.action").String()).To(Equal("Cordon"))
+			Expect(ng.Field("status.remediations.1.node").String()).To(Equal("worker-2"))
+
+			gvr := schema.GroupVersionResource{Group: "events.k8s.io", Version: "v1", Resource: "events"}
+			events, err := f.KubeClient().Dynamic().Resource(gvr).Namespace("default").List(context.TODO(), metav1.ListOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events.Items).To(HaveLen(2))
+			for _, event := range events.Items {
+				Expect(event.Object["reason"]).To(Equal("NodeRemediation"))
+			}
+		})
+	})
+
+	Context("NodeGroup with default rate limit", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: NotReady
+      action: ReplaceMachine
+` + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Only one machine must be replaced", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "worker-1").Exists()).To(BeFalse())
+			Expect(f.KubernetesResource("Machine", "d8-cloud-instance-manager", "worker-2").Exists()).To(BeTrue())
+			Expect(f.KubernetesGlobalResource("Node", "worker-1").Field(`metadata.annotations.node\.deckhouse\.io/remediation`).String()).To(Equal("NotReady/ReplaceMachine"))
+			Expect(f.KubernetesGlobalResource("Node", "worker-2").Field(`metadata.annotations.node\.deckhouse\.io/remediation`).Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.remediations.#").Int()).To(Equal(int64(1)))
+		})
+	})
+
+	Context("NodeGroup with rate limit exceeded", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: NotReady
+      action: Drain
+status:
+  remediations:
+  - node: worker-0
+    condition: NotReady
+    action: Drain
+    time: "2999-01-01T00:00:00Z"
+` + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Nodes must not be remediated", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			Expect(f.KubernetesGlobalResource("Node", "worker-1").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("Node", "worker-2").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.remediations.#").Int()).To(Equal(int64(1)))
+		})
+	})
+
+	Context("Problem doesn't persist long enough", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: KernelDeadlock
+      for: 87600h
+      action: Reboot
+` + stateNodes + stateMachines)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Nodes must be untouched", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			Expect(f.KubernetesGlobalResource("Node", "worker-2").Field(`metadata.annotations.node\.deckhouse\.io/reboot-requested`).Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.remediations").Exists()).To(BeFalse())
+		})
+	})
+
+	Context("Node with kernel deadlock", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: Static
+  remediation:
+    rules:
+    - condition: KernelDeadlock
+      action: Reboot
+` + stateNodes)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Reboot must be requested", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			worker2 := f.KubernetesGlobalResource("Node", "worker-2")
+			Expect(worker2.Field(`metadata.annotations.node\.deckhouse\.io/reboot-requested`).Exists()).To(BeTrue())
+			Expect(worker2.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).String()).To(Equal("KernelDeadlock/Reboot"))
+		})
+	})
+
+	Context("Static NodeGroup with ReplaceMachine action", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: Static
+  remediation:
+    rules:
+    - condition: NotReady
+      action: ReplaceMachine
+` + stateNodes)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Action must be skipped", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			Expect(f.KubernetesGlobalResource("Node", "worker-1").Field(`metadata.annotations.node\.deckhouse\.io/remediation`).Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.remediations").Exists()).To(BeFalse())
+		})
+	})
+
+	Context("Recovered node", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: NotReady
+      action: Drain
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-3
+  labels:
+    node.deckhouse.io/group: worker
+  annotations:
+    node.deckhouse.io/remediation: NotReady/Drain
+    node.deckhouse.io/remediation-cordoned: ""
+    update.node.deckhouse.io/drained: remediation
+spec:
+  unschedulable: true
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+`)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Node must be uncordoned", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			worker3 := f.KubernetesGlobalResource("Node", "worker-3")
+			Expect(worker3.Field("spec.unschedulable").Exists()).To(BeFalse())
+			Expect(worker3.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).Exists()).To(BeFalse())
+			Expect(worker3.Field(`metadata.annotations.node\.deckhouse\.io/remediation-cordoned`).Exists()).To(BeFalse())
+			Expect(worker3.Field(`metadata.annotations.update\.node\.deckhouse\.io/drained`).Exists()).To(BeFalse())
+		})
+	})
+
+	Context("Node cordoned by someone else", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: NotReady
+      action: Cordon
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-1
+  labels:
+    node.deckhouse.io/group: worker
+spec:
+  unschedulable: true
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+`)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Node must not be marked as cordoned by the remediation", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			worker1 := f.KubernetesGlobalResource("Node", "worker-1")
+			Expect(worker1.Field("spec.unschedulable").Bool()).To(BeTrue())
+			Expect(worker1.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).String()).To(Equal("NotReady/Cordon"))
+			Expect(worker1.Field(`metadata.annotations.node\.deckhouse\.io/remediation-cordoned`).Exists()).To(BeFalse())
+		})
+
+		Context("Problem is gone", func() {
+			BeforeEach(func() {
+				f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: CloudEphemeral
+  remediation:
+    rules:
+    - condition: NotReady
+      action: Cordon
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-1
+  labels:
+    node.deckhouse.io/group: worker
+  annotations:
+    node.deckhouse.io/remediation: NotReady/Cordon
+spec:
+  unschedulable: true
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+    lastTransitionTime: "2020-01-01T00:00:00Z"
+`)
+				f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+				f.RunHook()
+			})
+
+			It("Node must stay cordoned", func() {
+				Expect(f).To(ExecuteSuccessfully())
+
+				worker1 := f.KubernetesGlobalResource("Node", "worker-1")
+				Expect(worker1.Field("spec.unschedulable").Bool()).To(BeTrue())
+				Expect(worker1.Field(`metadata.annotations.node\.deckhouse\.io/remediation`).Exists()).To(BeFalse())
+			})
+		})
+	})
+})
@@ -17,7 +17,10 @@ limitations under the License.
 package hooks
 
 import (
+	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,8 +29,10 @@ import (
 	"github.com/flant/addon-operator/sdk"
 	"github.com/flant/shell-operator/pkg/kube/object_patch"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 
@@ -70,6 +75,22 @@ var _ = sdk.RegisterFunc(&go_hook.HookConfig{
 			},
 			FilterFunc: updateApprovalFilterNode,
 		},
+		// Pods and PodDisruptionBudgets are used to respect PodDisruptionBudgets while approving disruptions
+		{
+			Name:                         "pods",
+			ApiVersion:                   "v1",
+			Kind:                         "Pod",
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   updateApprovalFilterPod,
+		},
+		{
+			Name:                         "pdbs",
+			ApiVersion:                   "policy/v1",
+			Kind:                         "PodDisruptionBudget",
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   updateApprovalFilterPDB,
+		},
 	},
 }, handleUpdateApproval)
 
@@ -79,6 +100,12 @@ func handleUpdateApproval(input *go_hook.HookInput) error {
 
 		nodes:      make(map[string]updateApprovalNode),
 		nodeGroups: make(map[string]updateNodeGroup),
+
+		disruptedNodes: make(map[string]struct{}),
+		blockedNodes:   make(map[string]string),
+
+		pods: make(map[string][]updateApprovalPod),
+		pdbs: make(map[string][]updateApprovalPDB),
 	}
 
 	snap := input.Snapshots["configuration_checksums_secret"]
@@ -102,39 +129,95 @@ func handleUpdateApproval(input *go_hook.HookInput) error {
 		setNodeMetric(input, n, approver.nodeGroups[n.NodeGroup], approver.ngChecksums[n.NodeGroup])
 	}
 
+	snap = input.Snapshots["pods"]
+	for _, s := range snap {
+		if s == nil {
+			continue
+		}
+		pod := s.(updateApprovalPod)
+		approver.pods[pod.Node] = append(approver.pods[pod.Node], pod)
+	}
+
+	snap = input.Snapshots["pdbs"]
+	for _, s := range snap {
+		pdb := s.(updateApprovalPDB)
+		approver.pdbs[pdb.Namespace] = append(approver.pdbs[pdb.Namespace], pdb)
+	}
+
 	approver.deckhouseNodeName = os.Getenv("DECKHOUSE_NODE_NAME")
 
-	err := approver.processUpdatedNodes(input)
+	err := approver.approve(input)
 	if err != nil {
 		return err
 	}
-	if approver.finished {
-		return nil
-	}
 
-	err = approver.approveDisruptions(input)
+	approver.setUpdatePlans(input)
+
+	return nil
+}
+
+type updateApprover struct {
+	finished bool
+
+	ngChecksums       shared.ConfigurationChecksum
+	nodes             map[string]updateApprovalNode
+	nodeGroups        map[string]updateNodeGroup
+	deckhouseNodeName string
+
+	// Nodes approved for disruption during the current run.
+	disruptedNodes map[string]struct{}
+	// Nodes with postponed disruption and the reasons.
+	blockedNodes map[string]string
+
+	// Evictable Pods by node name and PodDisruptionBudgets by namespace.
+	pods map[string][]updateApprovalPod
+	pdbs map[string][]updateApprovalPDB
+}
+
+func (ar *updateApprover) approve(input *go_hook.HookInput) error {
+	err := ar.processUpdatedNodes(input)
 	if err != nil {
 		return err
 	}
-	if approver.finished {
+	if ar.finished {
 		return nil
 	}
 
-	err = approver.approveUpdates(input)
+	err = ar.approveDisruptions(input)
 	if err != nil {
 		return err
 	}
+	if ar.finished {
+		return nil
+	}
 
-	return nil
+	return ar.approveUpdates(input)
 }
 
-type updateApprover struct {
-	finished bool
+// sortedNodes returns nodes sorted by name to make the update order predictable.
+func (ar *updateApprover) sortedNodes() []updateApprovalNode {
+	nodes := make([]updateApprovalNode, 0, len(ar.nodes))
+	for _, node := range ar.nodes {
+		nodes = append(nodes, node)
+	}
 
-	ngChecksums       shared.ConfigurationChecksum
-	nodes             map[string]updateApprovalNode
-	nodeGroups        map[string]updateNodeGroup
-	deckhouseNodeName string
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	return nodes
+}
+
+func (ar *updateApprover) nodeGroupNodes(ngName string) []updateApprovalNode {
+	nodes := make([]updateApprovalNode, 0)
+
+	for _, node := range ar.sortedNodes() {
+		if node.NodeGroup == ngName {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
 }
 
 func calculateConcurrency(ngCon *intstr.IntOrString, totalNodes int) int {
@@ -164,15 +247,9 @@ func calculateConcurrency(ngCon *intstr.IntOrString, totalNodes int) int {
 //   - If there are not ready nodes in the group, they'll be updated first
 func (ar *updateApprover) approveUpdates(input *go_hook.HookInput) error {
 	for _, ng := range ar.nodeGroups {
-		nodeGroupNodes := make([]updateApprovalNode, 0)
+		nodeGroupNodes := ar.nodeGroupNodes(ng.Name)
 		currentUpdates := 0
 
-		for _, node := range ar.nodes {
-			if node.NodeGroup == ng.Name {
-				nodeGroupNodes = append(nodeGroupNodes, node)
-			}
-		}
-
 		concurrency := calculateConcurrency(ng.Concurrency, len(nodeGroupNodes))
 
 		var hasWaitingForApproval bool
@@ -289,7 +366,7 @@ func (ar *updateApprover) approveDisruptions(input *go_hook.HookInput) error {
 		now = time.Date(2021, 01, 01, 13, 30, 00, 00, time.UTC)
 	}
 
-	for _, node := range ar.nodes {
+	for _, node := range ar.sortedNodes() {
 		if !((node.IsDisruptionRequired || node.IsRollingUpdate) && !node.IsDraining) {
 			continue
 		}
@@ -315,7 +392,14 @@ func (ar *updateApprover) approveDisruptions(input *go_hook.HookInput) error {
 			}
 		}
 
+		if blockedBy := ar.checkDisruptionBudget(node, ng); blockedBy != "" {
+			input.LogEntry.Infof("Disruption of node %s is postponed: %s", node.Name, blockedBy)
+			ar.blockedNodes[node.Name] = blockedBy
+			continue
+		}
+
 		ar.finished = true
+		ar.disruptedNodes[node.Name] = struct{}{}
 
 		// If approvalMode == RollingUpdate simply delete machine
 		if ng.Disruptions.ApprovalMode == "RollingUpdate" {
@@ -373,11 +457,160 @@ func (ar *updateApprover) approveDisruptions(input *go_hook.HookInput) error {
 	return nil
 }
 
+// isUnavailable returns true if the node can't run workloads or is already approved for disruption.
+func (ar *updateApprover) isUnavailable(node updateApprovalNode) bool {
+	if _, ok := ar.disruptedNodes[node.Name]; ok {
+		return true
+	}
+
+	return !node.IsReady || node.IsUnschedulable || node.IsDraining || node.IsDisruptionApproved
+}
+
+func (ar *updateApprover) countUnavailable(nodes []updateApprovalNode) int {
+	var count int
+
+	for _, node := range nodes {
+		if ar.isUnavailable(node) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// checkDisruptionBudget checks if the node can be disrupted without exceeding the NodeGroup disruption budget
+// and PodDisruptionBudgets of Pods on the node. It returns the reason if the disruption has to be postponed.
+func (ar *updateApprover) checkDisruptionBudget(node updateApprovalNode, ng updateNodeGroup) string {
+	budget := ng.Disruptions.Budget
+	if budget == nil || ar.isUnavailable(node) {
+		return ""
+	}
+
+	ngNodes := ar.nodeGroupNodes(ng.Name)
+
+	if budget.MaxUnavailable != nil {
+		maxUnavailable := calculateConcurrency(budget.MaxUnavailable, len(ngNodes))
+		if unavailable := ar.countUnavailable(ngNodes); unavailable >= maxUnavailable {
+			return fmt.Sprintf("DisruptionBudget: %d of %d nodes are unavailable (maxUnavailable is %d)", unavailable, len(ngNodes), maxUnavailable)
+		}
+	}
+
+	if budget.MaxUnavailablePerZone != nil {
+		zoneNodes := make([]updateApprovalNode, 0)
+		for _, ngNode := range ngNodes {
+			if ngNode.Zone == node.Zone {
+				zoneNodes = append(zoneNodes, ngNode)
+			}
+		}
+
+		maxUnavailable := calculateConcurrency(budget.MaxUnavailablePerZone, len(zoneNodes))
+		if unavailable := ar.countUnavailable(zoneNodes); unavailable >= maxUnavailable {
+			return fmt.Sprintf("DisruptionBudget: %d of %d nodes are unavailable in zone %q (maxUnavailablePerZone is %d)", unavailable, len(zoneNodes), node.Zone, maxUnavailable)
+		}
+	}
+
+	// Nodes are replaced by new ones in the RollingUpdate mode, so Pods are evicted by the machine controller.
+	if budget.RespectPodDisruptionBudgets && ng.Disruptions.ApprovalMode != "RollingUpdate" {
+		return ar.checkPodDisruptionBudgets(node.Name)
+	}
+
+	return ""
+}
+
+// checkPodDisruptionBudgets returns the PodDisruptionBudget that doesn't allow to evict all Pods from the node.
+func (ar *updateApprover) checkPodDisruptionBudgets(nodeName string) string {
+	podsByNamespace := make(map[string][]updateApprovalPod)
+	for _, pod := range ar.pods[nodeName] {
+		podsByNamespace[pod.Namespace] = append(podsByNamespace[pod.Namespace], pod)
+	}
+
+	namespaces := make([]string, 0, len(podsByNamespace))
+	for namespace := range podsByNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	for _, namespace := range namespaces {
+		for _, pdb := range ar.pdbs[namespace] {
+			selector, err := v1.LabelSelectorAsSelector(pdb.Selector)
+			if err != nil {
+				continue
+			}
+
+			var matchedPods int32
+			for _, pod := range podsByNamespace[namespace] {
+				if selector.Matches(labels.Set(pod.Labels)) {
+					matchedPods++
+				}
+			}
+
+			if matchedPods > 0 && matchedPods > pdb.DisruptionsAllowed {
+				return fmt.Sprintf("PodDisruptionBudget %s/%s", namespace, pdb.Name)
+			}
+		}
+	}
+
+	return ""
+}
+
+// setUpdatePlans sets the nodes waiting for update in the expected update order to the NodeGroup status.
+func (ar *updateApprover) setUpdatePlans(input *go_hook.HookInput) {
+	for _, ng := range ar.nodeGroups {
+		plan := ar.updatePlan(ng)
+		if reflect.DeepEqual(plan, ng.Status.UpdatePlan) {
+			continue
+		}
+
+		patch := map[string]interface{}{
+			"status": map[string]interface{}{
+				"updatePlan": plan,
+			},
+		}
+		input.PatchCollector.MergePatch(patch, "deckhouse.io/v1", "NodeGroup", "", ng.Name, object_patch.WithSubresource("/status"))
+	}
+}
+
+// updatePlan returns the nodes waiting for update in the order they are approved:
+// nodes which are already updating, then not ready nodes and then the rest of nodes.
+func (ar *updateApprover) updatePlan(ng updateNodeGroup) []ngv1.UpdatePlanNode {
+	var updating, notReady, waiting []ngv1.UpdatePlanNode
+
+	for _, node := range ar.nodeGroupNodes(ng.Name) {
+		status := calculateNodeStatus(node, ng, ar.ngChecksums[ng.Name])
+		if status == "UpToDate" || status == "UpdateFailedNoConfigChecksum" {
+			continue
+		}
+
+		planNode := ngv1.UpdatePlanNode{
+			Name:      node.Name,
+			Zone:      node.Zone,
+			Status:    status,
+			BlockedBy: ar.blockedNodes[node.Name],
+		}
+
+		switch {
+		case node.IsApproved:
+			updating = append(updating, planNode)
+		case !node.IsReady:
+			notReady = append(notReady, planNode)
+		default:
+			waiting = append(waiting, planNode)
+		}
+	}
+
+	plan := append(append(updating, notReady...), waiting...)
+	if len(plan) == 0 {
+		return nil
+	}
+
+	return plan
+}
+
 // Process updated nodes: remove approved and disruption-approved annotations, if:
 //   - Node is ready
 //   - Node checksum is equal to NodeGroup checksum
 func (ar *updateApprover) processUpdatedNodes(input *go_hook.HookInput) error {
-	for _, node := range ar.nodes {
+	for _, node := range ar.sortedNodes() {
 		if !node.IsApproved {
 			continue
 		}
@@ -426,6 +659,7 @@ func (ar *updateApprover) processUpdatedNodes(input *go_hook.HookInput) error {
 type updateApprovalNode struct {
 	Name      string
 	NodeGroup string
+	Zone      string
 
 	ConfigurationChecksum string
 
@@ -484,6 +718,8 @@ func updateApprovalNodeGroupFilter(obj *unstructured.Unstructured) (go_hook.Filt
 		ung.Disruptions.ApprovalMode = "Automatic"
 	}
 
+	ung.Disruptions.Budget = ng.Spec.Disruptions.Budget
+
 	ung.Status = ng.Status
 
 	if ung.Disruptions.ApprovalMode == "Automatic" {
@@ -550,6 +786,7 @@ func updateApprovalFilterNode(obj *unstructured.Unstructured) (go_hook.FilterRes
 		IsDisruptionApproved:  isDisruptionApproved,
 		ConfigurationChecksum: configChecksum,
 		NodeGroup:             nodeGroup,
+		Zone:                  node.Labels[corev1.LabelTopologyZone],
 		IsReady:               isReady,
 		IsDisruptionRequired:  isDisruptionRequired,
 		IsDraining:            isDraining,
@@ -562,6 +799,65 @@ func updateApprovalFilterNode(obj *unstructured.Unstructured) (go_hook.FilterRes
 	return n, nil
 }
 
+type updateApprovalPod struct {
+	Namespace string
+	Node      string
+	Labels    map[string]string
+}
+
+// updateApprovalFilterPod keeps only Pods which are evicted while draining.
+func updateApprovalFilterPod(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var pod corev1.Pod
+
+	err := sdk.FromUnstructured(obj, &pod)
+	if err != nil {
+		return nil, err
+	}
+
+	if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return nil, nil
+	}
+
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return nil, nil
+	}
+
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return nil, nil
+		}
+	}
+
+	return updateApprovalPod{
+		Namespace: pod.Namespace,
+		Node:      pod.Spec.NodeName,
+		Labels:    pod.Labels,
+	}, nil
+}
+
+type updateApprovalPDB struct {
+	Name               string
+	Namespace          string
+	Selector           *v1.LabelSelector
+	DisruptionsAllowed int32
+}
+
+func updateApprovalFilterPDB(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var pdb policyv1.PodDisruptionBudget
+
+	err := sdk.FromUnstructured(obj, &pdb)
+	if err != nil {
+		return nil, err
+	}
+
+	return updateApprovalPDB{
+		Name:               pdb.Name,
+		Namespace:          pdb.Namespace,
+		Selector:           pdb.Spec.Selector,
+		DisruptionsAllowed: pdb.Status.DisruptionsAllowed,
+	}, nil
+}
+
 func setNodeMetric(input *go_hook.HookInput, node updateApprovalNode, ng updateNodeGroup, desiredChecksum string) {
 	nodeStatus := calculateNodeStatus(node, ng, desiredChecksum)
 	setNodeStatusesMetrics(input, node.Name, node.NodeGroup, nodeStatus)
@@ -20,10 +20,12 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"testing"
 	"text/template"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	. "github.com/deckhouse/deckhouse/testing/hooks"
 )
@@ -1227,6 +1229,254 @@ metadata:
 			})
 		})
 	})
+
+	Context("Disruption budget", func() {
+		const disruptionBudgetState = `
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: configuration-checksums
+  namespace: d8-cloud-instance-manager
+data:
+  worker: dXBkYXRlZA== # updated
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: Static
+  disruptions:
+    approvalMode: Automatic
+    budget:
+      maxUnavailable: 1
+      respectPodDisruptionBudgets: true
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-1
+  labels:
+    node.deckhouse.io/group: worker
+    topology.kubernetes.io/zone: zone-a
+  annotations:
+    update.node.deckhouse.io/approved: ""
+    update.node.deckhouse.io/disruption-required: ""
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-2
+  labels:
+    node.deckhouse.io/group: worker
+    topology.kubernetes.io/zone: zone-b
+  annotations:
+    update.node.deckhouse.io/approved: ""
+    update.node.deckhouse.io/disruption-required: ""
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-3
+  labels:
+    node.deckhouse.io/group: worker
+    topology.kubernetes.io/zone: zone-b
+  annotations:
+    update.node.deckhouse.io/waiting-for-approval: ""
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+`
+
+		Context("with maxUnavailable exceeded", func() {
+			BeforeEach(func() {
+				f.BindingContexts.Set(f.KubeStateSet(disruptionBudgetState))
+				f.RunHook()
+			})
+
+			It("Should drain only one node and show the update plan", func() {
+				Expect(f).To(ExecuteSuccessfully())
+
+				Expect(f.KubernetesGlobalResource("Node", "worker-1").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).String()).To(Equal("bashible"))
+				Expect(f.KubernetesGlobalResource("Node", "worker-2").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).Exists()).To(BeFalse())
+
+				Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.updatePlan").String()).To(MatchJSON(`[
+{"name":"worker-1","zone":"zone-a","status":"WaitingForDisruptionApproval"},
+{"name":"worker-2","zone":"zone-b","status":"WaitingForDisruptionApproval","blockedBy":"DisruptionBudget: 1 of 3 nodes are unavailable (maxUnavailable is 1)"},
+{"name":"worker-3","zone":"zone-b","status":"WaitingForApproval"}
+]`))
+			})
+		})
+
+		Context("with unavailable nodes", func() {
+			BeforeEach(func() {
+				f.BindingContexts.Set(f.KubeStateSet(`
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: configuration-checksums
+  namespace: d8-cloud-instance-manager
+data:
+  worker: dXBkYXRlZA== # updated
+---
+apiVersion: deckhouse.io/v1
+kind: NodeGroup
+metadata:
+  name: worker
+spec:
+  nodeType: Static
+  disruptions:
+    approvalMode: Automatic
+    budget:
+      maxUnavailable: 3
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-1
+  labels:
+    node.deckhouse.io/group: worker
+status:
+  conditions:
+  - type: Ready
+    status: "False"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-2
+  labels:
+    node.deckhouse.io/group: worker
+spec:
+  unschedulable: true
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-3
+  labels:
+    node.deckhouse.io/group: worker
+  annotations:
+    update.node.deckhouse.io/approved: ""
+    update.node.deckhouse.io/disruption-required: ""
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-4
+  labels:
+    node.deckhouse.io/group: worker
+  annotations:
+    update.node.deckhouse.io/approved: ""
+    update.node.deckhouse.io/disruption-required: ""
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+---
+apiVersion: v1
+kind: Node
+metadata:
+  name: worker-5
+  labels:
+    node.deckhouse.io/group: worker
+status:
+  conditions:
+  - type: Ready
+    status: "True"
+`))
+				f.RunHook()
+			})
+
+			It("Should count unavailable nodes in the reason", func() {
+				Expect(f).To(ExecuteSuccessfully())
+
+				Expect(f.KubernetesGlobalResource("Node", "worker-3").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).String()).To(Equal("bashible"))
+				Expect(f.KubernetesGlobalResource("Node", "worker-4").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).Exists()).To(BeFalse())
+
+				Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.updatePlan").String()).To(ContainSubstring(
+					`DisruptionBudget: 3 of 5 nodes are unavailable (maxUnavailable is 3)`))
+			})
+		})
+
+		Context("with PodDisruptionBudget not allowing disruptions", func() {
+			BeforeEach(func() {
+				f.BindingContexts.Set(f.KubeStateSet(disruptionBudgetState + `
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app-0
+  namespace: app
+  labels:
+    app: app
+spec:
+  nodeName: worker-1
+status:
+  phase: Running
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: agent-0
+  namespace: app
+  labels:
+    app: app
+  ownerReferences:
+  - apiVersion: apps/v1
+    kind: DaemonSet
+    name: agent
+    uid: 9d3a5f1e-2b0c-4d8e-9f6a-1c2b3d4e5f60
+spec:
+  nodeName: worker-2
+status:
+  phase: Running
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: app
+  namespace: app
+spec:
+  maxUnavailable: 0
+  selector:
+    matchLabels:
+      app: app
+status:
+  disruptionsAllowed: 0
+`))
+				f.RunHook()
+			})
+
+			It("Should postpone disruption of the node with the blocking PodDisruptionBudget", func() {
+				Expect(f).To(ExecuteSuccessfully())
+
+				Expect(f.KubernetesGlobalResource("Node", "worker-1").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).Exists()).To(BeFalse())
+				Expect(f.KubernetesGlobalResource("Node", "worker-2").Field(`metadata.annotations.update\.node\.deckhouse\.io/draining`).String()).To(Equal("bashible"))
+
+				Expect(f.KubernetesGlobalResource("NodeGroup", "worker").Field("status.updatePlan.0.blockedBy").String()).To(Equal("PodDisruptionBudget app/app"))
+			})
+		})
+	})
 })
 
 type skipDrainingState struct {
@@ -1556,3 +1806,22 @@ status:
 
 	return state
 }
+
+func Test_calculateConcurrency(t *testing.T) {
+	tests := []struct {
+		value    intstr.IntOrString
+		nodes    int
+		expected int
+	}{
+		{value: intstr.FromInt(2), nodes: 5, expected: 2},
+		{value: intstr.FromString("3"), nodes: 5, expected: 3},
+		{value: intstr.FromString("50%"), nodes: 5, expected: 2},
+		{value: intstr.FromString("10%"), nodes: 5, expected: 1},
+	}
+
+	for _, tt := range tests {
+		if got := calculateConcurrency(&tt.value, tt.nodes); got != tt.expected {
+			t.Errorf("calculateConcurrency(%s, %d): got %d | expected %d", tt.value.String(), tt.nodes, got, tt.expected)
+		}
+	}
+}
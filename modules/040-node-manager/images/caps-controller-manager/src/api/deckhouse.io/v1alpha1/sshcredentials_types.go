@@ -28,6 +28,7 @@ type SSHCredentialsSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
+	//+kubebuilder:validation:Pattern=`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`
 	User          string `json:"user"`
 	PrivateSSHKey string `json:"privateSSHKey"`
 	SudoPassword  string `json:"sudoPassword,omitempty"`
@@ -38,6 +39,42 @@ type SSHCredentialsSpec struct {
 	SSHPort int `json:"sshPort,omitempty"`
 
 	SSHExtraArgs string `json:"sshExtraArgs,omitempty"`
+
+	// SSHCertificate is an OpenSSH certificate signed by the SSH CA for PrivateSSHKey encoded as base64 string.
+	SSHCertificate string `json:"sshCertificate,omitempty"`
+
+	// KnownHosts is a list of host keys in the known_hosts format.
+	// If set, connections to hosts with unknown host keys are rejected.
+	KnownHosts string `json:"knownHosts,omitempty"`
+
+	// Bastion is a jump host used to connect to the StaticInstance.
+	Bastion *SSHBastion `json:"bastion,omitempty"`
+
+	// CleanupPolicy is the default cleanup policy for StaticInstances that use these credentials.
+	//+kubebuilder:validation:Enum=Cleanup;Reset;Quarantine
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+}
+
+// SSHBastion defines a jump host used to connect to the StaticInstance.
+type SSHBastion struct {
+	// Host is a hostname or an IP address, it is passed to the shell in the ProxyCommand.
+	//+kubebuilder:validation:Pattern=`^[a-zA-Z0-9:][a-zA-Z0-9.:-]*$`
+	Host string `json:"host"`
+
+	// User defaults to SSHCredentialsSpec.User.
+	//+kubebuilder:validation:Pattern=`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`
+	User string `json:"user,omitempty"`
+
+	//+kubebuilder:default:=22
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:validation:Maximum=65535
+	Port int `json:"port,omitempty"`
+
+	// PrivateSSHKey defaults to SSHCredentialsSpec.PrivateSSHKey.
+	PrivateSSHKey string `json:"privateSSHKey,omitempty"`
+
+	// SSHCertificate defaults to SSHCredentialsSpec.SSHCertificate if PrivateSSHKey is not set.
+	SSHCertificate string `json:"sshCertificate,omitempty"`
 }
 
 //+kubebuilder:object:root=true
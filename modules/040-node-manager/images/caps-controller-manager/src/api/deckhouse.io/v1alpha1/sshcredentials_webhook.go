@@ -18,6 +18,9 @@ package v1alpha1
 
 import (
 	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
 
 	"golang.org/x/crypto/ssh"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,6 +31,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
+// Usernames and hostnames are passed to the ssh command line and to the shell in the bastion ProxyCommand,
+// so they are restricted to the characters that are safe there. The bastion fields must match the patterns in the CRD.
+var (
+	sshUserRegexp = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]*$`)
+	sshHostRegexp = regexp.MustCompile(`^[a-zA-Z0-9:][a-zA-Z0-9.:-]*$`)
+)
+
 // log is for logging in this package.
 var sshcredentialslog = logf.Log.WithName("sshcredentials-resource")
 
@@ -57,39 +67,155 @@ var _ webhook.Validator = &SSHCredentials{}
 func (r *SSHCredentials) ValidateCreate() (admission.Warnings, error) {
 	sshcredentialslog.Info("validate create", "name", r.Name)
 
-	privateSSHKey, err := base64.StdEncoding.DecodeString(r.Spec.PrivateSSHKey)
-	if err != nil {
-		return nil, field.Invalid(field.NewPath("spec", "privateSSHKey"), "******", "privateSSHKey must be a valid base64 encoded string")
+	return nil, r.validate(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *SSHCredentials) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	sshcredentialslog.Info("validate update", "name", r.Name)
+
+	oldCredentials, ok := old.(*SSHCredentials)
+	if !ok {
+		return nil, fmt.Errorf("expected SSHCredentials, got %T", old)
 	}
 
-	_, err = ssh.ParseRawPrivateKey(privateSSHKey)
+	return nil, r.validate(oldCredentials)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *SSHCredentials) ValidateDelete() (admission.Warnings, error) {
+	sshcredentialslog.Info("validate delete", "name", r.Name)
+
+	return nil, nil
+}
+
+// validate checks the credentials, old is nil on create.
+func (r *SSHCredentials) validate(old *SSHCredentials) error {
+	var err error
+
+	// The user is checked only when it is set or changed, so the credentials created before the check can be updated.
+	if old == nil || old.Spec.User != r.Spec.User {
+		err = validateSSHUser(field.NewPath("spec", "user"), r.Spec.User)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = validatePrivateSSHKey(field.NewPath("spec", "privateSSHKey"), r.Spec.PrivateSSHKey)
 	if err != nil {
-		return nil, field.Invalid(field.NewPath("spec", "privateSSHKey"), "******", "privateSSHKey must be a valid private key encoded as base64 string")
+		return err
 	}
 
-	return nil, nil
+	if r.Spec.SSHCertificate != "" {
+		err = validateSSHCertificate(field.NewPath("spec", "sshCertificate"), r.Spec.SSHCertificate)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Spec.KnownHosts != "" {
+		err = validateKnownHosts(field.NewPath("spec", "knownHosts"), r.Spec.KnownHosts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Spec.Bastion != nil {
+		bastionPath := field.NewPath("spec", "bastion")
+
+		if r.Spec.Bastion.Host == "" {
+			return field.Required(bastionPath.Child("host"), "bastion host must be set")
+		}
+
+		if !sshHostRegexp.MatchString(r.Spec.Bastion.Host) {
+			return field.Invalid(bastionPath.Child("host"), r.Spec.Bastion.Host, fmt.Sprintf("%s must be a hostname or an IP address", bastionPath.Child("host").String()))
+		}
+
+		// Host keys are not checked without known hosts, and the bastion could intercept the connections to the hosts.
+		if r.Spec.KnownHosts == "" {
+			return field.Required(field.NewPath("spec", "knownHosts"), "known hosts must be set to use the bastion host")
+		}
+
+		if r.Spec.Bastion.User != "" {
+			err = validateSSHUser(bastionPath.Child("user"), r.Spec.Bastion.User)
+			if err != nil {
+				return err
+			}
+		}
+
+		if r.Spec.Bastion.PrivateSSHKey != "" {
+			err = validatePrivateSSHKey(bastionPath.Child("privateSSHKey"), r.Spec.Bastion.PrivateSSHKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		if r.Spec.Bastion.SSHCertificate != "" {
+			err = validateSSHCertificate(bastionPath.Child("sshCertificate"), r.Spec.Bastion.SSHCertificate)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *SSHCredentials) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
-	sshcredentialslog.Info("validate update", "name", r.Name)
+func validateSSHUser(path *field.Path, value string) error {
+	if !sshUserRegexp.MatchString(value) {
+		return field.Invalid(path, value, fmt.Sprintf("%s must contain only letters, digits, '_', '.' and '-', and must not start with '.' or '-'", path.String()))
+	}
 
-	privateSSHKey, err := base64.StdEncoding.DecodeString(r.Spec.PrivateSSHKey)
+	return nil
+}
+
+func validatePrivateSSHKey(path *field.Path, value string) error {
+	privateSSHKey, err := base64.StdEncoding.DecodeString(value)
 	if err != nil {
-		return nil, field.Invalid(field.NewPath("spec", "privateSSHKey"), "******", "privateSSHKey must be a valid base64 encoded string")
+		return field.Invalid(path, "******", fmt.Sprintf("%s must be a valid base64 encoded string", path.String()))
 	}
 
 	_, err = ssh.ParseRawPrivateKey(privateSSHKey)
 	if err != nil {
-		return nil, field.Invalid(field.NewPath("spec", "privateSSHKey"), "******", "privateSSHKey must be a valid private key encoded as base64 string")
+		return field.Invalid(path, "******", fmt.Sprintf("%s must be a valid private key encoded as base64 string", path.String()))
 	}
 
-	return nil, nil
+	return nil
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *SSHCredentials) ValidateDelete() (admission.Warnings, error) {
-	sshcredentialslog.Info("validate delete", "name", r.Name)
+func validateSSHCertificate(path *field.Path, value string) error {
+	sshCertificate, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return field.Invalid(path, value, fmt.Sprintf("%s must be a valid base64 encoded string", path.String()))
+	}
 
-	return nil, nil
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(sshCertificate)
+	if err != nil {
+		return field.Invalid(path, value, fmt.Sprintf("%s must be a valid OpenSSH certificate encoded as base64 string", path.String()))
+	}
+
+	_, ok := publicKey.(*ssh.Certificate)
+	if !ok {
+		return field.Invalid(path, value, fmt.Sprintf("%s must be an OpenSSH certificate, not a public key", path.String()))
+	}
+
+	return nil
+}
+
+func validateKnownHosts(path *field.Path, value string) error {
+	rest := []byte(value)
+
+	for len(rest) > 0 {
+		var err error
+
+		_, _, _, _, rest, err = ssh.ParseKnownHosts(rest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return field.Invalid(path, value, fmt.Sprintf("%s must be in the known_hosts format: %s", path.String(), err))
+		}
+	}
+
+	return nil
 }
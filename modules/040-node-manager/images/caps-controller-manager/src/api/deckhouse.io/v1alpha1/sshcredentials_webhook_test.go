@@ -0,0 +1,107 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func testPrivateSSHKey(t *testing.T) string {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(block))
+}
+
+func TestSSHCredentialsValidateUsersAndHosts(t *testing.T) {
+	key := testPrivateSSHKey(t)
+	knownHosts := "bastion.example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"
+
+	tests := []struct {
+		name       string
+		user       string
+		bastion    *SSHBastion
+		knownHosts string
+		valid      bool
+	}{
+		{name: "user", user: "ubuntu", valid: true},
+		{name: "user with dots and dashes", user: "deploy.user-1", valid: true},
+		{name: "user starting with a dash", user: "-oProxyCommand=id", valid: false},
+		{name: "user with shell characters", user: "ubuntu;id", valid: false},
+		{name: "bastion hostname", user: "ubuntu", bastion: &SSHBastion{Host: "bastion.example.com", User: "jump"}, knownHosts: knownHosts, valid: true},
+		{name: "bastion IPv4", user: "ubuntu", bastion: &SSHBastion{Host: "10.0.0.1"}, knownHosts: knownHosts, valid: true},
+		{name: "bastion IPv6", user: "ubuntu", bastion: &SSHBastion{Host: "fd00::1"}, knownHosts: knownHosts, valid: true},
+		{name: "bastion host with shell characters", user: "ubuntu", bastion: &SSHBastion{Host: "bastion$(id)"}, valid: false},
+		{name: "bastion host with spaces", user: "ubuntu", bastion: &SSHBastion{Host: "bastion -o ProxyCommand=id"}, valid: false},
+		{name: "bastion host starting with a dash", user: "ubuntu", bastion: &SSHBastion{Host: "-bastion"}, valid: false},
+		{name: "bastion user with quotes", user: "ubuntu", bastion: &SSHBastion{Host: "bastion", User: "jump'"}, knownHosts: knownHosts, valid: false},
+		{name: "bastion without known hosts", user: "ubuntu", bastion: &SSHBastion{Host: "bastion.example.com"}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentials := &SSHCredentials{Spec: SSHCredentialsSpec{
+				User:          tt.user,
+				PrivateSSHKey: key,
+				Bastion:       tt.bastion,
+				KnownHosts:    tt.knownHosts,
+			}}
+
+			err := credentials.validate(nil)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid credentials, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("expected validation error")
+			}
+		})
+	}
+}
+
+func TestSSHCredentialsValidateUpdate(t *testing.T) {
+	key := testPrivateSSHKey(t)
+
+	old := &SSHCredentials{Spec: SSHCredentialsSpec{User: "admin user", PrivateSSHKey: key}}
+
+	// the user created before the check is not validated while it is not changed
+	updated := old.DeepCopy()
+	updated.Spec.PrivateSSHKey = testPrivateSSHKey(t)
+	if _, err := updated.ValidateUpdate(old); err != nil {
+		t.Errorf("expected valid update, got %v", err)
+	}
+
+	updated = old.DeepCopy()
+	updated.Spec.User = "admin;id"
+	if _, err := updated.ValidateUpdate(old); err == nil {
+		t.Errorf("expected validation error for the changed user")
+	}
+}
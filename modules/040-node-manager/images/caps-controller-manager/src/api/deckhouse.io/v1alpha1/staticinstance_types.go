@@ -32,8 +32,33 @@ type StaticInstanceSpec struct {
 
 	Address        string                  `json:"address"`
 	CredentialsRef *corev1.ObjectReference `json:"credentialsRef"`
+
+	// CleanupPolicy overrides the cleanup policy set in the referenced SSHCredentials.
+	// +optional
+	// +kubebuilder:validation:Enum=Cleanup;Reset;Quarantine
+	CleanupPolicy CleanupPolicy `json:"cleanupPolicy,omitempty"`
+
+	// WipeDevices is a list of block devices to wipe with the Reset cleanup policy.
+	// +optional
+	WipeDevices []string `json:"wipeDevices,omitempty"`
 }
 
+type CleanupPolicy string
+
+const (
+	// CleanupPolicyCleanup runs the cleanup script and returns the instance to the pool.
+	CleanupPolicyCleanup CleanupPolicy = "Cleanup"
+	// CleanupPolicyReset runs the cleanup script, removes the pod logs,
+	// wipes the devices listed in WipeDevices, reboots the host and returns the instance to the pool.
+	CleanupPolicyReset CleanupPolicy = "Reset"
+	// CleanupPolicyQuarantine runs the cleanup script and keeps the instance out of the pool
+	// until an operator marks it clean with the StaticInstanceReleaseFromQuarantineAnnotation.
+	CleanupPolicyQuarantine CleanupPolicy = "Quarantine"
+)
+
+// StaticInstanceReleaseFromQuarantineAnnotation returns a quarantined StaticInstance to the pool.
+const StaticInstanceReleaseFromQuarantineAnnotation = "node.deckhouse.io/release-from-quarantine"
+
 // StaticInstanceStatus defines the observed state of StaticInstance
 type StaticInstanceStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -48,6 +73,9 @@ type StaticInstanceStatus struct {
 	// +optional
 	CurrentStatus *StaticInstanceStatusCurrentStatus `json:"currentStatus,omitempty"`
 
+	// +optional
+	LastCleanup *StaticInstanceStatusLastCleanup `json:"lastCleanup,omitempty"`
+
 	// Conditions defines current service state of the StaticInstance.
 	// +optional
 	Conditions clusterv1.Conditions `json:"conditions,omitempty"`
@@ -58,10 +86,32 @@ type StaticInstanceStatusCurrentStatus struct {
 	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
 
 	// +optional
-	// +kubebuilder:validation:Enum=Pending;Bootstrapping;Running;Cleaning
+	// +kubebuilder:validation:Enum=Pending;Bootstrapping;Running;Cleaning;Quarantined
 	Phase StaticInstanceStatusCurrentStatusPhase `json:"phase"`
 }
 
+type StaticInstanceStatusLastCleanup struct {
+	// +optional
+	Time metav1.Time `json:"time"`
+
+	// +optional
+	Policy CleanupPolicy `json:"policy,omitempty"`
+
+	// +optional
+	// +kubebuilder:validation:Enum=Succeeded;TimedOut
+	Result StaticInstanceStatusLastCleanupResult `json:"result,omitempty"`
+
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type StaticInstanceStatusLastCleanupResult string
+
+const (
+	StaticInstanceStatusLastCleanupResultSucceeded StaticInstanceStatusLastCleanupResult = "Succeeded"
+	StaticInstanceStatusLastCleanupResultTimedOut  StaticInstanceStatusLastCleanupResult = "TimedOut"
+)
+
 type StaticInstanceStatusCurrentStatusPhase string
 
 const (
@@ -69,6 +119,7 @@ const (
 	StaticInstanceStatusCurrentStatusPhaseBootstrapping StaticInstanceStatusCurrentStatusPhase = "Bootstrapping"
 	StaticInstanceStatusCurrentStatusPhaseRunning       StaticInstanceStatusCurrentStatusPhase = "Running"
 	StaticInstanceStatusCurrentStatusPhaseCleaning      StaticInstanceStatusCurrentStatusPhase = "Cleaning"
+	StaticInstanceStatusCurrentStatusPhaseQuarantined   StaticInstanceStatusCurrentStatusPhase = "Quarantined"
 )
 
 //+kubebuilder:object:root=true
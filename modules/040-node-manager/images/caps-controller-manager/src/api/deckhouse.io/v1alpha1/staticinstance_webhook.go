@@ -77,11 +77,13 @@ func (r *StaticInstance) ValidateUpdate(old runtime.Object) (admission.Warnings,
 func (r *StaticInstance) ValidateDelete() (admission.Warnings, error) {
 	staticinstancelog.Info("validate delete", "name", r.Name)
 
-	if r.Status.CurrentStatus == nil || r.Status.CurrentStatus.Phase != StaticInstanceStatusCurrentStatusPhasePending {
+	if r.Status.CurrentStatus == nil ||
+		(r.Status.CurrentStatus.Phase != StaticInstanceStatusCurrentStatusPhasePending &&
+			r.Status.CurrentStatus.Phase != StaticInstanceStatusCurrentStatusPhaseQuarantined) {
 		return nil, apierrors.NewForbidden(schema.GroupResource{
 			Group:    r.GroupVersionKind().Group,
 			Resource: "staticinstances",
-		}, r.Name, errors.New("if you need to delete a StaticInstance that is not pending, you can find the associated Instance and delete it manually, after which the StaticInstance will be deleted automatically"))
+		}, r.Name, errors.New("if you need to delete a StaticInstance that is not pending or quarantined, you can find the associated Instance and delete it manually, after which the StaticInstance will be deleted automatically"))
 	}
 
 	return nil, nil
@@ -27,12 +27,27 @@ import (
 	"sigs.k8s.io/cluster-api/api/v1beta1"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHBastion) DeepCopyInto(out *SSHBastion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHBastion.
+func (in *SSHBastion) DeepCopy() *SSHBastion {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHBastion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSHCredentials) DeepCopyInto(out *SSHCredentials) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHCredentials.
@@ -88,6 +103,11 @@ func (in *SSHCredentialsList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SSHCredentialsSpec) DeepCopyInto(out *SSHCredentialsSpec) {
 	*out = *in
+	if in.Bastion != nil {
+		in, out := &in.Bastion, &out.Bastion
+		*out = new(SSHBastion)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHCredentialsSpec.
@@ -167,6 +187,11 @@ func (in *StaticInstanceSpec) DeepCopyInto(out *StaticInstanceSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.WipeDevices != nil {
+		in, out := &in.WipeDevices, &out.WipeDevices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticInstanceSpec.
@@ -197,6 +222,11 @@ func (in *StaticInstanceStatus) DeepCopyInto(out *StaticInstanceStatus) {
 		*out = new(StaticInstanceStatusCurrentStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LastCleanup != nil {
+		in, out := &in.LastCleanup, &out.LastCleanup
+		*out = new(StaticInstanceStatusLastCleanup)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(v1beta1.Conditions, len(*in))
@@ -231,3 +261,19 @@ func (in *StaticInstanceStatusCurrentStatus) DeepCopy() *StaticInstanceStatusCur
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticInstanceStatusLastCleanup) DeepCopyInto(out *StaticInstanceStatusLastCleanup) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticInstanceStatusLastCleanup.
+func (in *StaticInstanceStatusLastCleanup) DeepCopy() *StaticInstanceStatusLastCleanup {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticInstanceStatusLastCleanup)
+	in.DeepCopyInto(out)
+	return out
+}
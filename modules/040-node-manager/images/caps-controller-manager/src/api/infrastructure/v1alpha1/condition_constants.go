@@ -36,6 +36,10 @@ const (
 	// StaticInstanceWaitingForNodeRefReason indicates when a StaticInstance is registered into a capacity pool and
 	// waiting for a StaticInstance.Status.NodeRef to be assigned.
 	StaticInstanceWaitingForNodeRefReason = "WaitingForNodeRefToBeAssigned"
+
+	// StaticInstanceQuarantinedReason indicates when a StaticInstance was cleaned up with the Quarantine cleanup policy
+	// and is waiting for an operator to return it to the capacity pool.
+	StaticInstanceQuarantinedReason = "Quarantined"
 )
 
 // Conditions and Reasons defined on StaticMachine.
@@ -18,6 +18,8 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -26,6 +28,22 @@ import (
 	"caps-controller-manager/internal/ssh"
 )
 
+const (
+	cleanupScriptPath = "/var/lib/bashible/cleanup_static_node.sh"
+	// noRebootFlag asks the cleanup script not to reboot the host, scripts created by older bashible versions ignore it
+	noRebootFlag = "--no-reboot"
+)
+
+// resetPaths are the paths with the kubelet and containerd state and the pod logs that are removed with the Reset cleanup policy.
+// The state is also removed by the cleanup script, but it is removed again in case the script is absent on the host.
+var resetPaths = []string{
+	"/etc/kubernetes",
+	"/var/lib/kubelet",
+	"/var/lib/containerd",
+	"/var/log/pods",
+	"/var/log/containers",
+}
+
 // Cleanup runs the cleanup script on StaticInstance.
 func (c *Client) Cleanup(ctx context.Context, instanceScope *scope.InstanceScope) error {
 	switch instanceScope.GetPhase() {
@@ -68,9 +86,11 @@ func (c *Client) cleanupFromCleaningPhase(ctx context.Context, instanceScope *sc
 		return nil
 	}
 
-	err := instanceScope.ToPending(ctx)
+	instanceScope.SetLastCleanup(deckhousev1.StaticInstanceStatusLastCleanupResultSucceeded, "")
+
+	err := instanceScope.ToReleased(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to set StaticInstance to Pending phase")
+		return errors.Wrap(err, "failed to release StaticInstance")
 	}
 
 	return nil
@@ -78,7 +98,7 @@ func (c *Client) cleanupFromCleaningPhase(ctx context.Context, instanceScope *sc
 
 func (c *Client) cleanup(instanceScope *scope.InstanceScope) bool {
 	done := c.cleanupTaskManager.spawn(instanceScope.MachineScope.StaticMachine.Spec.ProviderID, func() bool {
-		err := ssh.ExecSSHCommand(instanceScope, "test -f /var/lib/bashible/cleanup_static_node.sh || exit 0 && bash /var/lib/bashible/cleanup_static_node.sh --yes-i-am-sane-and-i-understand-what-i-am-doing", nil)
+		err := ssh.ExecSSHCommand(instanceScope, cleanupCommand(instanceScope.GetCleanupPolicy(), instanceScope.Instance.Spec.WipeDevices), nil)
 		if err != nil {
 			instanceScope.Logger.Error(err, "Failed to clean up StaticInstance: failed to exec ssh command")
 
@@ -88,10 +108,36 @@ func (c *Client) cleanup(instanceScope *scope.InstanceScope) bool {
 		return true
 	})
 	if done {
-		c.recorder.SendNormalEvent(instanceScope.Instance, instanceScope.MachineScope.StaticMachine.Labels["node-group"], "CleanupScriptSucceeded", "Cleanup script executed successfully")
+		c.recorder.SendNormalEvent(instanceScope.Instance, instanceScope.MachineScope.StaticMachine.Labels["node-group"], "CleanupScriptSucceeded", fmt.Sprintf("Cleanup script executed successfully with the %s cleanup policy", instanceScope.GetCleanupPolicy()))
 	} else {
 		instanceScope.Logger.Info("Cleaning is not finished yet, waiting...")
 	}
 
 	return done
 }
+
+// cleanupCommand returns the command that cleans up StaticInstance according to the cleanup policy.
+// The cleanup script reboots the host in the background a few seconds after it finishes,
+// so with the Reset policy the script is asked not to reboot, and the host is rebooted after the devices are wiped.
+// A script created by an older bashible version does not support it, so the command fails without running it,
+// and the cleanup is retried after bashible updates the script on the host.
+func cleanupCommand(policy deckhousev1.CleanupPolicy, wipeDevices []string) string {
+	if policy != deckhousev1.CleanupPolicyReset {
+		return fmt.Sprintf("test -f %[1]s || exit 0 && bash %[1]s --yes-i-am-sane-and-i-understand-what-i-am-doing", cleanupScriptPath)
+	}
+
+	commands := []string{
+		fmt.Sprintf("if test -f %[1]s; then grep -q -- %[2]s %[1]s || { echo 'the cleanup script does not support %[2]s yet' >&2; exit 1; }; fi", cleanupScriptPath, noRebootFlag),
+		fmt.Sprintf("if test -f %[1]s; then bash %[1]s --yes-i-am-sane-and-i-understand-what-i-am-doing %[2]s; fi", cleanupScriptPath, noRebootFlag),
+		fmt.Sprintf("rm -rf %s", strings.Join(resetPaths, " ")),
+	}
+
+	for _, device := range wipeDevices {
+		commands = append(commands, fmt.Sprintf("wipefs --all --force %s", device))
+	}
+
+	// run reboot in the background to normally end the ssh session
+	commands = append(commands, "{ (sleep 5 && shutdown -r now) & }")
+
+	return strings.Join(commands, " && ")
+}
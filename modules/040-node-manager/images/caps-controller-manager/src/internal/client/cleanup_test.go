@@ -0,0 +1,78 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strings"
+	"testing"
+
+	deckhousev1 "caps-controller-manager/api/deckhouse.io/v1alpha1"
+)
+
+func TestCleanupCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      deckhousev1.CleanupPolicy
+		wipeDevices []string
+		expected    string
+	}{
+		{
+			name:     "cleanup",
+			policy:   deckhousev1.CleanupPolicyCleanup,
+			expected: "test -f /var/lib/bashible/cleanup_static_node.sh || exit 0 && bash /var/lib/bashible/cleanup_static_node.sh --yes-i-am-sane-and-i-understand-what-i-am-doing",
+		},
+		{
+			name:        "quarantine ignores wipe devices",
+			policy:      deckhousev1.CleanupPolicyQuarantine,
+			wipeDevices: []string{"/dev/sdb"},
+			expected:    "test -f /var/lib/bashible/cleanup_static_node.sh || exit 0 && bash /var/lib/bashible/cleanup_static_node.sh --yes-i-am-sane-and-i-understand-what-i-am-doing",
+		},
+		{
+			name:        "reset",
+			policy:      deckhousev1.CleanupPolicyReset,
+			wipeDevices: []string{"/dev/sdb", "/dev/sdc"},
+			expected: "if test -f /var/lib/bashible/cleanup_static_node.sh; then grep -q -- --no-reboot /var/lib/bashible/cleanup_static_node.sh || { echo 'the cleanup script does not support --no-reboot yet' >&2; exit 1; }; fi" +
+				" && if test -f /var/lib/bashible/cleanup_static_node.sh; then bash /var/lib/bashible/cleanup_static_node.sh --yes-i-am-sane-and-i-understand-what-i-am-doing --no-reboot; fi" +
+				" && rm -rf /etc/kubernetes /var/lib/kubelet /var/lib/containerd /var/log/pods /var/log/containers" +
+				" && wipefs --all --force /dev/sdb" +
+				" && wipefs --all --force /dev/sdc" +
+				" && { (sleep 5 && shutdown -r now) & }",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := cleanupCommand(tt.policy, tt.wipeDevices)
+			if command != tt.expected {
+				t.Errorf("got %q | expected %q", command, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanupCommandRebootsAfterWipe(t *testing.T) {
+	command := cleanupCommand(deckhousev1.CleanupPolicyReset, []string{"/dev/sdb"})
+
+	wipe := strings.Index(command, "wipefs")
+	reboot := strings.Index(command, "shutdown -r now")
+	if wipe < 0 || reboot < wipe {
+		t.Errorf("devices must be wiped before the reboot: %q", command)
+	}
+	if !strings.Contains(command, "--no-reboot") {
+		t.Errorf("the cleanup script must not reboot the host before the devices are wiped: %q", command)
+	}
+}
@@ -144,6 +144,10 @@ func (r *StaticInstanceReconciler) reconcileNormal(
 		instanceScope.Logger.Info("StaticInstance is pending")
 	}
 
+	if instanceScope.GetPhase() == deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined {
+		return r.reconcileQuarantined(ctx, instanceScope)
+	}
+
 	if instanceScope.MachineScope != nil {
 		instances := &deckhousev1.StaticInstanceList{}
 
@@ -181,6 +185,30 @@ func (r *StaticInstanceReconciler) reconcileNormal(
 	return ctrl.Result{}, nil
 }
 
+// reconcileQuarantined returns the quarantined StaticInstance to the pool once an operator marks it clean.
+func (r *StaticInstanceReconciler) reconcileQuarantined(
+	ctx context.Context,
+	instanceScope *scope.InstanceScope,
+) (ctrl.Result, error) {
+	_, ok := instanceScope.Instance.Annotations[deckhousev1.StaticInstanceReleaseFromQuarantineAnnotation]
+	if !ok {
+		instanceScope.Logger.Info("StaticInstance is quarantined, waiting for an operator to release it")
+
+		return ctrl.Result{}, nil
+	}
+
+	delete(instanceScope.Instance.Annotations, deckhousev1.StaticInstanceReleaseFromQuarantineAnnotation)
+
+	err := instanceScope.ToPending(ctx)
+	if err != nil {
+		return ctrl.Result{}, errors.Wrap(err, "failed to release StaticInstance from quarantine")
+	}
+
+	r.Recorder.SendNormalEvent(instanceScope.Instance, "", "StaticInstanceReleasedFromQuarantine", "StaticInstance has been released from quarantine")
+
+	return ctrl.Result{}, nil
+}
+
 func (r *StaticInstanceReconciler) getStaticMachine(
 	ctx context.Context,
 	staticInstance *deckhousev1.StaticInstance,
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	deckhousev1 "caps-controller-manager/api/deckhouse.io/v1alpha1"
+	"caps-controller-manager/internal/event"
+	"caps-controller-manager/internal/scope"
+)
+
+func TestReconcileQuarantined(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    deckhousev1.StaticInstanceStatusCurrentStatusPhase
+	}{
+		{
+			name:     "waits for an operator",
+			expected: deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined,
+		},
+		{
+			name:        "released by an operator",
+			annotations: map[string]string{deckhousev1.StaticInstanceReleaseFromQuarantineAnnotation: ""},
+			expected:    deckhousev1.StaticInstanceStatusCurrentStatusPhasePending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			scheme := runtime.NewScheme()
+			if err := deckhousev1.AddToScheme(scheme); err != nil {
+				t.Fatal(err)
+			}
+
+			instance := &deckhousev1.StaticInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: "static-0", Annotations: tt.annotations},
+				Status: deckhousev1.StaticInstanceStatus{
+					CurrentStatus: &deckhousev1.StaticInstanceStatusCurrentStatus{
+						Phase: deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined,
+					},
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).WithStatusSubresource(instance).Build()
+
+			s, err := scope.NewScope(c, &rest.Config{}, logr.Discard())
+			if err != nil {
+				t.Fatal(err)
+			}
+			instanceScope, err := scope.NewInstanceScope(s, instance)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &StaticInstanceReconciler{Client: c, Scheme: scheme, Recorder: event.NewRecorder(c, logr.Discard())}
+
+			_, err = r.reconcileNormal(ctx, instanceScope)
+			if err != nil {
+				t.Fatalf("reconcile: %v", err)
+			}
+
+			got := &deckhousev1.StaticInstance{}
+			if err := c.Get(ctx, client.ObjectKey{Name: "static-0"}, got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.Status.CurrentStatus == nil || got.Status.CurrentStatus.Phase != tt.expected {
+				t.Errorf("phase: got %+v | expected %s", got.Status.CurrentStatus, tt.expected)
+			}
+			if _, ok := got.Annotations[deckhousev1.StaticInstanceReleaseFromQuarantineAnnotation]; ok {
+				t.Errorf("the release annotation must be removed")
+			}
+		})
+	}
+}
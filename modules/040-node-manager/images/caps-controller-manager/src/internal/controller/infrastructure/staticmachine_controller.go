@@ -318,9 +318,12 @@ func (r *StaticMachineReconciler) cleanup(
 			instanceScope.Logger.Error(err, "Failed to set StaticMachine error status")
 		}
 
-		err = instanceScope.ToPending(ctx)
+		// the host may be only partially cleaned up (e.g., devices are not wiped), so it is quarantined whatever the cleanup policy is
+		instanceScope.SetLastCleanup(deckhousev1.StaticInstanceStatusLastCleanupResultTimedOut, "Timed out waiting for StaticInstance to clean up")
+
+		err = instanceScope.ToQuarantined(ctx)
 		if err != nil {
-			instanceScope.Logger.Error(err, "Failed to set StaticInstance to Pending phase")
+			instanceScope.Logger.Error(err, "Failed to quarantine StaticInstance")
 		}
 
 		instanceScope.Logger.Error(errors.New("timed out waiting for StaticInstance to clean up"), "StaticInstance is cleaning")
@@ -136,6 +136,45 @@ func (i *InstanceScope) Patch(ctx context.Context) error {
 	return nil
 }
 
+// GetCleanupPolicy returns the cleanup policy of the static instance.
+// The policy set in the StaticInstance takes precedence over the one set in the SSHCredentials.
+func (i *InstanceScope) GetCleanupPolicy() deckhousev1.CleanupPolicy {
+	if i.Instance.Spec.CleanupPolicy != "" {
+		return i.Instance.Spec.CleanupPolicy
+	}
+
+	if i.Credentials != nil && i.Credentials.Spec.CleanupPolicy != "" {
+		return i.Credentials.Spec.CleanupPolicy
+	}
+
+	return deckhousev1.CleanupPolicyCleanup
+}
+
+// SetLastCleanup records the result of the last cleanup of the static instance.
+func (i *InstanceScope) SetLastCleanup(result deckhousev1.StaticInstanceStatusLastCleanupResult, message string) {
+	i.Instance.Status.LastCleanup = &deckhousev1.StaticInstanceStatusLastCleanup{
+		Time:    metav1.NewTime(time.Now().UTC()),
+		Policy:  i.GetCleanupPolicy(),
+		Result:  result,
+		Message: message,
+	}
+}
+
+// ToReleased returns the cleaned up static instance to the pool or puts it into quarantine depending on the cleanup policy.
+// The static instance whose last cleanup timed out is always quarantined.
+func (i *InstanceScope) ToReleased(ctx context.Context) error {
+	if i.GetCleanupPolicy() == deckhousev1.CleanupPolicyQuarantine || i.isLastCleanupTimedOut() {
+		return i.ToQuarantined(ctx)
+	}
+
+	return i.ToPending(ctx)
+}
+
+func (i *InstanceScope) isLastCleanupTimedOut() bool {
+	return i.Instance.Status.LastCleanup != nil &&
+		i.Instance.Status.LastCleanup.Result == deckhousev1.StaticInstanceStatusLastCleanupResultTimedOut
+}
+
 func (i *InstanceScope) ToPending(ctx context.Context) error {
 	i.Instance.Status.MachineRef = nil
 	i.Instance.Status.NodeRef = nil
@@ -153,6 +192,23 @@ func (i *InstanceScope) ToPending(ctx context.Context) error {
 	return nil
 }
 
+func (i *InstanceScope) ToQuarantined(ctx context.Context) error {
+	i.Instance.Status.MachineRef = nil
+	i.Instance.Status.NodeRef = nil
+	i.Instance.Status.CurrentStatus = nil
+
+	conditions.MarkFalse(i.Instance, infrav1.StaticInstanceBootstrapSucceededCondition, infrav1.StaticInstanceQuarantinedReason, clusterv1.ConditionSeverityWarning, "")
+
+	i.SetPhase(deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined)
+
+	err := i.Patch(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to set StaticInstance to Quarantined phase")
+	}
+
+	return nil
+}
+
 // Close the InstanceScope by updating the instance spec and status.
 func (i *InstanceScope) Close(ctx context.Context) error {
 	return i.Patch(ctx)
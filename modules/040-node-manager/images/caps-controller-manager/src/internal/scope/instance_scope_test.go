@@ -0,0 +1,188 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	deckhousev1 "caps-controller-manager/api/deckhouse.io/v1alpha1"
+)
+
+func newTestInstanceScope(t *testing.T, instance *deckhousev1.StaticInstance, credentials *deckhousev1.SSHCredentials) (*InstanceScope, client.Client) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	if err := deckhousev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(instance).
+		WithStatusSubresource(instance).
+		Build()
+
+	s, err := NewScope(c, &rest.Config{}, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instanceScope, err := NewInstanceScope(s, instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instanceScope.Credentials = credentials
+
+	return instanceScope, c
+}
+
+func newCleaningInstance(policy deckhousev1.CleanupPolicy) *deckhousev1.StaticInstance {
+	return &deckhousev1.StaticInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "static-0"},
+		Spec: deckhousev1.StaticInstanceSpec{
+			CleanupPolicy: policy,
+		},
+		Status: deckhousev1.StaticInstanceStatus{
+			NodeRef:    &corev1.ObjectReference{Name: "static-0"},
+			MachineRef: &corev1.ObjectReference{Name: "static-0-machine"},
+			CurrentStatus: &deckhousev1.StaticInstanceStatusCurrentStatus{
+				Phase: deckhousev1.StaticInstanceStatusCurrentStatusPhaseCleaning,
+			},
+		},
+	}
+}
+
+func TestGetCleanupPolicy(t *testing.T) {
+	credentials := &deckhousev1.SSHCredentials{
+		Spec: deckhousev1.SSHCredentialsSpec{CleanupPolicy: deckhousev1.CleanupPolicyQuarantine},
+	}
+
+	tests := []struct {
+		name        string
+		policy      deckhousev1.CleanupPolicy
+		credentials *deckhousev1.SSHCredentials
+		expected    deckhousev1.CleanupPolicy
+	}{
+		{name: "default", expected: deckhousev1.CleanupPolicyCleanup},
+		{name: "from credentials", credentials: credentials, expected: deckhousev1.CleanupPolicyQuarantine},
+		{name: "instance overrides credentials", policy: deckhousev1.CleanupPolicyReset, credentials: credentials, expected: deckhousev1.CleanupPolicyReset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceScope, _ := newTestInstanceScope(t, newCleaningInstance(tt.policy), tt.credentials)
+
+			if policy := instanceScope.GetCleanupPolicy(); policy != tt.expected {
+				t.Errorf("got %s | expected %s", policy, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToReleased(t *testing.T) {
+	tests := []struct {
+		policy   deckhousev1.CleanupPolicy
+		expected deckhousev1.StaticInstanceStatusCurrentStatusPhase
+	}{
+		{policy: deckhousev1.CleanupPolicyCleanup, expected: deckhousev1.StaticInstanceStatusCurrentStatusPhasePending},
+		{policy: deckhousev1.CleanupPolicyReset, expected: deckhousev1.StaticInstanceStatusCurrentStatusPhasePending},
+		{policy: deckhousev1.CleanupPolicyQuarantine, expected: deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			ctx := context.Background()
+			instanceScope, c := newTestInstanceScope(t, newCleaningInstance(tt.policy), nil)
+
+			if err := instanceScope.ToReleased(ctx); err != nil {
+				t.Fatalf("release: %v", err)
+			}
+
+			instance := &deckhousev1.StaticInstance{}
+			if err := c.Get(ctx, client.ObjectKey{Name: "static-0"}, instance); err != nil {
+				t.Fatal(err)
+			}
+
+			if instance.Status.CurrentStatus == nil || instance.Status.CurrentStatus.Phase != tt.expected {
+				t.Errorf("phase: got %+v | expected %s", instance.Status.CurrentStatus, tt.expected)
+			}
+			if instance.Status.NodeRef != nil || instance.Status.MachineRef != nil {
+				t.Errorf("node and machine refs must be removed: %+v", instance.Status)
+			}
+		})
+	}
+}
+
+func TestToReleasedAfterTimeout(t *testing.T) {
+	for _, policy := range []deckhousev1.CleanupPolicy{deckhousev1.CleanupPolicyCleanup, deckhousev1.CleanupPolicyReset} {
+		t.Run(string(policy), func(t *testing.T) {
+			ctx := context.Background()
+			instanceScope, c := newTestInstanceScope(t, newCleaningInstance(policy), nil)
+
+			instanceScope.SetLastCleanup(deckhousev1.StaticInstanceStatusLastCleanupResultTimedOut, "timed out")
+
+			if err := instanceScope.ToReleased(ctx); err != nil {
+				t.Fatalf("release: %v", err)
+			}
+
+			instance := &deckhousev1.StaticInstance{}
+			if err := c.Get(ctx, client.ObjectKey{Name: "static-0"}, instance); err != nil {
+				t.Fatal(err)
+			}
+
+			if instance.Status.CurrentStatus == nil || instance.Status.CurrentStatus.Phase != deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined {
+				t.Errorf("phase: got %+v | expected Quarantined", instance.Status.CurrentStatus)
+			}
+			if instance.Status.LastCleanup == nil || instance.Status.LastCleanup.Result != deckhousev1.StaticInstanceStatusLastCleanupResultTimedOut {
+				t.Errorf("last cleanup: got %+v | expected TimedOut", instance.Status.LastCleanup)
+			}
+		})
+	}
+}
+
+func TestToPendingFromQuarantined(t *testing.T) {
+	ctx := context.Background()
+
+	instance := newCleaningInstance(deckhousev1.CleanupPolicyQuarantine)
+	instance.Status.NodeRef = nil
+	instance.Status.MachineRef = nil
+	instance.Status.CurrentStatus.Phase = deckhousev1.StaticInstanceStatusCurrentStatusPhaseQuarantined
+
+	instanceScope, c := newTestInstanceScope(t, instance, nil)
+
+	if err := instanceScope.ToPending(ctx); err != nil {
+		t.Fatalf("release from quarantine: %v", err)
+	}
+
+	got := &deckhousev1.StaticInstance{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "static-0"}, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status.CurrentStatus == nil || got.Status.CurrentStatus.Phase != deckhousev1.StaticInstanceStatusCurrentStatusPhasePending {
+		t.Errorf("phase: got %+v | expected Pending", got.Status.CurrentStatus)
+	}
+}
@@ -28,39 +28,28 @@ import (
 
 	"github.com/pkg/errors"
 
+	deckhousev1 "caps-controller-manager/api/deckhouse.io/v1alpha1"
 	"caps-controller-manager/internal/scope"
 )
 
+const defaultSSHPort = 22
+
 // ExecSSHCommand executes a command on the StaticInstance.
 func ExecSSHCommand(instanceScope *scope.InstanceScope, command string, stdout io.Writer) error {
-	privateSSHKey, err := base64.StdEncoding.DecodeString(instanceScope.Credentials.Spec.PrivateSSHKey)
-	if err != nil {
-		return errors.Wrap(err, "failed to decode private ssh key")
-	}
-
-	dir := os.TempDir()
-	sshKey := filepath.Join(dir, "ssh-key")
-
-	err = os.WriteFile(sshKey, privateSSHKey, 0600)
+	dir, err := os.MkdirTemp("", "caps-ssh-")
 	if err != nil {
-		return errors.Wrap(err, "failed to write private ssh key to temporary file")
+		return errors.Wrap(err, "failed to create temporary directory for ssh files")
 	}
-
-	args := []string{
-		"-qv",
-		"-i",
-		sshKey,
-		"-o",
-		"StrictHostKeyChecking=no",
-		fmt.Sprintf("-p %d", instanceScope.Credentials.Spec.SSHPort),
-	}
-
-	for _, arg := range strings.Split(instanceScope.Credentials.Spec.SSHExtraArgs, " ") {
-		if arg == "" {
-			continue
+	defer func() {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			instanceScope.Logger.Error(err, "Failed to remove temporary directory for ssh files")
 		}
+	}()
 
-		args = append(args, arg)
+	args, err := sshArgs(dir, instanceScope.Credentials.Spec)
+	if err != nil {
+		return errors.Wrap(err, "failed to prepare ssh arguments")
 	}
 
 	var stdin io.Reader
@@ -116,3 +105,146 @@ func ExecSSHCommandToString(instanceScope *scope.InstanceScope, command string)
 
 	return strings.TrimSpace(string(stdoutBytes)), nil
 }
+
+// sshArgs returns the OpenSSH client arguments for the given credentials.
+// Private keys, certificates and known hosts are written to dir, which must exist until the command finishes.
+func sshArgs(dir string, spec deckhousev1.SSHCredentialsSpec) ([]string, error) {
+	identityArgs, err := writeIdentity(dir, "ssh-key", spec.PrivateSSHKey, spec.SSHCertificate)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write ssh identity")
+	}
+
+	hostKeyArgs, err := writeKnownHosts(dir, spec.KnownHosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write known hosts")
+	}
+
+	args := []string{"-qv"}
+	args = append(args, identityArgs...)
+	args = append(args, hostKeyArgs...)
+	args = append(args, fmt.Sprintf("-p %d", spec.SSHPort))
+
+	if spec.Bastion != nil {
+		proxyCommand, err := bastionProxyCommand(dir, spec, hostKeyArgs)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to prepare bastion proxy command")
+		}
+
+		args = append(args, "-o", "ProxyCommand="+proxyCommand)
+	}
+
+	for _, arg := range strings.Split(spec.SSHExtraArgs, " ") {
+		if arg == "" {
+			continue
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// bastionProxyCommand returns the ProxyCommand that connects to the StaticInstance through the bastion host.
+func bastionProxyCommand(dir string, spec deckhousev1.SSHCredentialsSpec, hostKeyArgs []string) (string, error) {
+	bastion := spec.Bastion
+
+	privateSSHKey := bastion.PrivateSSHKey
+	sshCertificate := bastion.SSHCertificate
+
+	if privateSSHKey == "" {
+		privateSSHKey = spec.PrivateSSHKey
+
+		if sshCertificate == "" {
+			sshCertificate = spec.SSHCertificate
+		}
+	}
+
+	identityArgs, err := writeIdentity(dir, "bastion-ssh-key", privateSSHKey, sshCertificate)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write bastion ssh identity")
+	}
+
+	user := bastion.User
+	if user == "" {
+		user = spec.User
+	}
+
+	port := bastion.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+
+	args := []string{"ssh", "-q"}
+	args = append(args, identityArgs...)
+	args = append(args, hostKeyArgs...)
+	args = append(args, "-p", fmt.Sprint(port), "-W", "%h:%p", fmt.Sprintf("%s@%s", user, bastion.Host))
+
+	// OpenSSH runs the ProxyCommand with the shell, so every argument is quoted
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	return strings.Join(quoted, " "), nil
+}
+
+// shellQuote quotes the string for the POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// writeIdentity writes the private key and the certificate to dir and returns the arguments to use them.
+func writeIdentity(dir, name, encodedPrivateSSHKey, encodedSSHCertificate string) ([]string, error) {
+	privateSSHKey, err := base64.StdEncoding.DecodeString(encodedPrivateSSHKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode private ssh key")
+	}
+
+	sshKey := filepath.Join(dir, name)
+
+	err = os.WriteFile(sshKey, privateSSHKey, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write private ssh key to temporary file")
+	}
+
+	args := []string{"-i", sshKey}
+
+	if encodedSSHCertificate == "" {
+		return args, nil
+	}
+
+	sshCertificate, err := base64.StdEncoding.DecodeString(encodedSSHCertificate)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode ssh certificate")
+	}
+
+	sshCertificatePath := sshKey + "-cert.pub"
+
+	err = os.WriteFile(sshCertificatePath, sshCertificate, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write ssh certificate to temporary file")
+	}
+
+	return append(args, "-o", "CertificateFile="+sshCertificatePath), nil
+}
+
+// writeKnownHosts writes the known hosts to dir and returns the arguments that enable host key checking.
+// If known hosts are not set, host key checking is disabled.
+func writeKnownHosts(dir, knownHosts string) ([]string, error) {
+	if knownHosts == "" {
+		return []string{"-o", "StrictHostKeyChecking=no"}, nil
+	}
+
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+
+	err := os.WriteFile(knownHostsPath, []byte(knownHosts), 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write known hosts to temporary file")
+	}
+
+	return []string{
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=" + knownHostsPath,
+		"-o", "GlobalKnownHostsFile=/dev/null",
+	}, nil
+}
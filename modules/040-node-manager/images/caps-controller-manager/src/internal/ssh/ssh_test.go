@@ -0,0 +1,146 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ssh
+
+import (
+	"encoding/base64"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	deckhousev1 "caps-controller-manager/api/deckhouse.io/v1alpha1"
+)
+
+var testKey = base64.StdEncoding.EncodeToString([]byte("private key"))
+
+func proxyCommand(t *testing.T, args []string) string {
+	t.Helper()
+
+	for i, arg := range args {
+		if arg == "-o" && i+1 < len(args) && strings.HasPrefix(args[i+1], "ProxyCommand=") {
+			return strings.TrimPrefix(args[i+1], "ProxyCommand=")
+		}
+	}
+
+	t.Fatalf("ProxyCommand is not set: %v", args)
+	return ""
+}
+
+func TestSSHArgs(t *testing.T) {
+	dir := t.TempDir()
+
+	args, err := sshArgs(dir, deckhousev1.SSHCredentialsSpec{
+		User:          "ubuntu",
+		PrivateSSHKey: testKey,
+		SSHPort:       2222,
+		SSHExtraArgs:  "-o  ConnectTimeout=5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"-qv",
+		"-i", filepath.Join(dir, "ssh-key"),
+		"-o", "StrictHostKeyChecking=no",
+		"-p 2222",
+		"-o", "ConnectTimeout=5",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("got %q | expected %q", args, expected)
+	}
+}
+
+func TestSSHArgsBastion(t *testing.T) {
+	dir := t.TempDir()
+
+	args, err := sshArgs(dir, deckhousev1.SSHCredentialsSpec{
+		User:          "ubuntu",
+		PrivateSSHKey: testKey,
+		SSHPort:       22,
+		KnownHosts:    "10.0.0.1 ssh-ed25519 AAAA",
+		Bastion: &deckhousev1.SSHBastion{
+			Host: "bastion.example.com",
+			User: "jump",
+			Port: 2222,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	knownHosts := filepath.Join(dir, "known_hosts")
+	expected := strings.Join([]string{
+		"'ssh'", "'-q'",
+		"'-i'", "'" + filepath.Join(dir, "bastion-ssh-key") + "'",
+		"'-o'", "'StrictHostKeyChecking=yes'",
+		"'-o'", "'UserKnownHostsFile=" + knownHosts + "'",
+		"'-o'", "'GlobalKnownHostsFile=/dev/null'",
+		"'-p'", "'2222'",
+		"'-W'", "'%h:%p'",
+		"'jump@bastion.example.com'",
+	}, " ")
+
+	if command := proxyCommand(t, args); command != expected {
+		t.Errorf("got %q | expected %q", command, expected)
+	}
+}
+
+func TestBastionProxyCommandIsQuoted(t *testing.T) {
+	dir := t.TempDir()
+
+	// the values are rejected by the webhook, but the command must be safe anyway
+	args, err := sshArgs(dir, deckhousev1.SSHCredentialsSpec{
+		User:          "ubuntu",
+		PrivateSSHKey: testKey,
+		Bastion: &deckhousev1.SSHBastion{
+			Host: "host;touch pwned",
+			User: "it's$(id)",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the shell must pass the values to ssh as is
+	out, err := exec.Command("sh", "-c", "printf '%s\\n' "+proxyCommand(t, args)).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if last := lines[len(lines)-1]; last != "it's$(id)@host;touch pwned" {
+		t.Errorf("got %q | expected the destination to be passed as a single argument", last)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"":          "''",
+		"plain":     "'plain'",
+		"it's":      `'it'\''s'`,
+		"$(id) `x`": "'$(id) `x`'",
+	}
+
+	for in, expected := range tests {
+		if got := shellQuote(in); got != expected {
+			t.Errorf("shellQuote(%q): got %q | expected %q", in, got, expected)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
+	"github.com/flant/addon-operator/sdk"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/deckhouse/deckhouse/modules/140-user-authz/hooks/internal"
+)
+
+// This hook grants access requested by approved AuthorizationRequests.
+// An AuthorizationRule is created in each requested namespace and deleted when the requested duration expires.
+
+const (
+	authRequestSnapshot      = "authorization_requests"
+	authRequestNsSnapshot    = "namespaces"
+	authRequestRulePrefix    = "access-request-"
+	authRequestLabelKey      = "user-authz.deckhouse.io/access-request"
+	authRequestPhasePending  = "Pending"
+	authRequestPhaseActive   = "Active"
+	authRequestPhaseRejected = "Rejected"
+	authRequestPhaseExpired  = "Expired"
+)
+
+var _ = sdk.RegisterFunc(&go_hook.HookConfig{
+	Queue: internal.Queue(authRequestSnapshot),
+	Schedule: []go_hook.ScheduleConfig{
+		{
+			Name:    "expire_requests",
+			Crontab: "* * * * *",
+		},
+	},
+	Kubernetes: []go_hook.KubernetesConfig{
+		{
+			Name:       authRequestSnapshot,
+			ApiVersion: "deckhouse.io/v1alpha1",
+			Kind:       "AuthorizationRequest",
+			FilterFunc: filterAuthorizationRequest,
+		},
+		{
+			Name:       authRequestNsSnapshot,
+			ApiVersion: "v1",
+			Kind:       "Namespace",
+			FilterFunc: filterAuthorizationRequestNamespace,
+		},
+	},
+}, handleAuthorizationRequests)
+
+type authorizationRequest struct {
+	Name   string
+	UID    k8stypes.UID
+	Spec   authorizationRequestSpec
+	Status authorizationRequestStatus
+}
+
+type authorizationRequestSpec struct {
+	Requester      string   `json:"requester"`
+	AccessLevel    string   `json:"accessLevel"`
+	PortForwarding bool     `json:"portForwarding"`
+	Namespaces     []string `json:"namespaces"`
+	Duration       string   `json:"duration"`
+	Approval       *struct {
+		Approved bool   `json:"approved"`
+		Approver string `json:"approver"`
+		Comment  string `json:"comment"`
+	} `json:"approval"`
+}
+
+type authorizationRequestStatus struct {
+	Phase      string       `json:"phase,omitempty"`
+	GrantedAt  *metav1.Time `json:"grantedAt,omitempty"`
+	ValidUntil *metav1.Time `json:"validUntil,omitempty"`
+	Message    string       `json:"message,omitempty"`
+}
+
+func filterAuthorizationRequest(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var req struct {
+		Spec   authorizationRequestSpec   `json:"spec"`
+		Status authorizationRequestStatus `json:"status"`
+	}
+
+	err := sdk.FromUnstructured(obj, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	return authorizationRequest{
+		Name:   obj.GetName(),
+		UID:    obj.GetUID(),
+		Spec:   req.Spec,
+		Status: req.Status,
+	}, nil
+}
+
+func filterAuthorizationRequestNamespace(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	return obj.GetName(), nil
+}
+
+func handleAuthorizationRequests(input *go_hook.HookInput) error {
+	namespaces := make(map[string]struct{}, len(input.Snapshots[authRequestNsSnapshot]))
+	for _, ns := range input.Snapshots[authRequestNsSnapshot] {
+		namespaces[ns.(string)] = struct{}{}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, s := range input.Snapshots[authRequestSnapshot] {
+		req := s.(authorizationRequest)
+
+		switch {
+		case req.Status.Phase == authRequestPhaseExpired || req.Status.Phase == authRequestPhaseRejected:
+			continue
+
+		case req.Spec.Approval == nil:
+			if req.Status.Phase != authRequestPhasePending {
+				patchAuthorizationRequestStatus(input, req.Name, authorizationRequestStatus{
+					Phase:   authRequestPhasePending,
+					Message: "waiting for the approval",
+				})
+			}
+
+		case !req.Spec.Approval.Approved:
+			msg := fmt.Sprintf("rejected by %s", req.Spec.Approval.Approver)
+			if req.Spec.Approval.Comment != "" {
+				msg += ": " + req.Spec.Approval.Comment
+			}
+			patchAuthorizationRequestStatus(input, req.Name, authorizationRequestStatus{Phase: authRequestPhaseRejected, Message: msg})
+			input.PatchCollector.Create(authorizationRequestEvent(&req, "AccessRejected", msg, now))
+
+		case req.Status.Phase != authRequestPhaseActive:
+			grantAuthorizationRequest(input, &req, namespaces, now)
+
+		case req.Status.ValidUntil == nil || !now.Before(req.Status.ValidUntil.Time):
+			deleteAuthorizationRequestRules(input, &req)
+			msg := fmt.Sprintf("access of %s has expired", req.Spec.Requester)
+			patchAuthorizationRequestStatus(input, req.Name, authorizationRequestStatus{
+				Phase:      authRequestPhaseExpired,
+				GrantedAt:  req.Status.GrantedAt,
+				ValidUntil: req.Status.ValidUntil,
+				Message:    msg,
+			})
+			input.PatchCollector.Create(authorizationRequestEvent(&req, "AccessExpired", msg, now))
+
+		default:
+			// restore rules deleted by someone or create rules in namespaces created after the approval
+			for _, ns := range req.Spec.Namespaces {
+				if _, ok := namespaces[ns]; ok {
+					input.PatchCollector.Create(authorizationRequestRule(&req, ns, req.Status.ValidUntil), object_patch.UpdateIfExists())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func grantAuthorizationRequest(input *go_hook.HookInput, req *authorizationRequest, namespaces map[string]struct{}, now time.Time) {
+	duration, err := time.ParseDuration(req.Spec.Duration)
+	if err != nil || duration <= 0 {
+		patchAuthorizationRequestStatus(input, req.Name, authorizationRequestStatus{
+			Phase:   authRequestPhaseRejected,
+			Message: fmt.Sprintf("invalid duration %q", req.Spec.Duration),
+		})
+		return
+	}
+
+	grantedAt := metav1.NewTime(now)
+	validUntil := metav1.NewTime(now.Add(duration))
+
+	var missing []string
+	for _, ns := range req.Spec.Namespaces {
+		if _, ok := namespaces[ns]; !ok {
+			missing = append(missing, ns)
+			continue
+		}
+		input.PatchCollector.Create(authorizationRequestRule(req, ns, &validUntil), object_patch.UpdateIfExists())
+	}
+
+	msg := fmt.Sprintf("%s access to %s is granted to %s by %s until %s",
+		req.Spec.AccessLevel, strings.Join(req.Spec.Namespaces, ", "), req.Spec.Requester, req.Spec.Approval.Approver, validUntil.Format(time.RFC3339))
+	status := authorizationRequestStatus{
+		Phase:      authRequestPhaseActive,
+		GrantedAt:  &grantedAt,
+		ValidUntil: &validUntil,
+		Message:    msg,
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		status.Message += fmt.Sprintf(", namespaces %s do not exist yet", strings.Join(missing, ", "))
+	}
+
+	patchAuthorizationRequestStatus(input, req.Name, status)
+	input.PatchCollector.Create(authorizationRequestEvent(req, "AccessGranted", msg, now))
+}
+
+func deleteAuthorizationRequestRules(input *go_hook.HookInput, req *authorizationRequest) {
+	for _, ns := range req.Spec.Namespaces {
+		input.PatchCollector.Delete("deckhouse.io/v1alpha1", "AuthorizationRule", ns, authRequestRulePrefix+req.Name, object_patch.InBackground())
+	}
+}
+
+func patchAuthorizationRequestStatus(input *go_hook.HookInput, name string, status authorizationRequestStatus) {
+	input.PatchCollector.MergePatch(
+		map[string]interface{}{"status": status},
+		"deckhouse.io/v1alpha1", "AuthorizationRequest", "", name,
+		object_patch.WithSubresource("/status"), object_patch.IgnoreMissingObject(),
+	)
+}
+
+func authorizationRequestRule(req *authorizationRequest, namespace string, validUntil *metav1.Time) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "deckhouse.io/v1alpha1",
+		"kind":       "AuthorizationRule",
+		"metadata": map[string]interface{}{
+			"name":      authRequestRulePrefix + req.Name,
+			"namespace": namespace,
+			"labels": map[string]interface{}{
+				"heritage":          "deckhouse",
+				authRequestLabelKey: req.Name,
+			},
+			"ownerReferences": []interface{}{
+				map[string]interface{}{
+					"apiVersion": "deckhouse.io/v1alpha1",
+					"kind":       "AuthorizationRequest",
+					"name":       req.Name,
+					"uid":        string(req.UID),
+				},
+			},
+		},
+		"spec": map[string]interface{}{
+			"accessLevel":    req.Spec.AccessLevel,
+			"portForwarding": req.Spec.PortForwarding,
+			"validUntil":     validUntil.UTC().Format(time.RFC3339),
+			"subjects": []interface{}{
+				map[string]interface{}{
+					"kind": "User",
+					"name": req.Spec.Requester,
+				},
+			},
+		},
+	}}
+}
+
+func authorizationRequestEvent(req *authorizationRequest, reason, msg string, now time.Time) *eventsv1.Event {
+	return &eventsv1.Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: "events.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			// 'default' namespace is used for linking this event with an AuthorizationRequest object, which is global
+			Namespace: "default",
+			// the same naming as client-go event recorder uses, it is unique for a single event of the request per run
+			Name: fmt.Sprintf("%s.%x", req.Name, now.UnixNano()),
+		},
+		Regarding: corev1.ObjectReference{
+			Kind:       "AuthorizationRequest",
+			Name:       req.Name,
+			UID:        req.UID,
+			APIVersion: "deckhouse.io/v1alpha1",
+		},
+		Reason:              reason,
+		Note:                msg,
+		Type:                corev1.EventTypeNormal,
+		EventTime:           metav1.MicroTime{Time: now},
+		Action:              "Binding",
+		ReportingInstance:   "deckhouse",
+		ReportingController: "deckhouse",
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/deckhouse/deckhouse/testing/hooks"
+)
+
+const stateAuthRequestNamespaces = `
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: prod
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: stage
+`
+
+var _ = Describe("User Authz hooks :: handle authorization requests ::", func() {
+	f := HookExecutionConfigInit(`{"userAuthz":{"internal":{}}}`, `{}`)
+	f.RegisterCRD("deckhouse.io", "v1alpha1", "AuthorizationRequest", false)
+	f.RegisterCRD("deckhouse.io", "v1alpha1", "AuthorizationRule", true)
+
+	Context("Requests without an active grant", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateAuthRequestNamespaces + `
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: AuthorizationRequest
+metadata:
+  name: pending
+spec:
+  requester: jane@example.com
+  accessLevel: Editor
+  namespaces: [prod]
+  duration: 4h
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: AuthorizationRequest
+metadata:
+  name: rejected
+spec:
+  requester: jane@example.com
+  accessLevel: Admin
+  namespaces: [prod]
+  duration: 4h
+  approval:
+    approved: false
+    approver: admin@example.com
+    comment: too much
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: AuthorizationRequest
+metadata:
+  name: approved
+spec:
+  requester: jane@example.com
+  accessLevel: PrivilegedUser
+  namespaces: [prod, stage, dev]
+  duration: 2h30m
+  approval:
+    approved: true
+    approver: admin@example.com
+`)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Should set statuses and grant approved access", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			pending := f.KubernetesGlobalResource("AuthorizationRequest", "pending")
+			Expect(pending.Field("status.phase").String()).To(Equal("Pending"))
+
+			rejected := f.KubernetesGlobalResource("AuthorizationRequest", "rejected")
+			Expect(rejected.Field("status.phase").String()).To(Equal("Rejected"))
+			Expect(rejected.Field("status.message").String()).To(Equal("rejected by admin@example.com: too much"))
+			Expect(f.KubernetesResource("AuthorizationRule", "prod", "access-request-rejected").Exists()).To(BeFalse())
+
+			approved := f.KubernetesGlobalResource("AuthorizationRequest", "approved")
+			Expect(approved.Field("status.phase").String()).To(Equal("Active"))
+			Expect(approved.Field("status.message").String()).To(HaveSuffix("namespaces dev do not exist yet"))
+
+			grantedAt, err := time.Parse(time.RFC3339, approved.Field("status.grantedAt").String())
+			Expect(err).ToNot(HaveOccurred())
+			validUntil, err := time.Parse(time.RFC3339, approved.Field("status.validUntil").String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(validUntil.Sub(grantedAt)).To(Equal(150 * time.Minute))
+
+			for _, ns := range []string{"prod", "stage"} {
+				rule := f.KubernetesResource("AuthorizationRule", ns, "access-request-approved")
+				Expect(rule.Exists()).To(BeTrue())
+				Expect(rule.Field("spec.accessLevel").String()).To(Equal("PrivilegedUser"))
+				Expect(rule.Field("spec.validUntil").String()).To(Equal(approved.Field("status.validUntil").String()))
+				Expect(rule.Field("spec.subjects").String()).To(MatchJSON(`[{"kind":"User","name":"jane@example.com"}]`))
+				Expect(rule.Field("metadata.ownerReferences.0.name").String()).To(Equal("approved"))
+			}
+			Expect(f.KubernetesResource("AuthorizationRule", "dev", "access-request-approved").Exists()).To(BeFalse())
+		})
+	})
+
+	Context("Active request with expired access", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateAuthRequestNamespaces + `
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: AuthorizationRequest
+metadata:
+  name: expired
+spec:
+  requester: jane@example.com
+  accessLevel: Editor
+  namespaces: [prod]
+  duration: 1h
+  approval:
+    approved: true
+    approver: admin@example.com
+status:
+  phase: Active
+  grantedAt: "2023-01-01T00:00:00Z"
+  validUntil: "2023-01-01T01:00:00Z"
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: AuthorizationRule
+metadata:
+  name: access-request-expired
+  namespace: prod
+spec:
+  accessLevel: Editor
+  validUntil: "2023-01-01T01:00:00Z"
+  subjects:
+  - kind: User
+    name: jane@example.com
+`)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Should revoke access", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			expired := f.KubernetesGlobalResource("AuthorizationRequest", "expired")
+			Expect(expired.Field("status.phase").String()).To(Equal("Expired"))
+			Expect(expired.Field("status.validUntil").String()).To(Equal("2023-01-01T01:00:00Z"))
+			Expect(f.KubernetesResource("AuthorizationRule", "prod", "access-request-expired").Exists()).To(BeFalse())
+		})
+	})
+})
@@ -29,6 +29,13 @@ const (
 
 var _ = sdk.RegisterFunc(&go_hook.HookConfig{
 	Queue: internal.Queue(authRuleSnapshot),
+	Schedule: []go_hook.ScheduleConfig{
+		// expire rules with the validUntil field
+		{
+			Name:    "expire_rules",
+			Crontab: "* * * * *",
+		},
+	},
 	Kubernetes: []go_hook.KubernetesConfig{
 		{
 			Name:       authRuleSnapshot,
@@ -17,8 +17,13 @@ limitations under the License.
 package hooks
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	. "github.com/deckhouse/deckhouse/testing/hooks"
 )
@@ -45,6 +50,30 @@ spec:
   subjects:
   - kind: Group
     name: Everyone
+`
+	stateExpiredClusterAuthRules = `
+---
+apiVersion: deckhouse.io/v1
+kind: ClusterAuthorizationRule
+metadata:
+  name: expired
+spec:
+  accessLevel: ClusterAdmin
+  validUntil: "2020-01-01T00:00:00Z"
+  subjects:
+  - kind: Group
+    name: Everyone
+---
+apiVersion: deckhouse.io/v1
+kind: ClusterAuthorizationRule
+metadata:
+  name: valid
+spec:
+  accessLevel: User
+  validUntil: "2100-01-01T00:00:00Z"
+  subjects:
+  - kind: Group
+    name: Everyone
 `
 )
 
@@ -52,6 +81,13 @@ var _ = Describe("User Authz hooks :: handle cluster authorization rules ::", fu
 	f := HookExecutionConfigInit(`{"userAuthz":{"internal":{}}}`, `{}`)
 	f.RegisterCRD("deckhouse.io", "v1", "ClusterAuthorizationRule", false)
 
+	ruleEvents := func() []unstructured.Unstructured {
+		gvr := schema.GroupVersionResource{Group: "events.k8s.io", Version: "v1", Resource: "events"}
+		list, err := f.KubeClient().Dynamic().Resource(gvr).Namespace("default").List(context.TODO(), metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return list.Items
+	}
+
 	Context("Empty cluster", func() {
 		BeforeEach(func() {
 			f.BindingContexts.Set(f.KubeStateSet(``))
@@ -75,4 +111,55 @@ var _ = Describe("User Authz hooks :: handle cluster authorization rules ::", fu
 			Expect(f.ValuesGet("userAuthz.internal.clusterAuthRuleCrds").String()).To(MatchJSON(`[{"name":"car0","spec":{"accessLevel":"ClusterEditor", "subjects":[{"kind":"Group", "name":"NotEveryone"}]}},{"name":"car1","spec":{"accessLevel":"ClusterAdmin", "subjects":[{"kind":"Group", "name":"Everyone"}]}}]`))
 		})
 	})
+
+	Context("Cluster with expiring CARs", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(stateExpiredClusterAuthRules)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Expired CARs must not be stored in values and the expiration must be reported", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.ValuesGet("userAuthz.internal.clusterAuthRuleCrds").String()).To(MatchJSON(`[{"name":"valid","spec":{"accessLevel":"User", "validUntil":"2100-01-01T00:00:00Z", "subjects":[{"kind":"Group", "name":"Everyone"}]}}]`))
+
+			Expect(f.KubernetesGlobalResource("ClusterAuthorizationRule", "expired").Field(`metadata.annotations.user-authz\.deckhouse\.io/expired`).Exists()).To(BeTrue())
+			Expect(f.KubernetesGlobalResource("ClusterAuthorizationRule", "valid").Field(`metadata.annotations`).Exists()).To(BeFalse())
+
+			events := ruleEvents()
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Object).To(HaveKeyWithValue("reason", "AccessExpired"))
+			Expect(events[0].Object["regarding"]).To(HaveKeyWithValue("kind", "ClusterAuthorizationRule"))
+			Expect(events[0].Object["regarding"]).To(HaveKeyWithValue("name", "expired"))
+		})
+	})
+
+	Context("Cluster with expired CAR which expiration is reported", func() {
+		BeforeEach(func() {
+			f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: ClusterAuthorizationRule
+metadata:
+  name: expired
+  annotations:
+    user-authz.deckhouse.io/expired: ""
+spec:
+  accessLevel: ClusterAdmin
+  validUntil: "2020-01-01T00:00:00Z"
+  subjects:
+  - kind: Group
+    name: Everyone
+`)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Event must not be emitted again", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.ValuesGet("userAuthz.internal.clusterAuthRuleCrds").String()).To(MatchJSON(`[]`))
+
+			Expect(ruleEvents()).To(BeEmpty())
+		})
+	})
 })
@@ -18,16 +18,30 @@ package internal
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 )
 
+// expiredAnnotation marks a rule which expiration is already reported by an event
+const expiredAnnotation = "user-authz.deckhouse.io/expired"
+
 type authorizationRule struct {
 	Name      string                 `json:"name"`
 	Spec      map[string]interface{} `json:"spec"`
 	Namespace string                 `json:"namespace,omitempty"`
+
+	APIVersion      string       `json:"-"`
+	Kind            string       `json:"-"`
+	UID             k8stypes.UID `json:"-"`
+	ExpiryIsEmitted bool         `json:"-"`
 }
 
 func ApplyAuthorizationRuleFilter(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
@@ -39,10 +53,16 @@ func ApplyAuthorizationRuleFilter(obj *unstructured.Unstructured) (go_hook.Filte
 		return nil, err
 	}
 
+	_, expiryIsEmitted := obj.GetAnnotations()[expiredAnnotation]
+
 	car := &authorizationRule{
-		Name:      obj.GetName(),
-		Namespace: obj.GetNamespace(),
-		Spec:      spec,
+		Name:            obj.GetName(),
+		Namespace:       obj.GetNamespace(),
+		Spec:            spec,
+		APIVersion:      obj.GetAPIVersion(),
+		Kind:            obj.GetKind(),
+		UID:             obj.GetUID(),
+		ExpiryIsEmitted: expiryIsEmitted,
 	}
 
 	return car, nil
@@ -50,19 +70,96 @@ func ApplyAuthorizationRuleFilter(obj *unstructured.Unstructured) (go_hook.Filte
 
 func AuthorizationRulesHandler(valuesPath, snapshotKey string) func(input *go_hook.HookInput) error {
 	return func(input *go_hook.HookInput) error {
-		input.Values.Set(valuesPath, snapshotsToAuthorizationRulesSlice(input.Snapshots[snapshotKey]))
+		now := time.Now().UTC()
+
+		for _, snapshot := range input.Snapshots[snapshotKey] {
+			if snapshot == nil {
+				continue
+			}
+			ar := snapshot.(*authorizationRule)
+			if !ar.isExpired(now) || ar.ExpiryIsEmitted {
+				continue
+			}
+
+			// the annotation prevents the event from being emitted on every run
+			input.PatchCollector.MergePatch(expiredAnnotationPatch, ar.APIVersion, ar.Kind, ar.Namespace, ar.Name, object_patch.IgnoreMissingObject())
+			input.PatchCollector.Create(ar.expiryEvent(now))
+		}
+
+		input.Values.Set(valuesPath, snapshotsToAuthorizationRulesSlice(input.Snapshots[snapshotKey], now))
 		return nil
 	}
 }
 
-func snapshotsToAuthorizationRulesSlice(snapshots []go_hook.FilterResult) []authorizationRule {
+var expiredAnnotationPatch = map[string]interface{}{
+	"metadata": map[string]interface{}{
+		"annotations": map[string]interface{}{
+			expiredAnnotation: "",
+		},
+	},
+}
+
+func snapshotsToAuthorizationRulesSlice(snapshots []go_hook.FilterResult, now time.Time) []authorizationRule {
 	ars := make([]authorizationRule, 0, len(snapshots))
 	for _, snapshot := range snapshots {
 		if snapshot == nil {
 			continue
 		}
 		ar := snapshot.(*authorizationRule)
+		if ar.isExpired(now) {
+			continue
+		}
 		ars = append(ars, *ar)
 	}
 	return ars
 }
+
+// isExpired checks the validUntil field of the rule. Expired rules are not rendered and do not grant any access.
+func (ar *authorizationRule) isExpired(now time.Time) bool {
+	validUntil, ok := ar.Spec["validUntil"].(string)
+	if !ok {
+		return false
+	}
+
+	t, err := time.Parse(time.RFC3339, validUntil)
+	if err != nil {
+		// the format is validated by the CRD schema
+		return false
+	}
+
+	return !now.Before(t)
+}
+
+func (ar *authorizationRule) expiryEvent(now time.Time) *eventsv1.Event {
+	// 'default' namespace is used for linking the event with a ClusterAuthorizationRule object, which is global
+	namespace := ar.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	return &eventsv1.Event{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Event",
+			APIVersion: "events.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			// the same naming as client-go event recorder uses
+			Name: fmt.Sprintf("%s.%x", ar.Name, now.UnixNano()),
+		},
+		Regarding: corev1.ObjectReference{
+			Kind:       ar.Kind,
+			Name:       ar.Name,
+			Namespace:  ar.Namespace,
+			UID:        ar.UID,
+			APIVersion: ar.APIVersion,
+		},
+		Reason:              "AccessExpired",
+		Note:                fmt.Sprintf("the rule has expired at %s, the access is revoked", ar.Spec["validUntil"]),
+		Type:                corev1.EventTypeNormal,
+		EventTime:           metav1.MicroTime{Time: now},
+		Action:              "Binding",
+		ReportingInstance:   "deckhouse",
+		ReportingController: "deckhouse",
+	}
+}
@@ -129,6 +129,7 @@ var _ = Describe("Module :: user-authz :: helm template ::", func() {
 
 			f.ValuesSet("userAuthz.enableMultiTenancy", true)
 			f.ValuesSet("userAuthz.controlPlaneConfigurator.enabled", true)
+			f.ValuesSet("userAuthz.audit.allowedSampleRate", 0.01)
 			f.ValuesSet("global.discovery.extensionAPIServerAuthenticationRequestheaderClientCA", "test")
 			f.ValuesSet("userAuthz.internal.webhookCertificate.ca", "test")
 			f.ValuesSet("userAuthz.internal.webhookCertificate.crt", "test")
@@ -273,7 +274,9 @@ var _ = Describe("Module :: user-authz :: helm template ::", func() {
 		})
 
 		It("Should deploy authorization webhook and supporting objects", func() {
-			Expect(f.KubernetesResource("DaemonSet", "d8-user-authz", "user-authz-webhook").Exists()).To(BeTrue())
+			ds := f.KubernetesResource("DaemonSet", "d8-user-authz", "user-authz-webhook")
+			Expect(ds.Exists()).To(BeTrue())
+			Expect(ds.Field(`spec.template.spec.containers.#(name=="webhook").env.#(name=="AUDIT_ALLOWED_SAMPLE_RATE").value`).String()).To(Equal("0.01"))
 			Expect(f.KubernetesResource("ConfigMap", "d8-user-authz", "control-plane-configurator").Field("data.ca").Exists()).To(BeTrue())
 			Expect(f.KubernetesResource("ConfigMap", "d8-user-authz", "apiserver-authentication-requestheader-client-ca").Exists()).To(BeTrue())
 			Expect(f.KubernetesResource("Secret", "d8-user-authz", "user-authz-webhook").Exists()).To(BeTrue())
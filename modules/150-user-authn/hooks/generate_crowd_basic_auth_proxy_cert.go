@@ -95,6 +95,9 @@ type provider struct {
 	Crowd struct {
 		EnableBasicAuth bool `json:"enableBasicAuth"`
 	} `json:"crowd"`
+	LDAP struct {
+		EnableBasicAuth bool `json:"enableBasicAuth"`
+	} `json:"ldap"`
 }
 
 func generateProxyAuthCert(input *go_hook.HookInput, dc dependency.Container) error {
@@ -116,18 +119,19 @@ func generateProxyAuthCert(input *go_hook.HookInput, dc dependency.Container) er
 		return err
 	}
 
-	var crowdConfig *provider
+	var basicAuthConfig *provider
 
 	for _, prov := range providers {
-		if prov.Typ == "Crowd" && prov.Crowd.EnableBasicAuth {
-			if crowdConfig != nil {
-				return errors.New("only one enableBasicAuth must be enabled for Crowd")
+		prov := prov
+		if (prov.Typ == "Crowd" && prov.Crowd.EnableBasicAuth) || (prov.Typ == "LDAP" && prov.LDAP.EnableBasicAuth) {
+			if basicAuthConfig != nil {
+				return errors.New("only one enableBasicAuth must be enabled for Crowd and LDAP providers")
 			}
-			crowdConfig = &prov
+			basicAuthConfig = &prov
 		}
 	}
 
-	if crowdConfig == nil {
+	if basicAuthConfig == nil {
 		return nil
 	}
 
@@ -118,6 +118,53 @@ metadata:
 	})
 })
 
+var _ = Describe("User Authn hooks :: generate crowd auth proxy :: LDAP ::", func() {
+	f := HookExecutionConfigInit(`{"userAuthn":{"internal": {"providers": [{
+  "type": "LDAP",
+  "displayName": "LDAP",
+  "ldap": {
+    "host": "ldap.example.com:636",
+    "enableBasicAuth": true,
+    "userSearch": {"baseDN": "ou=users,dc=example,dc=com", "username": "uid", "idAttr": "uid", "emailAttr": "mail"}
+  }
+}]}, "publishAPI": {"enable": true}}}`, "")
+
+	Context("Fresh cluster", func() {
+		BeforeEach(func() {
+			f.ValuesSet("global.modulesImages", GetModulesImages())
+			f.KubeStateSet(``)
+			testCreateJobPod()
+			f.BindingContexts.Set(f.GenerateBeforeHelmContext())
+			f.RunHook()
+		})
+
+		It("Certificate should be generated", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.ValuesGet("userAuthn.internal.crowdProxyCert").String()).To(BeEquivalentTo(testingCert))
+		})
+	})
+
+	Context("Basic auth is enabled in Crowd and LDAP providers", func() {
+		BeforeEach(func() {
+			f.ValuesSetFromYaml("userAuthn.internal.providers", []byte(`
+- type: LDAP
+  ldap:
+    enableBasicAuth: true
+- type: Crowd
+  crowd:
+    enableBasicAuth: true
+`))
+			f.KubeStateSet(``)
+			f.BindingContexts.Set(f.GenerateBeforeHelmContext())
+			f.RunHook()
+		})
+
+		It("Should fail", func() {
+			Expect(f).NotTo(ExecuteSuccessfully())
+		})
+	})
+})
+
 func testCreateJobPod() {
 	_, _ = dependency.TestDC.MustGetK8sClient().CoreV1().Pods("d8-system").Create(context.Background(), &corev1.Pod{
 		TypeMeta: v1.TypeMeta{
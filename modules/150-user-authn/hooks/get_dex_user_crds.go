@@ -17,6 +17,7 @@ limitations under the License.
 package hooks
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strings"
 	"time"
@@ -24,16 +25,25 @@ import (
 	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
 	"github.com/flant/addon-operator/sdk"
 	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"github.com/flant/shell-operator/pkg/kube_events_manager/types"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
 
 	"github.com/deckhouse/deckhouse/go_lib/encoding"
 	"github.com/deckhouse/deckhouse/go_lib/set"
 )
 
-type expirePatch struct {
+type userStatusPatch struct {
 	ExpireAt string   `json:"expireAt,omitempty"`
 	Groups   []string `json:"groups"`
+
+	// nil pointers remove fields from the status
+	Locked            bool    `json:"locked"`
+	LockedUntil       *string `json:"lockedUntil"`
+	LastLogin         string  `json:"lastLogin,omitempty"`
+	PasswordChangedAt string  `json:"passwordChangedAt,omitempty"`
+	PasswordExpireAt  *string `json:"passwordExpireAt"`
 }
 
 type DexUserInternalValues struct {
@@ -59,10 +69,29 @@ type DexUserSpec struct {
 	UserID   string   `json:"userID,omitempty"`
 	Groups   []string `json:"groups,omitempty"`
 	TTL      string   `json:"ttl,omitempty"`
+
+	RequirePasswordChange bool `json:"requirePasswordChange,omitempty"`
 }
 
 type DexUserStatus struct {
 	ExpireAt string `json:"expireAt,omitempty"`
+
+	Locked            bool   `json:"locked,omitempty"`
+	LockedUntil       string `json:"lockedUntil,omitempty"`
+	LastLogin         string `json:"lastLogin,omitempty"`
+	PasswordChangedAt string `json:"passwordChangedAt,omitempty"`
+	PasswordExpireAt  string `json:"passwordExpireAt,omitempty"`
+}
+
+// DexPassword is a Dex storage object of a static user, Dex keeps the lockout state,
+// the last login time and the password changed on the password change page in it.
+type DexPassword struct {
+	Email                 string `json:"email"`
+	Hash                  string `json:"hash"`
+	RequirePasswordChange bool   `json:"requirePasswordChange,omitempty"`
+	PasswordChangedAt     string `json:"passwordChangedAt,omitempty"`
+	LockedUntil           string `json:"lockedUntil,omitempty"`
+	LastLogin             string `json:"lastLogin,omitempty"`
 }
 
 type DexGroup struct {
@@ -111,6 +140,20 @@ var _ = sdk.RegisterFunc(&go_hook.HookConfig{
 			Kind:       "Group",
 			FilterFunc: applyDexGroupFilter,
 		},
+		{
+			Name:       "passwords",
+			ApiVersion: "dex.coreos.com/v1",
+			Kind:       "Password",
+			NamespaceSelector: &types.NamespaceSelector{
+				NameSelector: &types.NameSelector{
+					MatchNames: []string{"d8-user-authn"},
+				},
+			},
+			// Dex updates passwords on every login, the status is synchronized by cron
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			FilterFunc:                   applyDexPasswordFilter,
+		},
 	},
 }, getDexUsers)
 
@@ -124,6 +167,23 @@ func getDexUsers(input *go_hook.HookInput) error {
 		makeUserGroupsMap(groupsSnap, group.Spec.Name, []string{}, mapOfUsersToGroups)
 	}
 
+	passwords := make(map[string]*DexPassword, len(input.Snapshots["passwords"]))
+	for _, obj := range input.Snapshots["passwords"] {
+		password := obj.(*DexPassword)
+		passwords[password.Email] = password
+	}
+
+	var passwordMaxAge time.Duration
+	if maxAge := input.Values.Get("userAuthn.passwordPolicy.maxAge").String(); maxAge != "" {
+		var err error
+		passwordMaxAge, err = time.ParseDuration(maxAge)
+		if err != nil {
+			return fmt.Errorf("cannot parse password max age: %v", err)
+		}
+	}
+
+	now := time.Now()
+
 	for _, user := range input.Snapshots["users"] {
 		dexUser, ok := user.(*DexUser)
 		if !ok {
@@ -154,32 +214,73 @@ func getDexUsers(input *go_hook.HookInput) error {
 			expireAt = dexUser.Status.ExpireAt
 		}
 
+		status := userStatusPatch{
+			ExpireAt:          expireAt,
+			Groups:            groups,
+			PasswordChangedAt: dexUser.Status.PasswordChangedAt,
+		}
+
+		if password, ok := passwords[strings.ToLower(dexUser.Spec.Email)]; ok {
+			userHash := dexUser.Spec.Password
+			if strings.HasPrefix(userHash, "$2") {
+				userHash = base64.StdEncoding.EncodeToString([]byte(userHash))
+			}
+
+			switch {
+			case password.Hash != userHash && timeAfter(password.PasswordChangedAt, status.PasswordChangedAt):
+				// the user has changed the password on the Dex password change page, keep it in the User resource
+				input.LogEntry.Infof("User %s has changed the password", dexUser.Name)
+				dexUser.Spec.Password = password.Hash
+				dexUser.Spec.RequirePasswordChange = password.RequirePasswordChange
+				status.PasswordChangedAt = password.PasswordChangedAt
+
+				specPatch := map[string]interface{}{
+					"spec": map[string]interface{}{
+						"password":              password.Hash,
+						"requirePasswordChange": password.RequirePasswordChange,
+					},
+				}
+				input.PatchCollector.MergePatch(specPatch, "deckhouse.io/v1", "User", "", dexUser.Name)
+			case password.Hash != userHash:
+				// the password has been changed in the User resource
+				status.PasswordChangedAt = now.Format(time.RFC3339)
+			case status.PasswordChangedAt == "":
+				status.PasswordChangedAt = password.PasswordChangedAt
+				if status.PasswordChangedAt == "" {
+					status.PasswordChangedAt = now.Format(time.RFC3339)
+				}
+			}
+
+			status.LastLogin = password.LastLogin
+			if timeAfter(password.LockedUntil, now.Format(time.RFC3339)) {
+				status.Locked = true
+				status.LockedUntil = &password.LockedUntil
+			}
+		}
+
+		if passwordMaxAge > 0 && status.PasswordChangedAt != "" {
+			changedAt, err := time.Parse(time.RFC3339, status.PasswordChangedAt)
+			if err != nil {
+				return fmt.Errorf("cannot parse password change time of user %s: %v", dexUser.Name, err)
+			}
+			passwordExpireAt := changedAt.Add(passwordMaxAge).Format(time.RFC3339)
+			status.PasswordExpireAt = &passwordExpireAt
+		}
+
 		users = append(users, DexUserInternalValues{
 			Name:        dexUser.Name,
 			EncodedName: encoding.ToFnvLikeDex(strings.ToLower(dexUser.Spec.Email)),
 			Spec:        dexUser.Spec,
-			Status:      dexUser.Status,
-			ExpireAt:    expireAt,
+			// only fields used in templates, the lockout state and the last login must not trigger helm
+			Status: DexUserStatus{
+				ExpireAt:          dexUser.Status.ExpireAt,
+				PasswordChangedAt: status.PasswordChangedAt,
+			},
+			ExpireAt: expireAt,
 		})
 
-		var patch map[string]interface{}
-		if expireAt == "" {
-			patch = map[string]interface{}{
-				"status": expirePatch{
-					Groups: groups,
-				},
-			}
-		} else {
-			patch = map[string]interface{}{
-				"status": expirePatch{
-					ExpireAt: expireAt,
-					Groups:   groups,
-				},
-			}
-		}
-
-		input.LogEntry.Infof("Update groups in user status %s. Groups: %v", dexUser.Name, patch["status"].(expirePatch).Groups)
-		input.PatchCollector.MergePatch(patch, "deckhouse.io/v1", "User", "", dexUser.Name, object_patch.WithSubresource("/status"))
+		input.LogEntry.Infof("Update groups in user status %s. Groups: %v", dexUser.Name, status.Groups)
+		input.PatchCollector.MergePatch(map[string]interface{}{"status": status}, "deckhouse.io/v1", "User", "", dexUser.Name, object_patch.WithSubresource("/status"))
 	}
 
 	input.Values.Set("userAuthn.internal.dexUsersCRDs", users)
@@ -195,6 +296,28 @@ func applyDexGroupFilter(obj *unstructured.Unstructured) (go_hook.FilterResult,
 	return group, nil
 }
 
+func applyDexPasswordFilter(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var password = &DexPassword{}
+	err := sdk.FromUnstructured(obj, password)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert kubernetes object: %v", err)
+	}
+	return password, nil
+}
+
+// timeAfter compares RFC3339 timestamps, an empty or invalid timestamp is before any other
+func timeAfter(t, u string) bool {
+	parsedT, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return false
+	}
+	parsedU, err := time.Parse(time.RFC3339, u)
+	if err != nil {
+		return true
+	}
+	return parsedT.Truncate(time.Second).After(parsedU)
+}
+
 func applyDexUserFilter(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
 	var user = &DexUser{}
 	err := sdk.FromUnstructured(obj, user)
@@ -30,6 +30,7 @@ var _ = Describe("User Authn hooks :: get dex user crds ::", func() {
 	f := HookExecutionConfigInit(`{"userAuthn":{"internal": {}}}`, "")
 	f.RegisterCRD("deckhouse.io", "v1", "User", false)
 	f.RegisterCRD("deckhouse.io", "v1alpha1", "Group", false)
+	f.RegisterCRD("dex.coreos.com", "v1", "Password", true)
 
 	Context("Fresh cluster", func() {
 		BeforeEach(func() {
@@ -510,4 +511,107 @@ spec:
 		})
 	})
 
+	Context("Cluster with User whose password has been changed on the Dex password change page", func() {
+		BeforeEach(func() {
+			f.ValuesSet("userAuthn.passwordPolicy.maxAge", "720h")
+			f.BindingContexts.Set(f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: User
+metadata:
+  name: admin
+spec:
+  email: Admin@example.com
+  password: $2y$10$old
+  requirePasswordChange: true
+status:
+  passwordChangedAt: "2023-01-01T00:00:00Z"
+---
+apiVersion: dex.coreos.com/v1
+kind: Password
+metadata:
+  name: mfsg22loibsxqylnobwgkltdn5w4x4u44scceizf
+  namespace: d8-user-authn
+email: admin@example.com
+hash: JDJ5JDEwJG5ldw==
+username: admin
+userID: admin
+passwordChangedAt: "2023-02-01T10:00:00.123456Z"
+lastLogin: "2023-02-01T10:01:00.5Z"
+lockedUntil: "2099-01-01T00:00:00Z"
+`))
+			f.RunHook()
+		})
+		It("Should keep the new password and reflect the Dex state in the status", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			user := f.KubernetesGlobalResource("User", "admin")
+			Expect(user.Field("spec.password").String()).To(Equal("JDJ5JDEwJG5ldw=="))
+			Expect(user.Field("spec.requirePasswordChange").Bool()).To(BeFalse())
+			Expect(user.Field("status.passwordChangedAt").String()).To(Equal("2023-02-01T10:00:00.123456Z"))
+			Expect(user.Field("status.passwordExpireAt").String()).To(Equal("2023-03-03T10:00:00Z"))
+			Expect(user.Field("status.lastLogin").String()).To(Equal("2023-02-01T10:01:00.5Z"))
+			Expect(user.Field("status.locked").Bool()).To(BeTrue())
+			Expect(user.Field("status.lockedUntil").String()).To(Equal("2099-01-01T00:00:00Z"))
+
+			Expect(f.ValuesGet("userAuthn.internal.dexUsersCRDs").String()).To(MatchJSON(`
+[
+  {
+    "name": "admin",
+    "spec": {
+      "email": "Admin@example.com",
+      "password": "JDJ5JDEwJG5ldw==",
+      "userID": "admin"
+    },
+    "encodedName": "mfsg22loibsxqylnobwgkltdn5w4x4u44scceizf",
+    "status": {
+      "passwordChangedAt": "2023-02-01T10:00:00.123456Z"
+    }
+  }
+]`))
+		})
+	})
+
+	Context("Cluster with User whose password has been changed in the User resource", func() {
+		BeforeEach(func() {
+			f.BindingContexts.Set(f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1
+kind: User
+metadata:
+  name: admin
+spec:
+  email: admin@example.com
+  password: $2y$10$new
+status:
+  passwordChangedAt: "2023-02-01T10:00:00Z"
+  locked: true
+  lockedUntil: "2023-02-01T10:15:00Z"
+---
+apiVersion: dex.coreos.com/v1
+kind: Password
+metadata:
+  name: mfsg22loibsxqylnobwgkltdn5w4x4u44scceizf
+  namespace: d8-user-authn
+email: admin@example.com
+hash: JDJ5JDEwJG9sZA==
+username: admin
+userID: admin
+passwordChangedAt: "2023-02-01T10:00:00Z"
+lockedUntil: "2023-02-01T10:15:00Z"
+`))
+			f.RunHook()
+		})
+		It("Should update the password change time and unlock the user", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			user := f.KubernetesGlobalResource("User", "admin")
+			Expect(user.Field("spec.password").String()).To(Equal("$2y$10$new"))
+			Expect(user.Field("status.passwordChangedAt").Time()).Should(BeTemporally("~", time.Now(), time.Minute))
+			Expect(user.Field("status.passwordExpireAt").Exists()).To(BeFalse())
+			Expect(user.Field("status.locked").Bool()).To(BeFalse())
+			Expect(user.Field("status.lockedUntil").Exists()).To(BeFalse())
+		})
+	})
+
 })
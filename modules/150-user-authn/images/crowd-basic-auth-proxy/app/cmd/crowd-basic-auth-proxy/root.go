@@ -31,11 +31,11 @@ func Execute() {
 
 	rootCmd := &cobra.Command{
 		Use:   "crowd-auth-proxy",
-		Short: "Basic auth proxy for Kubernetes API Server with Atlassian Crowd",
-		Long:  `Basic auth proxy for Kubernetes API Server with Atlassian Crowd`,
+		Short: "Basic auth proxy for Kubernetes API Server with Atlassian Crowd or LDAP",
+		Long:  `Basic auth proxy for Kubernetes API Server with Atlassian Crowd or LDAP`,
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println("------------------------------------")
-			fmt.Println("[ Starting Crowd Basic auth proxy ]")
+			fmt.Println("[ Starting Basic auth proxy ]")
 			fmt.Println("------------------------------------")
 			handler.Run()
 		},
@@ -43,16 +43,34 @@ func Execute() {
 
 	rootCmd.PersistentFlags().StringVar(&handler.ListenAddress, "listen", ":7332", "listen address and port")
 	rootCmd.PersistentFlags().StringVar(&handler.CertPath, "cert-path", "/some/cert/path", "directory with client.crt and client.key files")
+	rootCmd.PersistentFlags().StringVar(&handler.Provider, "provider", proxy.ProviderCrowd, "identity provider to check credentials in: crowd or ldap")
 	rootCmd.PersistentFlags().StringVar(&handler.CrowdBaseURL, "crowd-base-url", "https://crowd.example.com", "URL of Atlassian Crowd")
 	rootCmd.PersistentFlags().StringVar(&handler.CrowdApplicationLogin, "crowd-application-login", "crowd", "login of Atlassian Crowd application")
 	rootCmd.PersistentFlags().StringVar(&handler.CrowdApplicationPassword, "crowd-application-password", "user123", "password of Atlassian Crowd application")
 	rootCmd.PersistentFlags().StringArrayVar(&handler.CrowdGroups, "crowd-allowed-group", nil, "Allowed Crowd groups")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.Host, "ldap-host", "", "LDAP server host and optional port")
+	rootCmd.PersistentFlags().BoolVar(&handler.LDAP.InsecureNoSSL, "ldap-insecure-no-ssl", false, "connect to LDAP without TLS")
+	rootCmd.PersistentFlags().BoolVar(&handler.LDAP.StartTLS, "ldap-start-tls", false, "connect to LDAP using the ldap:// protocol and then issue a StartTLS command")
+	rootCmd.PersistentFlags().BoolVar(&handler.LDAP.InsecureSkipVerify, "ldap-insecure-skip-verify", false, "do not verify the LDAP server certificate")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.RootCAPath, "ldap-root-ca-path", "", "path to the CA chain to verify the LDAP server certificate")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.BindDN, "ldap-bind-dn", "", "DN of the LDAP service account, anonymous bind is used if empty")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.BindPassword, "ldap-bind-password", os.Getenv("LDAP_BIND_PASSWORD"), "password of the LDAP service account (LDAP_BIND_PASSWORD env)")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.UserSearchBaseDN, "ldap-user-search-base-dn", "", "base DN to search users from")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.UserSearchFilter, "ldap-user-search-filter", "", "optional filter to apply when searching users")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.UserSearchUsername, "ldap-user-search-username", "uid", "attribute to match the login with")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.GroupSearchBaseDN, "ldap-group-search-base-dn", "", "base DN to search groups from, groups are not searched if empty")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.GroupSearchFilter, "ldap-group-search-filter", "", "optional filter to apply when searching groups")
+	rootCmd.PersistentFlags().StringVar(&handler.LDAP.GroupSearchNameAttr, "ldap-group-search-name-attr", "cn", "attribute of the group name")
+	rootCmd.PersistentFlags().StringArrayVar(&handler.LDAP.GroupSearchUserMatchers, "ldap-group-search-user-matcher", nil, "user and group attributes to match a user to a group in the form userAttr:groupAttr")
+	rootCmd.PersistentFlags().StringArrayVar(&handler.LDAP.AllowedGroups, "ldap-allowed-group", nil, "Allowed LDAP groups")
 	rootCmd.PersistentFlags().StringVar(&handler.KubernetesAPIServerURL, "api-server-url", "https://api.example.com", "Kubernetes api server URL")
-	rootCmd.PersistentFlags().DurationVar(&handler.AuthCacheTTL, "auth-cache-ttl", 10*time.Second, "Crowd auth cache TTL")
-	rootCmd.PersistentFlags().DurationVar(&handler.GroupsCacheTTL, "groups-cache-ttl", 2*time.Minute, "Crowd groups cache TTL")
+	rootCmd.PersistentFlags().DurationVar(&handler.AuthCacheTTL, "auth-cache-ttl", 10*time.Second, "failed auth cache TTL")
+	rootCmd.PersistentFlags().DurationVar(&handler.GroupsCacheTTL, "groups-cache-ttl", 2*time.Minute, "successful auth and groups cache TTL")
+	rootCmd.PersistentFlags().IntVar(&handler.LockoutMaxAttempts, "lockout-max-attempts", 5, "failed login attempts in a row from the same IP address to lock the user out for this address after, 0 disables the lockout")
+	rootCmd.PersistentFlags().DurationVar(&handler.LockoutDuration, "lockout-duration", 15*time.Minute, "duration of the lockout")
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Printf("starting crowd proxy error: %s", err)
+		fmt.Printf("starting basic auth proxy error: %s", err)
 		os.Exit(1)
 	}
 }
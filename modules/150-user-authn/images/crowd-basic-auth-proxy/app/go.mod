@@ -7,6 +7,7 @@ require (
 	github.com/coreos/go-systemd v0.0.0-20190719114852-fd7a80b32e1f // indirect
 	github.com/coreos/pkg v0.0.0-20180928190104-399ea9e2e55f
 	github.com/felixge/httpsnoop v1.0.1
+	github.com/go-ldap/ldap/v3 v3.4.4
 	github.com/prometheus/client_golang v1.11.1
 	github.com/spf13/cobra v0.0.5
 )
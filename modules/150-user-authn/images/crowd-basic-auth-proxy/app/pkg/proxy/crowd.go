@@ -20,15 +20,28 @@ import (
 	"bytes"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
 
+var _ Provider = &CrowdClient{}
+
+type crowdResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *crowdResponseError) Error() string {
+	return fmt.Sprintf("crowd request was not successful: %v %v", e.StatusCode, e.Body)
+}
+
 type CrowdClient struct {
 	apiURL   string
 	login    string
@@ -55,16 +68,11 @@ func NewCrowdClient(apiURL, login, password string, allowedGroups []string) *Cro
 		},
 	}
 
-	groups := make(map[string]struct{})
-	for _, group := range allowedGroups {
-		groups[group] = struct{}{}
-	}
-
 	return &CrowdClient{
 		apiURL:        strings.TrimSuffix(apiURL, "/"),
 		login:         login,
 		password:      password,
-		allowedGroups: groups,
+		allowedGroups: groupsSet(allowedGroups),
 		httpClient:    client,
 	}
 }
@@ -100,30 +108,51 @@ func (c *CrowdClient) MakeRequest(url, method string, jsonPayload interface{}) (
 	}
 
 	if (resp.StatusCode != http.StatusOK) && (resp.StatusCode != http.StatusCreated) {
-		return "", fmt.Errorf("crowd request was not successful: %v %v", resp.StatusCode, string(responseBody))
+		return "", &crowdResponseError{StatusCode: resp.StatusCode, Body: string(responseBody)}
 	}
 
 	return string(responseBody), nil
 }
 
+func (c *CrowdClient) Authenticate(login, password string) ([]string, error) {
+	_, err := c.MakeRequest("/session", "POST", struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}{Username: login, Password: password})
+	if err != nil {
+		var respErr *crowdResponseError
+		// Crowd responds with 400 to wrong credentials and inactive users
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusBadRequest {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		return nil, fmt.Errorf("validating user credentials: %v", err)
+	}
+
+	body, err := c.MakeRequest("/user/group/nested?username="+url.QueryEscape(login), "GET", nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting user groups: %v", err)
+	}
+
+	groups, err := c.GetGroups(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing user groups: %v", err)
+	}
+
+	return groups, nil
+}
+
 func (c *CrowdClient) GetGroups(body string) ([]string, error) {
 	var crowdGroups struct {
 		Groups []struct{ Name string } `json:"groups"`
 	}
-	var groups []string
 
 	if err := json.Unmarshal([]byte(body), &crowdGroups); err != nil {
-		return groups, err
+		return nil, err
 	}
 
+	groups := make([]string, 0, len(crowdGroups.Groups))
 	for _, value := range crowdGroups.Groups {
-		if len(c.allowedGroups) > 0 {
-			if _, ok := c.allowedGroups[value.Name]; ok {
-				groups = append(groups, value.Name)
-			}
-		} else {
-			groups = append(groups, value.Name)
-		}
+		groups = append(groups, value.Name)
 	}
-	return groups, nil
+	return filterGroups(groups, c.allowedGroups), nil
 }
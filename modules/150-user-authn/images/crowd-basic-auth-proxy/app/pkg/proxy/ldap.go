@@ -0,0 +1,272 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const ldapTimeout = 30 * time.Second
+
+var _ Provider = &LDAPClient{}
+
+// LDAPConfig mirrors the LDAP settings of the DexProvider custom resource
+type LDAPConfig struct {
+	Host               string
+	InsecureNoSSL      bool
+	StartTLS           bool
+	InsecureSkipVerify bool
+	RootCAPath         string
+
+	BindDN       string
+	BindPassword string
+
+	UserSearchBaseDN   string
+	UserSearchFilter   string
+	UserSearchUsername string
+
+	GroupSearchBaseDN   string
+	GroupSearchFilter   string
+	GroupSearchNameAttr string
+	// GroupSearchUserMatchers are pairs of user and group attributes in the form "userAttr:groupAttr"
+	GroupSearchUserMatchers []string
+
+	AllowedGroups []string
+}
+
+type ldapUserMatcher struct {
+	UserAttr  string
+	GroupAttr string
+}
+
+type LDAPClient struct {
+	config        LDAPConfig
+	host          string
+	tlsConfig     *tls.Config
+	userMatchers  []ldapUserMatcher
+	allowedGroups map[string]struct{}
+}
+
+func NewLDAPClient(config LDAPConfig) (*LDAPClient, error) {
+	if config.Host == "" {
+		return nil, errors.New("ldap host is required")
+	}
+	if config.UserSearchBaseDN == "" || config.UserSearchUsername == "" {
+		return nil, errors.New("ldap user search base DN and username attribute are required")
+	}
+
+	host, port, err := net.SplitHostPort(config.Host)
+	if err != nil {
+		// the port is not specified, guess it the same way as Dex does
+		host, port = config.Host, "636"
+		if config.InsecureNoSSL || config.StartTLS {
+			port = "389"
+		}
+	}
+
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: config.InsecureSkipVerify} //nolint:gosec
+	if config.RootCAPath != "" {
+		caCert, err := ioutil.ReadFile(config.RootCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading ldap root CA: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in %s", config.RootCAPath)
+		}
+	}
+
+	var matchers []ldapUserMatcher
+	for _, m := range config.GroupSearchUserMatchers {
+		parts := strings.SplitN(m, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid ldap group search user matcher %q, must be in the form userAttr:groupAttr", m)
+		}
+		matchers = append(matchers, ldapUserMatcher{UserAttr: parts[0], GroupAttr: parts[1]})
+	}
+
+	return &LDAPClient{
+		config:        config,
+		host:          net.JoinHostPort(host, port),
+		tlsConfig:     tlsConfig,
+		userMatchers:  matchers,
+		allowedGroups: groupsSet(config.AllowedGroups),
+	}, nil
+}
+
+func (c *LDAPClient) Authenticate(login, password string) ([]string, error) {
+	// LDAP servers treat a bind with an empty password as an anonymous one and allow it
+	if login == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return nil, fmt.Errorf("connecting to ldap: %v", err)
+	}
+	defer conn.Close()
+
+	if err := c.bindServiceAccount(conn); err != nil {
+		return nil, err
+	}
+
+	user, err := c.userEntry(conn, login)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(user.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+		}
+		return nil, fmt.Errorf("binding as %s: %v", user.DN, err)
+	}
+
+	// search groups with the service account privileges, the user may not be allowed to read them
+	if err := c.bindServiceAccount(conn); err != nil {
+		return nil, err
+	}
+
+	groups, err := c.userGroups(conn, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterGroups(groups, c.allowedGroups), nil
+}
+
+func (c *LDAPClient) dial() (*ldap.Conn, error) {
+	dialer := ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout})
+
+	var (
+		conn *ldap.Conn
+		err  error
+	)
+	switch {
+	case c.config.InsecureNoSSL:
+		conn, err = ldap.DialURL("ldap://"+c.host, dialer)
+	case c.config.StartTLS:
+		conn, err = ldap.DialURL("ldap://"+c.host, dialer)
+		if err == nil {
+			if err = conn.StartTLS(c.tlsConfig); err != nil {
+				conn.Close()
+			}
+		}
+	default:
+		conn, err = ldap.DialURL("ldaps://"+c.host, dialer, ldap.DialWithTLSConfig(c.tlsConfig))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetTimeout(ldapTimeout)
+	return conn, nil
+}
+
+func (c *LDAPClient) bindServiceAccount(conn *ldap.Conn) error {
+	if c.config.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+
+	if err := conn.Bind(c.config.BindDN, c.config.BindPassword); err != nil {
+		return fmt.Errorf("binding as %s: %v", c.config.BindDN, err)
+	}
+	return nil
+}
+
+func (c *LDAPClient) userEntry(conn *ldap.Conn, login string) (*ldap.Entry, error) {
+	filter := fmt.Sprintf("(%s=%s)", c.config.UserSearchUsername, ldap.EscapeFilter(login))
+	if c.config.UserSearchFilter != "" {
+		filter = fmt.Sprintf("(&%s%s)", c.config.UserSearchFilter, filter)
+	}
+
+	attributes := make([]string, 0, len(c.userMatchers))
+	for _, m := range c.userMatchers {
+		attributes = append(attributes, m.UserAttr)
+	}
+
+	req := ldap.NewSearchRequest(
+		c.config.UserSearchBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, 0, false, filter, attributes, nil,
+	)
+	resp, err := conn.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("searching user %s: %v", login, err)
+	}
+
+	switch len(resp.Entries) {
+	case 0:
+		return nil, fmt.Errorf("%w: user %s not found", ErrInvalidCredentials, login)
+	case 1:
+		return resp.Entries[0], nil
+	default:
+		return nil, fmt.Errorf("filter %s returned multiple users", filter)
+	}
+}
+
+func (c *LDAPClient) userGroups(conn *ldap.Conn, user *ldap.Entry) ([]string, error) {
+	if c.config.GroupSearchBaseDN == "" {
+		return nil, nil
+	}
+
+	seen := make(map[string]struct{})
+	var groups []string
+
+	for _, m := range c.userMatchers {
+		values := user.GetAttributeValues(m.UserAttr)
+		if strings.EqualFold(m.UserAttr, "DN") {
+			values = []string{user.DN}
+		}
+
+		for _, value := range values {
+			filter := fmt.Sprintf("(%s=%s)", m.GroupAttr, ldap.EscapeFilter(value))
+			if c.config.GroupSearchFilter != "" {
+				filter = fmt.Sprintf("(&%s%s)", c.config.GroupSearchFilter, filter)
+			}
+
+			req := ldap.NewSearchRequest(
+				c.config.GroupSearchBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+				0, 0, false, filter, []string{c.config.GroupSearchNameAttr}, nil,
+			)
+			resp, err := conn.Search(req)
+			if err != nil {
+				return nil, fmt.Errorf("searching groups of %s: %v", user.DN, err)
+			}
+
+			for _, entry := range resp.Entries {
+				for _, name := range entry.GetAttributeValues(c.config.GroupSearchNameAttr) {
+					if _, ok := seen[name]; ok {
+						continue
+					}
+					seen[name] = struct{}{}
+					groups = append(groups, name)
+				}
+			}
+		}
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ReneKroon/ttlcache"
+)
+
+// Lockout counts failed login attempts and locks the login out after maxAttempts failures in a row.
+// Failures are counted for the login and the client IP address together, so nobody can lock out
+// other users by sending wrong passwords from another address.
+// Failures are forgotten after the lockout duration passes since the last one.
+// The state is kept in memory, so each replica of the proxy counts failures separately.
+type Lockout struct {
+	mu          sync.Mutex
+	maxAttempts int
+	duration    time.Duration
+	failures    *ttlcache.Cache
+}
+
+// NewLockout creates a lockout, it is disabled if maxAttempts is zero
+func NewLockout(maxAttempts int, duration time.Duration) *Lockout {
+	c := ttlcache.NewCache()
+	c.SkipTtlExtensionOnHit(true)
+	return &Lockout{maxAttempts: maxAttempts, duration: duration, failures: c}
+}
+
+func (l *Lockout) Locked(login, clientIP string) bool {
+	if l.maxAttempts <= 0 {
+		return false
+	}
+
+	value, exists := l.failures.Get(lockoutKey(login, clientIP))
+	return exists && value.(int) >= l.maxAttempts
+}
+
+// Fail registers a failed attempt and returns true if the login became locked
+func (l *Lockout) Fail(login, clientIP string) bool {
+	if l.maxAttempts <= 0 {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := lockoutKey(login, clientIP)
+
+	count := 1
+	if value, exists := l.failures.Get(key); exists {
+		count += value.(int)
+	}
+	l.failures.SetWithTTL(key, count, l.duration)
+
+	return count == l.maxAttempts
+}
+
+func (l *Lockout) Reset(login, clientIP string) {
+	l.failures.Remove(lockoutKey(login, clientIP))
+}
+
+func lockoutKey(login, clientIP string) string {
+	// the login cannot contain a colon in basic auth credentials
+	return login + ":" + clientIP
+}
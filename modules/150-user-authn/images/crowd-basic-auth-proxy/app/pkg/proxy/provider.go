@@ -0,0 +1,71 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ProviderCrowd = "crowd"
+	ProviderLDAP  = "ldap"
+)
+
+// ErrInvalidCredentials is returned by a provider if the login or the password is wrong.
+// Only such errors are counted as failed login attempts, errors of reaching the provider are not.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Provider checks user credentials in an identity provider
+type Provider interface {
+	// Authenticate returns allowed groups of the user
+	Authenticate(login, password string) ([]string, error)
+}
+
+func (h *Handler) newProvider() (Provider, error) {
+	switch h.Provider {
+	case ProviderCrowd:
+		return NewCrowdClient(h.CrowdBaseURL, h.CrowdApplicationLogin, h.CrowdApplicationPassword, h.CrowdGroups), nil
+	case ProviderLDAP:
+		return NewLDAPClient(h.LDAP)
+	default:
+		return nil, fmt.Errorf("unknown provider %q, must be one of: %s, %s", h.Provider, ProviderCrowd, ProviderLDAP)
+	}
+}
+
+func groupsSet(groups []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(groups))
+	for _, group := range groups {
+		set[group] = struct{}{}
+	}
+	return set
+}
+
+// filterGroups keeps only allowed groups, all groups are allowed if the allowed list is empty
+func filterGroups(groups []string, allowed map[string]struct{}) []string {
+	if len(allowed) == 0 {
+		return groups
+	}
+
+	var filtered []string
+	for _, group := range groups {
+		if _, ok := allowed[group]; ok {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
@@ -19,6 +19,7 @@ package proxy
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -93,17 +94,23 @@ type Handler struct {
 	ListenAddress            string
 	KubernetesAPIServerURL   string
 	CertPath                 string
+	Provider                 string
 	CrowdBaseURL             string
 	CrowdApplicationLogin    string
 	CrowdApplicationPassword string
 	CrowdGroups              []string
+	LDAP                     LDAPConfig
 
 	AuthCacheTTL   time.Duration
 	GroupsCacheTTL time.Duration
 
+	LockoutMaxAttempts int
+	LockoutDuration    time.Duration
+
 	Cache        *ttlcache.Cache
 	reverseProxy *httputil.ReverseProxy
-	crowdClient  *CrowdClient
+	provider     Provider
+	lockout      *Lockout
 
 	PrometheusRegistry *prometheus.Registry
 }
@@ -113,15 +120,21 @@ var _ http.Handler = &Handler{}
 func NewHandler() *Handler {
 	c := ttlcache.NewCache()
 	c.SkipTtlExtensionOnHit(true)
-	return &Handler{Cache: c, CrowdGroups: []string{}}
+	return &Handler{Cache: c, Provider: ProviderCrowd, CrowdGroups: []string{}}
 }
 
 func (h *Handler) Run() {
 	logger.Printf("-- Listening on: %s", h.ListenAddress)
-	logger.Printf("-- Atlassian Crowd URL: %s", h.CrowdBaseURL)
+	logger.Printf("-- Provider: %s", h.Provider)
+	if h.Provider == ProviderLDAP {
+		logger.Printf("-- LDAP host: %s", h.LDAP.Host)
+	} else {
+		logger.Printf("-- Atlassian Crowd URL: %s", h.CrowdBaseURL)
+	}
 	logger.Printf("-- Kubernetes API URL: %s", h.KubernetesAPIServerURL)
 	logger.Printf("-- Auth Cache TTL: %v", h.AuthCacheTTL)
 	logger.Printf("-- Groups Cache TTL: %v", h.GroupsCacheTTL)
+	logger.Printf("-- Lockout: %d attempts, %v", h.LockoutMaxAttempts, h.LockoutDuration)
 
 	u, _ := url.Parse(h.KubernetesAPIServerURL)
 
@@ -129,7 +142,12 @@ func (h *Handler) Run() {
 	h.reverseProxy.Transport = tlsHTTPClientTransport(h.CertPath)
 	h.reverseProxy.FlushInterval = defaultFlushInterval
 
-	h.crowdClient = NewCrowdClient(h.CrowdBaseURL, h.CrowdApplicationLogin, h.CrowdApplicationPassword, h.CrowdGroups)
+	provider, err := h.newProvider()
+	if err != nil {
+		logger.Fatalf("cannot create provider: %s", err)
+	}
+	h.provider = provider
+	h.lockout = NewLockout(h.LockoutMaxAttempts, h.LockoutDuration)
 
 	h.PrometheusRegistry = prometheus.NewRegistry()
 	requestCounter := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -137,7 +155,7 @@ func (h *Handler) Run() {
 		Help: "Count of all HTTP requests.",
 	}, []string{"handler", "code", "method"})
 
-	err := h.PrometheusRegistry.Register(requestCounter)
+	err = h.PrometheusRegistry.Register(requestCounter)
 	if err != nil {
 		logger.Fatalf("cannot register prometheus metrics: %s", err)
 	}
@@ -193,9 +211,17 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	groups := h.validateCredentials(basicLogin, basicPassword)
+	ip := clientIP(r)
+	if h.lockout.Locked(basicLogin, ip) {
+		logger.Errorf("429 Too Many Requests, user %s is locked out for %s after failed login attempts", basicLogin, ip)
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.LockoutDuration.Seconds())))
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return
+	}
+
+	groups := h.validateCredentials(basicLogin, basicPassword, ip)
 	if len(groups) == 0 {
-		logger.Errorf("403 Forbidden, Crowd authentication problem: User %s has no allowed groups", basicLogin)
+		logger.Errorf("403 Forbidden, %s authentication problem: User %s has no allowed groups", h.Provider, basicLogin)
 		http.Error(w, "Forbidden", http.StatusForbidden)
 		return
 	}
@@ -205,7 +231,21 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.modifyRequest(w, r, basicLogin, groups)
 }
 
-func (h *Handler) validateCredentials(login, password string) []string {
+// clientIP returns the address of the client. The proxy is published by the ingress controller,
+// which sets the X-Real-IP header to the address of the client.
+func clientIP(r *http.Request) string {
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (h *Handler) validateCredentials(login, password, clientIP string) []string {
 	userID := login + ":" + password
 
 	value, exists := h.Cache.Get(userID)
@@ -216,33 +256,20 @@ func (h *Handler) validateCredentials(login, password string) []string {
 		return []string{}
 	}
 
-	_, err := h.crowdClient.MakeRequest("/session", "POST", struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}{Username: login, Password: password})
-	if err != nil {
-		logger.Errorf("validating user credentials: %+v", err)
-		h.Cache.SetWithTTL(userID, nil, h.AuthCacheTTL)
-		return nil
-	}
-
-	body, err := h.crowdClient.MakeRequest("/user/group/nested?username="+login, "GET", nil)
+	groups, err := h.provider.Authenticate(login, password)
 	if err != nil {
-		logger.Errorf("getting user groups: %+v", err)
-		h.Cache.SetWithTTL(userID, nil, h.AuthCacheTTL)
-		return nil
-	}
-
-	crowdGroups, err := h.crowdClient.GetGroups(body)
-	if err != nil {
-		logger.Errorf("parsing user groups: %+v", err)
+		logger.Errorf("authenticating user %s: %+v", login, err)
+		if errors.Is(err, ErrInvalidCredentials) && h.lockout.Fail(login, clientIP) {
+			logger.Errorf("user %s is locked out for %s for %v after %d failed login attempts", login, clientIP, h.LockoutDuration, h.LockoutMaxAttempts)
+		}
 		h.Cache.SetWithTTL(userID, nil, h.AuthCacheTTL)
 		return nil
 	}
 
-	h.Cache.SetWithTTL(userID, crowdGroups, h.GroupsCacheTTL)
-	logger.Printf("received groups for %s: %s", login, crowdGroups)
-	return crowdGroups
+	h.lockout.Reset(login, clientIP)
+	h.Cache.SetWithTTL(userID, groups, h.GroupsCacheTTL)
+	logger.Printf("received groups for %s: %s", login, groups)
+	return groups
 }
 
 func (h *Handler) modifyRequest(w http.ResponseWriter, r *http.Request, login string, groups []string) {
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	passwords map[string]string
+	groups    map[string][]string
+	calls     int
+}
+
+func (p *fakeProvider) Authenticate(login, password string) ([]string, error) {
+	p.calls++
+	if p.passwords[login] != password {
+		return nil, ErrInvalidCredentials
+	}
+	return p.groups[login], nil
+}
+
+func newTestHandler(provider Provider) *Handler {
+	h := NewHandler()
+	h.AuthCacheTTL = time.Minute
+	h.GroupsCacheTTL = time.Minute
+	h.LockoutDuration = time.Minute
+	h.provider = provider
+	h.lockout = NewLockout(3, h.LockoutDuration)
+	return h
+}
+
+func TestValidateCredentialsCache(t *testing.T) {
+	provider := &fakeProvider{
+		passwords: map[string]string{"jane": "secret"},
+		groups:    map[string][]string{"jane": {"admins"}},
+	}
+	h := newTestHandler(provider)
+
+	for i := 0; i < 2; i++ {
+		if groups := h.validateCredentials("jane", "secret", "10.0.0.1"); !reflect.DeepEqual(groups, []string{"admins"}) {
+			t.Fatalf("groups: got %v | expected [admins]", groups)
+		}
+		if groups := h.validateCredentials("jane", "wrong", "10.0.0.1"); len(groups) != 0 {
+			t.Fatalf("groups for wrong password: got %v | expected none", groups)
+		}
+	}
+
+	if provider.calls != 2 {
+		t.Errorf("provider calls: got %d | expected 2, results must be cached", provider.calls)
+	}
+}
+
+func TestLockout(t *testing.T) {
+	provider := &fakeProvider{
+		passwords: map[string]string{"jane": "secret", "john": "secret"},
+		groups:    map[string][]string{"jane": {"admins"}, "john": {"admins"}},
+	}
+	h := newTestHandler(provider)
+
+	request := func(login, password, ip string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api", nil)
+		r.SetBasicAuth(login, password)
+		r.Header.Set("X-Real-IP", ip)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// a successful login resets failures
+	h.validateCredentials("john", "wrong-1", "10.0.0.1")
+	h.validateCredentials("john", "wrong-2", "10.0.0.1")
+	h.validateCredentials("john", "secret", "10.0.0.1")
+	h.validateCredentials("john", "wrong-3", "10.0.0.1")
+	if h.lockout.Locked("john", "10.0.0.1") {
+		t.Errorf("john must not be locked out, failures are not in a row")
+	}
+
+	for _, password := range []string{"wrong-1", "wrong-2", "wrong-3"} {
+		if code := request("jane", password, "10.0.0.2"); code != http.StatusForbidden {
+			t.Errorf("wrong password: got %d | expected %d", code, http.StatusForbidden)
+		}
+	}
+
+	calls := provider.calls
+	if code := request("jane", "secret", "10.0.0.2"); code != http.StatusTooManyRequests {
+		t.Errorf("locked out user: got %d | expected %d", code, http.StatusTooManyRequests)
+	}
+	if provider.calls != calls {
+		t.Errorf("provider must not be called for a locked out user")
+	}
+
+	// failures from another address do not lock the user out
+	if h.lockout.Locked("jane", "10.0.0.3") {
+		t.Errorf("jane must not be locked out for another address")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.RemoteAddr = "192.168.1.1:51234"
+	if ip := clientIP(r); ip != "192.168.1.1" {
+		t.Errorf("client IP without the header: got %q | expected %q", ip, "192.168.1.1")
+	}
+
+	r.Header.Set("X-Real-IP", "10.0.0.1")
+	if ip := clientIP(r); ip != "10.0.0.1" {
+		t.Errorf("client IP from the header: got %q | expected %q", ip, "10.0.0.1")
+	}
+}
+
+func TestLockoutIgnoresProviderErrors(t *testing.T) {
+	h := newTestHandler(providerFunc(func(_, _ string) ([]string, error) {
+		return nil, errors.New("connection refused")
+	}))
+
+	for _, password := range []string{"1", "2", "3", "4"} {
+		h.validateCredentials("jane", password, "10.0.0.1")
+	}
+	if h.lockout.Locked("jane", "10.0.0.1") {
+		t.Errorf("jane must not be locked out because of provider errors")
+	}
+}
+
+type providerFunc func(login, password string) ([]string, error)
+
+func (f providerFunc) Authenticate(login, password string) ([]string, error) {
+	return f(login, password)
+}
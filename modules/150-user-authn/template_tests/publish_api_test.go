@@ -154,4 +154,46 @@ var _ = Describe("Module :: user-authn :: helm template :: publish api", func()
 				Equal("1.1.1.1,192.168.0.0/24"))
 		})
 	})
+
+	Context("With LDAP provider with enableBasicAuth option", func() {
+		BeforeEach(func() {
+			hec.ValuesSet("userAuthn.internal.crowdProxyCert", "dGVzdA==")
+			hec.ValuesSet("userAuthn.internal.crowdProxyKey", "dGVzdA==")
+			hec.ValuesSetFromYaml("userAuthn.internal.providers", `
+- id: ldapID
+  displayName: ldapName
+  type: LDAP
+  ldap:
+    enableBasicAuth: true
+    host: ldap.example.com:636
+    bindDN: uid=serviceaccount,cn=users,dc=example,dc=com
+    bindPW: password
+    userSearch:
+      baseDN: cn=users,dc=example,dc=com
+      username: uid
+      idAttr: uid
+      emailAttr: mail
+    groupSearch:
+      baseDN: cn=groups,dc=example,dc=com
+      nameAttr: cn
+      userMatchers:
+      - userAttr: DN
+        groupAttr: member`)
+			hec.HelmRender()
+		})
+		It("Should deploy basic auth proxy for LDAP", func() {
+			Expect(hec.RenderError).ShouldNot(HaveOccurred())
+
+			deployment := hec.KubernetesResource("Deployment", "d8-user-authn", "crowd-basic-auth-proxy")
+			Expect(deployment.Exists()).To(BeTrue())
+			args := deployment.Field("spec.template.spec.containers.0.args").String()
+			Expect(args).To(ContainSubstring(`"--provider=ldap"`))
+			Expect(args).To(ContainSubstring(`"--ldap-host=ldap.example.com:636"`))
+			Expect(args).To(ContainSubstring(`"--ldap-group-search-user-matcher=DN:member"`))
+			Expect(args).NotTo(ContainSubstring("password"))
+
+			secret := hec.KubernetesResource("Secret", "d8-user-authn", "crowd-basic-auth-cert")
+			Expect(secret.Field("data.ldap-bind-password").String()).To(Equal("cGFzc3dvcmQ="))
+		})
+	})
 })
@@ -66,6 +66,9 @@ var _ = Describe("Module :: user-authn :: helm template :: user", func() {
     - Everyone
     - Admins
     password: $2a$10$E/MjyzFi6GZkta9GHd8zCeuYigbLenXv18jkxOZ6vhoWsKnaxNJou
+    requirePasswordChange: true
+  status:
+    passwordChangedAt: "2023-02-01T10:00:00Z"
 `)
 			hec.HelmRender()
 		})
@@ -77,6 +80,8 @@ var _ = Describe("Module :: user-authn :: helm template :: user", func() {
 			Expect(userPassword.Field("userID").String()).To(Equal("userName"))
 			Expect(userPassword.Field("hash").String()).To(Equal("JDJhJDEwJDdyeGN3aDhyMlJjbndjM2pEeXNxaE9yYnNrTEJqdHgxenZ6V2FRVlBGTzc4RERBTVpIaExD"))
 			Expect(userPassword.Field("groups").String()).To(MatchJSON(`["Everyone"]`))
+			Expect(userPassword.Field("requirePasswordChange").Exists()).To(BeFalse())
+			Expect(userPassword.Field("passwordChangedAt").Exists()).To(BeFalse())
 
 			base64Password := hec.KubernetesResource("Password", "d8-user-authn", "base64EncodedUser")
 			Expect(base64Password.Exists()).To(BeTrue())
@@ -93,6 +98,8 @@ var _ = Describe("Module :: user-authn :: helm template :: user", func() {
 			Expect(adminPassword.Field("userID").String()).To(Equal("adminName"))
 			Expect(adminPassword.Field("hash").String()).To(Equal("JDJhJDEwJEUvTWp5ekZpNkdaa3RhOUdIZDh6Q2V1WWlnYkxlblh2MThqa3hPWjZ2aG9Xc0tuYXhOSm91"))
 			Expect(adminPassword.Field("groups").String()).To(MatchJSON(`["Everyone","Admins"]`))
+			Expect(adminPassword.Field("requirePasswordChange").Bool()).To(BeTrue())
+			Expect(adminPassword.Field("passwordChangedAt").String()).To(Equal("2023-02-01T10:00:00Z"))
 		})
 	})
 })
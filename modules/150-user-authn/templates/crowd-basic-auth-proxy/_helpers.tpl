@@ -1,4 +1,4 @@
-{{- define "is_basic_auth_enabled_in_any_crowd" }}
+{{- define "is_basic_auth_enabled_in_any_provider" }}
   {{- if .Values.userAuthn.publishAPI.enable }}
     {{- range $provider := .Values.userAuthn.internal.providers }}
       {{- if eq $provider.type "Crowd" }}
@@ -6,6 +6,11 @@
           not empty string
         {{- end }}
       {{- end }}
+      {{- if eq $provider.type "LDAP" }}
+        {{- if $provider.ldap.enableBasicAuth }}
+          not empty string
+        {{- end }}
+      {{- end }}
     {{- end }}
   {{- end }}
 {{- end }}
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
+	"github.com/flant/addon-operator/sdk"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Custom metric mappings are rendered to a ConfigMap for each controller,
+// the controller Lua module collects metrics and the protobuf exporter exports them.
+
+const (
+	defaultMetricMappingTTL       = "1h"
+	defaultMetricMappingMaxSeries = 1000
+)
+
+type metricMappingLabel struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Header string `json:"header,omitempty"`
+}
+
+type metricMappingSpec struct {
+	Name      string               `json:"name"`
+	Type      string               `json:"type"`
+	Help      string               `json:"help,omitempty"`
+	Labels    []metricMappingLabel `json:"labels,omitempty"`
+	Value     string               `json:"value,omitempty"`
+	Buckets   []float64            `json:"buckets,omitempty"`
+	TTL       string               `json:"ttl,omitempty"`
+	MaxSeries int                  `json:"maxSeries,omitempty"`
+}
+
+type metricMappingResource struct {
+	Name string
+	Spec struct {
+		IngressControllerName string              `json:"ingressControllerName"`
+		Mappings              []metricMappingSpec `json:"mappings"`
+	} `json:"spec"`
+}
+
+// metricMapping is a mapping in the protobuf exporter format extended with label sources for the controller
+type metricMapping struct {
+	IngressControllerName string `json:"ingressControllerName"`
+	Resource              string `json:"resource"`
+
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	Help         string               `json:"help"`
+	Labels       []string             `json:"labels"`
+	LabelSources []metricMappingLabel `json:"labelSources"`
+	Value        string               `json:"value,omitempty"`
+	Buckets      []float64            `json:"buckets,omitempty"`
+	TTL          string               `json:"ttl"`
+	MaxSeries    int                  `json:"maxSeries"`
+}
+
+var _ = sdk.RegisterFunc(&go_hook.HookConfig{
+	OnBeforeHelm: &go_hook.OrderedConfig{Order: 10},
+	Queue:        "/modules/ingress-nginx",
+	Kubernetes: []go_hook.KubernetesConfig{
+		{
+			Name:       "metric_mappings",
+			ApiVersion: "deckhouse.io/v1alpha1",
+			Kind:       "IngressNginxMetricMapping",
+			FilterFunc: applyMetricMappingFilter,
+		},
+	},
+}, setMetricMappings)
+
+func applyMetricMappingFilter(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var mm metricMappingResource
+
+	err := sdk.FromUnstructured(obj, &mm)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert IngressNginxMetricMapping %s: %v", obj.GetName(), err)
+	}
+	mm.Name = obj.GetName()
+
+	return mm, nil
+}
+
+func setMetricMappings(input *go_hook.HookInput) error {
+	snap := input.Snapshots["metric_mappings"]
+
+	resources := make([]metricMappingResource, 0, len(snap))
+	for _, sn := range snap {
+		resources = append(resources, sn.(metricMappingResource))
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].Name < resources[j].Name })
+
+	mappings := make([]metricMapping, 0)
+	for _, res := range resources {
+		for _, spec := range res.Spec.Mappings {
+			mapping, err := newMetricMapping(spec)
+			if err != nil {
+				input.LogEntry.Warnf("skipping mapping %q of IngressNginxMetricMapping %q: %v", spec.Name, res.Name, err)
+				continue
+			}
+
+			mapping.IngressControllerName = res.Spec.IngressControllerName
+			mapping.Resource = res.Name
+			mappings = append(mappings, mapping)
+		}
+	}
+
+	input.Values.Set("ingressNginx.internal.metricMappings", mappings)
+
+	return nil
+}
+
+// newMetricMapping validates the mapping and sets default values, the CRD schema does not cover everything
+func newMetricMapping(spec metricMappingSpec) (metricMapping, error) {
+	mapping := metricMapping{
+		Name:         spec.Name,
+		Type:         spec.Type,
+		Help:         spec.Help,
+		Labels:       make([]string, 0, len(spec.Labels)),
+		LabelSources: make([]metricMappingLabel, 0, len(spec.Labels)),
+		Value:        spec.Value,
+		TTL:          spec.TTL,
+		MaxSeries:    spec.MaxSeries,
+	}
+
+	if mapping.Help == "" {
+		mapping.Help = spec.Name
+	}
+	if mapping.TTL == "" {
+		mapping.TTL = defaultMetricMappingTTL
+	}
+	if _, err := time.ParseDuration(mapping.TTL); err != nil {
+		return mapping, fmt.Errorf("invalid ttl: %v", err)
+	}
+	if mapping.MaxSeries <= 0 {
+		mapping.MaxSeries = defaultMetricMappingMaxSeries
+	}
+
+	seen := make(map[string]bool, len(spec.Labels))
+	for _, label := range spec.Labels {
+		if seen[label.Name] {
+			return mapping, fmt.Errorf("duplicate label %q", label.Name)
+		}
+		seen[label.Name] = true
+
+		if (label.Source == "RequestHeader" || label.Source == "ResponseHeader") && label.Header == "" {
+			return mapping, fmt.Errorf("header is required for the %s source of label %q", label.Source, label.Name)
+		}
+
+		mapping.Labels = append(mapping.Labels, label.Name)
+		mapping.LabelSources = append(mapping.LabelSources, label)
+	}
+
+	switch spec.Type {
+	case "Counter":
+	case "Histogram":
+		if spec.Value == "" || len(spec.Buckets) == 0 {
+			return mapping, fmt.Errorf("value and buckets are required for the Histogram type")
+		}
+		mapping.Buckets = uniqueSortedBuckets(spec.Buckets)
+	default:
+		return mapping, fmt.Errorf("unknown type %q", spec.Type)
+	}
+
+	return mapping, nil
+}
+
+func uniqueSortedBuckets(buckets []float64) []float64 {
+	result := make([]float64, 0, len(buckets))
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	for i, b := range sorted {
+		if i > 0 && b == sorted[i-1] {
+			continue
+		}
+		result = append(result, b)
+	}
+	return result
+}
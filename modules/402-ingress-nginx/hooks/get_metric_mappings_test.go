@@ -0,0 +1,120 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/deckhouse/deckhouse/testing/hooks"
+)
+
+var _ = Describe("ingress-nginx :: hooks :: get_metric_mappings ::", func() {
+	f := HookExecutionConfigInit(`{"ingressNginx":{"internal": {}}}`, "")
+	f.RegisterCRD("deckhouse.io", "v1alpha1", "IngressNginxMetricMapping", false)
+
+	Context("Fresh cluster", func() {
+		BeforeEach(func() {
+			f.BindingContexts.Set(f.KubeStateSet(""))
+			f.RunHook()
+		})
+
+		It("Should set empty mappings", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.ValuesGet("ingressNginx.internal.metricMappings").String()).To(MatchJSON(`[]`))
+		})
+	})
+
+	Context("Cluster with IngressNginxMetricMappings", func() {
+		BeforeEach(func() {
+			f.BindingContexts.Set(f.KubeStateSet(`
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: IngressNginxMetricMapping
+metadata:
+  name: tenants
+spec:
+  ingressControllerName: main
+  mappings:
+  - name: ingress_nginx_tenant_requests_total
+    type: Counter
+    labels:
+    - name: tenant
+      source: RequestHeader
+      header: X-Tenant-Id
+    - name: status_class
+      source: StatusClass
+  - name: ingress_nginx_tenant_invalid
+    type: Counter
+    labels:
+    - name: tenant
+      source: RequestHeader
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: IngressNginxMetricMapping
+metadata:
+  name: api
+spec:
+  mappings:
+  - name: ingress_nginx_api_path_request_seconds
+    type: Histogram
+    help: Request time by path
+    labels:
+    - name: path
+      source: Path
+    value: RequestTime
+    buckets: [1, 0.5, 0.1, 1]
+    ttl: 10m
+    maxSeries: 100
+`))
+			f.RunHook()
+		})
+
+		It("Should store valid mappings sorted by the resource name", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.ValuesGet("ingressNginx.internal.metricMappings").String()).To(MatchJSON(`[
+{
+  "ingressControllerName": "",
+  "resource": "api",
+  "name": "ingress_nginx_api_path_request_seconds",
+  "type": "Histogram",
+  "help": "Request time by path",
+  "labels": ["path"],
+  "labelSources": [{"name": "path", "source": "Path"}],
+  "value": "RequestTime",
+  "buckets": [0.1, 0.5, 1],
+  "ttl": "10m",
+  "maxSeries": 100
+},
+{
+  "ingressControllerName": "main",
+  "resource": "tenants",
+  "name": "ingress_nginx_tenant_requests_total",
+  "type": "Counter",
+  "help": "ingress_nginx_tenant_requests_total",
+  "labels": ["tenant", "status_class"],
+  "labelSources": [
+    {"name": "tenant", "source": "RequestHeader", "header": "X-Tenant-Id"},
+    {"name": "status_class", "source": "StatusClass"}
+  ],
+  "ttl": "1h",
+  "maxSeries": 1000
+}
+]`))
+		})
+	})
+})
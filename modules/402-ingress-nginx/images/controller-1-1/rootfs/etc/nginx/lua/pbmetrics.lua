@@ -38,8 +38,13 @@ local insert_tab = table.insert
 
 local match = string.match
 local gmatch = string.gmatch
+local gsub = string.gsub
 local sub = string.sub
+local lower = string.lower
 local format = string.format
+local io_open = io.open
+
+local cjson = require "cjson.safe"
 
 local iconv = require "iconv"
 local utf8enc = iconv.new("utf-8", "latin1")
@@ -173,6 +178,121 @@ local function _lowres_observe(metrichash, annotations, mapping, value)
   _observe(_LOWRES_BUCKETS, metrichash, annotations, mapping, value)
 end
 
+-- custom mappings are defined by IngressNginxMetricMapping resources and are reloaded at runtime
+local _CUSTOM_MAPPINGS_PATH = "/etc/nginx/custom-metric-mappings/mappings.json"
+-- must be equal to CustomMappingsIndexOffset in the protobuf exporter
+local _CUSTOM_MAPPINGS_INDEX_OFFSET = 1000
+
+local custom_mappings = {}
+local custom_mappings_raw
+
+local _HEADER_VARIABLE_PREFIXES = { RequestHeader = "http_", ResponseHeader = "sent_http_" }
+
+-- load_custom_mappings() reloads custom mappings if the file has been changed
+local function load_custom_mappings()
+  local file = io_open(_CUSTOM_MAPPINGS_PATH, "r")
+  if not file then
+    custom_mappings = {}
+    custom_mappings_raw = nil
+    return
+  end
+
+  local raw = file:read("*a")
+  file:close()
+  if raw == custom_mappings_raw then
+    return
+  end
+  custom_mappings_raw = raw
+
+  local mappings, err = cjson.decode(raw)
+  if type(mappings) ~= "table" then
+    log(ERROR, format("failed to decode custom metric mappings: %s", tostring(err)))
+    custom_mappings = {}
+    return
+  end
+
+  local compiled = {}
+  for i, mapping in ipairs(mappings) do
+    local labels = {}
+    for j, label in ipairs(mapping.labelSources or {}) do
+      local prefix = _HEADER_VARIABLE_PREFIXES[label.source]
+      labels[j] = { source = label.source, variable = prefix and (prefix .. gsub(lower(label.header or ""), "-", "_")) }
+    end
+
+    local buckets = mapping.buckets or {}
+    table.sort(buckets)
+
+    compiled[i] = {
+      index = _CUSTOM_MAPPINGS_INDEX_OFFSET + i - 1,
+      name = mapping.name,
+      marker = mapping.type == "Histogram" and "h" or "c",
+      labels = labels,
+      value = mapping.value,
+      buckets = buckets,
+    }
+  end
+  custom_mappings = compiled
+end
+
+-- _custom_label() returns a label value, labels are joined with the # separator, so it is replaced
+local function _custom_label(request, label)
+  local value
+  if label.variable then
+    value = ngx.var[label.variable]
+  elseif label.source == "Path" then
+    value = ngx.var.uri
+  elseif label.source == "StatusClass" then
+    value = sub(request.Status, 1, 1) .. "xx"
+  elseif label.source == "Upstream" then
+    value = match(ngx.var.upstream_addr or "", "([^%s,]+)$")
+  elseif label.source == "UpstreamStatus" then
+    value = match(ngx.var.upstream_status or "", "(%d+)[^%d]*$")
+  else
+    value = request[label.source]
+  end
+
+  if not value or value == "" then
+    return "-"
+  end
+  return (gsub(value, "#", "_"))
+end
+
+-- _custom_value() returns a value observed by a mapping, the mapping is skipped if there is no value
+local function _custom_value(mapping)
+  local value = mapping.value
+  if not value then
+    return 1
+  elseif value == "RequestTime" then
+    return tonumber(ngx.var.request_time)
+  elseif value == "UpstreamResponseTime" then
+    return ngx.var.upstream_addr and tonumber(ngx.var.total_upstream_response_time)
+  elseif value == "BytesSent" then
+    return tonumber(ngx.var.bytes_sent)
+  elseif value == "RequestLength" then
+    return tonumber(ngx.var.request_length)
+  end
+end
+
+-- _fill_custom() prepares metrics of custom mappings
+local function _fill_custom(request, var_annotations)
+  for _, mapping in ipairs(custom_mappings) do
+    local value = _custom_value(mapping)
+    if value then
+      local key = mapping.marker .. mapping.index
+      for _, label in ipairs(mapping.labels) do
+        key = key .. "#" .. _custom_label(request, label)
+      end
+
+      local annotations = { namespace = var_annotations.namespace, ingress = var_annotations.ingress, mapping = mapping.name }
+      if mapping.marker == "h" then
+        _observe(mapping.buckets, key, annotations, mapping.index, value)
+      else
+        _add(key, annotations, mapping.index, value)
+      end
+    end
+  end
+end
+
 local function _increment_geohash(overall_key, geoip_latitude, geoip_longitude, var_geoip_city, var_geoip_region_name, var_geoip_country_name, annotations)
   local geoip_latitude = tonumber(geoip_latitude)
   local geoip_longitude = tonumber(geoip_longitude)
@@ -365,6 +485,22 @@ local function fill_buffer()
     _increment_geohash(overall_key, ngx.var.geoip_latitude, ngx.var.geoip_longitude, ngx.var.geoip_city, ngx.var.geoip_region_name, ngx.var.geoip_city_country_code, var_annotations)
   end
 
+  if #custom_mappings > 0 then
+    local request = {
+      Namespace = var_namespace,
+      Ingress = var_ingress_name,
+      Service = var_service_name,
+      ServicePort = var_service_port,
+      Host = var_server_name,
+      Location = var_location_path,
+      ContentKind = content_kind,
+      Method = var_request_method,
+      Scheme = var_scheme,
+      Status = var_status,
+    }
+    _fill_custom(request, var_annotations)
+  end
+
   if debug_enabled then
     update_time()
     log(WARNING, format("lua parse seconds: %s", tostring(now() - start_time)))
@@ -431,6 +567,12 @@ function _M.init_worker()
   if err then
     log(ERROR, format("error while sending data: %s", tostring(err)))
   end
+
+  load_custom_mappings()
+  _, err = timer_every(10, load_custom_mappings)
+  if err then
+    log(ERROR, format("error while loading custom metric mappings: %s", tostring(err)))
+  end
 end
 
 -- call() used at log_by_lua stage to save request data to the buffer
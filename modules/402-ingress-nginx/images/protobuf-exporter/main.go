@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"os/signal"
@@ -33,11 +34,13 @@ func main() {
 	telemetryAddress := ":8080"
 	exporterAddress := ":8081"
 	mappingsPath := "./mappings.yaml"
+	customMappingsPath := ""
 	logLevel := "info"
 
 	flag.StringVar(&telemetryAddress, "server.telemetry-address", telemetryAddress, "Address to listen telemetry messages")
 	flag.StringVar(&exporterAddress, "server.exporter-address", exporterAddress, "Address to export prometheus metrics")
 	flag.StringVar(&mappingsPath, "mappings", mappingsPath, "Path to mappings")
+	flag.StringVar(&customMappingsPath, "custom-mappings", customMappingsPath, "Path to custom mappings, they are reloaded on the file change")
 	flag.StringVar(&logLevel, "log-level", logLevel, "Log level")
 	flag.Parse()
 
@@ -56,8 +59,12 @@ func main() {
 		log.Fatalf("Mappings registration from %q failed: %v", mappingsPath, err)
 	}
 
+	if customMappingsPath != "" {
+		server.NewCustomMappingsLoader(customMappingsPath, metricsVault).Start(context.Background())
+	}
+
 	errorCh := make(chan error)
-	metricsServer := server.NewMetricsServer()
+	metricsServer := server.NewMetricsServer(metricsVault)
 	tcpServer := server.NewTelemetryServer(metricsVault)
 
 	signalChan := make(chan os.Signal, 1)
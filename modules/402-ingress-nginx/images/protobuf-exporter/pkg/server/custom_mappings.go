@@ -0,0 +1,95 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/common/log"
+
+	"github.com/flant/protobuf_exporter/pkg/stats"
+	"github.com/flant/protobuf_exporter/pkg/vault"
+)
+
+// CustomMappingsLoader loads custom mappings to the vault and reloads them on the file change
+type CustomMappingsLoader struct {
+	path  string
+	vault *vault.MetricsVault
+}
+
+func NewCustomMappingsLoader(path string, vault *vault.MetricsVault) *CustomMappingsLoader {
+	return &CustomMappingsLoader{path: path, vault: vault}
+}
+
+// Start loads custom mappings and watches the file in the background.
+// Errors in custom mappings are only logged, the exporter keeps working with valid mappings.
+func (l *CustomMappingsLoader) Start(ctx context.Context) {
+	l.load()
+	go l.runWatcher(ctx)
+}
+
+func (l *CustomMappingsLoader) load() {
+	log.Infof("Loading custom mappings from %q", l.path)
+
+	mappings, err := vault.LoadMappingsByPath(l.path)
+	if err != nil {
+		log.Errorf("Can't load custom mappings: %v", err)
+		stats.Errors.WithLabelValues("load-custom-mappings").Inc()
+		return
+	}
+
+	if err := l.vault.ReplaceCustomMappings(mappings); err != nil {
+		log.Errorf("Can't register custom mappings: %v", err)
+		stats.Errors.WithLabelValues("load-custom-mappings").Inc()
+	}
+}
+
+func (l *CustomMappingsLoader) runWatcher(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatalf("start file watcher failed: %s", err)
+	}
+	defer watcher.Close()
+
+	err = watcher.Add(l.path)
+	if err != nil {
+		log.Fatalf("add watcher for file failed: %s", err)
+	}
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Remove {
+				// k8s configmaps use symlinks,
+				// old file is deleted and a new link with the same name is created
+				_ = watcher.Remove(event.Name)
+				err = watcher.Add(event.Name)
+				if err != nil {
+					log.Fatal(err)
+				}
+				l.load()
+			}
+
+		case err := <-watcher.Errors:
+			log.Errorf("watch files error: %s", err)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -21,20 +21,26 @@ import (
 	"net"
 	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/log"
 )
 
 type MetricsServer struct {
-	srv *http.Server
+	srv      *http.Server
+	gatherer prometheus.Gatherer
 }
 
-func NewMetricsServer() *MetricsServer {
-	return &MetricsServer{srv: &http.Server{}}
+// NewMetricsServer creates a server exporting metrics of the default registry and of the additional gatherer
+func NewMetricsServer(gatherer prometheus.Gatherer) *MetricsServer {
+	return &MetricsServer{srv: &http.Server{}, gatherer: gatherer}
 }
 
 func (m *MetricsServer) Start(address string, errorCh chan error) {
-	http.Handle("/metrics", promhttp.Handler())
+	gatherers := prometheus.Gatherers{prometheus.DefaultGatherer, m.gatherer}
+	http.Handle("/metrics", promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer, promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}),
+	))
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, err := fmt.Fprintf(w, `<!DOCTYPE html>
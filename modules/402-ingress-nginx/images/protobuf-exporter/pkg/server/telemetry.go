@@ -19,6 +19,7 @@ package server
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -118,9 +119,9 @@ func (s *TelemetryServer) handleConn(c *net.TCPConn) {
 				continue
 			}
 
-			err := s.vault.StoreCounter(int(message.MappingIndex), message.Labels, message.Value)
+			err := s.vault.StoreCounter(int(message.MappingIndex), message.Annotations, message.Labels, message.Value)
 			if err != nil {
-				stats.Errors.WithLabelValues("wrong-mapping").Inc()
+				stats.Errors.WithLabelValues(storeErrorType(err)).Inc()
 			} else {
 				stats.Messages.WithLabelValues("counter").Inc()
 			}
@@ -132,9 +133,9 @@ func (s *TelemetryServer) handleConn(c *net.TCPConn) {
 				continue
 			}
 
-			err := s.vault.StoreGauge(int(message.MappingIndex), message.Labels, message.Value)
+			err := s.vault.StoreGauge(int(message.MappingIndex), message.Annotations, message.Labels, message.Value)
 			if err != nil {
-				stats.Errors.WithLabelValues("wrong-mapping").Inc()
+				stats.Errors.WithLabelValues(storeErrorType(err)).Inc()
 			} else {
 				stats.Messages.WithLabelValues("gauge").Inc()
 			}
@@ -157,9 +158,9 @@ func (s *TelemetryServer) handleConn(c *net.TCPConn) {
 				buckets[bucketNumber] = value
 			}
 
-			err = s.vault.StoreHistogram(int(message.MappingIndex), message.Labels, message.Count, message.Sum, buckets)
+			err = s.vault.StoreHistogram(int(message.MappingIndex), message.Annotations, message.Labels, message.Count, message.Sum, buckets)
 			if err != nil {
-				stats.Errors.WithLabelValues("wrong-mapping").Inc()
+				stats.Errors.WithLabelValues(storeErrorType(err)).Inc()
 			} else {
 				stats.Messages.WithLabelValues("histogram").Inc()
 			}
@@ -171,6 +172,13 @@ func (s *TelemetryServer) handleConn(c *net.TCPConn) {
 	}
 }
 
+func storeErrorType(err error) string {
+	if errors.Is(err, vault.ErrSeriesLimitExceeded) {
+		return "series-limit"
+	}
+	return "wrong-mapping"
+}
+
 func (s *TelemetryServer) isMessagedDiscarded(annotation map[string]string) bool {
 	return s.messageProcessor.discardProcessor.IsDiscarded(annotation)
 }
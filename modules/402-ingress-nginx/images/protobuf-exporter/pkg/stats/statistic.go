@@ -33,9 +33,17 @@ var (
 		},
 		[]string{"type"},
 	)
+	DroppedSeries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "protobuf_exporter_dropped_series_total",
+			Help: "The number of messages dropped because the mapping series limit is reached.",
+		},
+		[]string{"mapping"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(Messages)
 	prometheus.MustRegister(Errors)
+	prometheus.MustRegister(DroppedSeries)
 }
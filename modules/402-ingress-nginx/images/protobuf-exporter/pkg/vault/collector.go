@@ -17,6 +17,7 @@ limitations under the License.
 package vault
 
 import (
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,11 +28,15 @@ import (
 	"github.com/flant/protobuf_exporter/pkg/stats"
 )
 
+// ErrSeriesLimitExceeded is returned on storing a new label set if the mapping already has MaxSeries label sets
+var ErrSeriesLimitExceeded = errors.New("series limit exceeded")
+
 type ConstMetricCollector interface {
 	GetType() MappingType
+	GetMapping() Mapping
 	Describe(ch chan<- *prometheus.Desc)
 	Collect(ch chan<- prometheus.Metric)
-	Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{})
+	Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) error
 	Clear(now time.Time)
 }
 
@@ -93,6 +98,10 @@ func (c *ConstHistogramCollector) GetType() MappingType {
 	return c.mapping.Type
 }
 
+func (c *ConstHistogramCollector) GetMapping() Mapping {
+	return c.mapping
+}
+
 func (c *ConstHistogramCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.desc
 }
@@ -112,7 +121,7 @@ func (c *ConstHistogramCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
-func (c *ConstHistogramCollector) Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) {
+func (c *ConstHistogramCollector) Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
@@ -120,6 +129,9 @@ func (c *ConstHistogramCollector) Store(labelsHash uint64, labels []string, time
 
 	storedMetric, ok := c.collection[labelsHash]
 	if !ok {
+		if seriesLimitReached(c.mapping, len(c.collection)) {
+			return ErrSeriesLimitExceeded
+		}
 		storedMetric = StampedHistogramMetric{Buckets: make(map[float64]uint64, len(c.mapping.Buckets)), LabelValues: labels}
 	}
 
@@ -136,6 +148,7 @@ func (c *ConstHistogramCollector) Store(labelsHash uint64, labels []string, time
 
 	storedMetric.LastUpdate = timestamp
 	c.collection[labelsHash] = storedMetric
+	return nil
 }
 
 func (c *ConstHistogramCollector) Clear(now time.Time) {
@@ -170,6 +183,10 @@ func (c *ConstCounterCollector) GetType() MappingType {
 	return c.mapping.Type
 }
 
+func (c *ConstCounterCollector) GetMapping() Mapping {
+	return c.mapping
+}
+
 func (c *ConstCounterCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.desc
 }
@@ -189,13 +206,16 @@ func (c *ConstCounterCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
-func (c *ConstCounterCollector) Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) {
+func (c *ConstCounterCollector) Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
 	counterValue := value.(uint64)
 	storedMetric, ok := c.collection[labelsHash]
 	if !ok {
+		if seriesLimitReached(c.mapping, len(c.collection)) {
+			return ErrSeriesLimitExceeded
+		}
 		storedMetric = StampedCounterMetric{Value: counterValue, LabelValues: labels}
 	} else {
 		atomic.AddUint64(&storedMetric.Value, counterValue)
@@ -203,6 +223,7 @@ func (c *ConstCounterCollector) Store(labelsHash uint64, labels []string, timest
 
 	storedMetric.LastUpdate = timestamp
 	c.collection[labelsHash] = storedMetric
+	return nil
 }
 
 func (c *ConstCounterCollector) Clear(now time.Time) {
@@ -237,6 +258,10 @@ func (c *ConstGaugeCollector) GetType() MappingType {
 	return c.mapping.Type
 }
 
+func (c *ConstGaugeCollector) GetMapping() Mapping {
+	return c.mapping
+}
+
 func (c *ConstGaugeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.desc
 }
@@ -256,19 +281,23 @@ func (c *ConstGaugeCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
-func (c *ConstGaugeCollector) Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) {
+func (c *ConstGaugeCollector) Store(labelsHash uint64, labels []string, timestamp time.Time, value interface{}) error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
 	gaugeValue := value.(float64)
 	storedMetric, ok := c.collection[labelsHash]
 	if !ok {
+		if seriesLimitReached(c.mapping, len(c.collection)) {
+			return ErrSeriesLimitExceeded
+		}
 		storedMetric = StampedGaugeMetric{Value: gaugeValue, LabelValues: labels}
 	}
 
 	storedMetric.Value = gaugeValue
 	storedMetric.LastUpdate = timestamp
 	c.collection[labelsHash] = storedMetric
+	return nil
 }
 
 func (c *ConstGaugeCollector) Clear(now time.Time) {
@@ -285,3 +314,12 @@ func (c *ConstGaugeCollector) Clear(now time.Time) {
 		}
 	}
 }
+
+func seriesLimitReached(mapping Mapping, series int) bool {
+	if mapping.MaxSeries == 0 || series < mapping.MaxSeries {
+		return false
+	}
+
+	stats.DroppedSeries.WithLabelValues(mapping.Name).Inc()
+	return true
+}
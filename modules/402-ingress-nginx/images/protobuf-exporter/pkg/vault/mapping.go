@@ -40,6 +40,10 @@ type Mapping struct {
 	LabelNames []string      `yaml:"labels,omitempty"`
 	Buckets    []float64     `yaml:"buckets,omitempty"`
 	TTL        time.Duration `yaml:"ttl,omitempty"`
+
+	// MaxSeries limits the number of stored label sets, new ones are dropped if the limit is reached.
+	// There is no limit when specifying 0.
+	MaxSeries int `yaml:"maxSeries,omitempty"`
 }
 
 func LoadMappings(fileContent []byte) ([]Mapping, error) {
@@ -20,78 +20,198 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 const labelsSeparator = byte(255)
 
+// CustomMappingsIndexOffset is the index of the first custom mapping in messages.
+// Custom mappings are replaced at runtime, so they are kept apart from the static ones.
+const CustomMappingsIndexOffset = 1000
+
+// MappingAnnotation is the message annotation with the name of the custom mapping the message belongs to.
+// It protects from storing messages sent for the previous version of custom mappings.
+const MappingAnnotation = "mapping"
+
 type MetricsVault struct {
 	metrics []ConstMetricCollector
 	now     func() time.Time
+
+	// custom mappings have their own registry, because metrics with the same name
+	// can't change labels in a registry during the program lifetime
+	customMtx      sync.RWMutex
+	custom         []ConstMetricCollector
+	customRegistry *prometheus.Registry
 }
 
+var _ prometheus.Gatherer = (*MetricsVault)(nil)
+
 func NewVault() *MetricsVault {
 	return &MetricsVault{now: time.Now}
 }
 
+func newCollector(mapping Mapping) (ConstMetricCollector, error) {
+	switch mapping.Type {
+	case CounterMapping:
+		return NewConstCounterCollector(mapping), nil
+	case GaugeMapping:
+		return NewConstGaugeCollector(mapping), nil
+	case HistogramMapping:
+		return NewConstHistogramCollector(mapping), nil
+	default:
+		return nil, fmt.Errorf("unknown mapping type %s", mapping.Type)
+	}
+}
+
 func (v *MetricsVault) RegisterMappings(mappings []Mapping) error {
+	if len(mappings) > CustomMappingsIndexOffset {
+		return fmt.Errorf("too many mappings, the limit is %d", CustomMappingsIndexOffset)
+	}
+
 	for _, mapping := range mappings {
-		switch mapping.Type {
-		case CounterMapping:
-			collector := NewConstCounterCollector(mapping)
-			v.metrics = append(v.metrics, collector)
+		collector, err := newCollector(mapping)
+		if err != nil {
+			return err
+		}
+		v.metrics = append(v.metrics, collector)
 
-			if err := prometheus.Register(collector); err != nil {
-				return fmt.Errorf("mapping registration: %v", err)
-			}
-		case GaugeMapping:
-			collector := NewConstGaugeCollector(mapping)
-			v.metrics = append(v.metrics, collector)
+		if err := prometheus.Register(collector); err != nil {
+			return fmt.Errorf("mapping registration: %v", err)
+		}
+	}
+	return nil
+}
 
-			if err := prometheus.Register(collector); err != nil {
-				return fmt.Errorf("mapping registration: %v", err)
-			}
-		case HistogramMapping:
-			collector := NewConstHistogramCollector(mapping)
-			v.metrics = append(v.metrics, collector)
+// ReplaceCustomMappings replaces custom mappings, stored metrics are kept for mappings that are not changed.
+// Invalid mappings are skipped and messages for them are rejected, an error is returned for them all together.
+func (v *MetricsVault) ReplaceCustomMappings(mappings []Mapping) error {
+	staticNames := make(map[string]struct{}, len(v.metrics))
+	for _, collector := range v.metrics {
+		staticNames[collector.GetMapping().Name] = struct{}{}
+	}
+
+	v.customMtx.Lock()
+	defer v.customMtx.Unlock()
+
+	previous := make(map[string]ConstMetricCollector, len(v.custom))
+	for _, collector := range v.custom {
+		if collector != nil {
+			previous[collector.GetMapping().Name] = collector
+		}
+	}
 
-			if err := prometheus.Register(collector); err != nil {
-				return fmt.Errorf("mapping registration: %v", err)
+	var errs []string
+	registry := prometheus.NewRegistry()
+	custom := make([]ConstMetricCollector, len(mappings))
+	for i, mapping := range mappings {
+		if _, ok := staticNames[mapping.Name]; ok {
+			errs = append(errs, fmt.Sprintf("%s: the name is used by a static mapping", mapping.Name))
+			continue
+		}
+
+		collector, ok := previous[mapping.Name]
+		if !ok || !reflect.DeepEqual(collector.GetMapping(), mapping) {
+			var err error
+			if collector, err = newCollector(mapping); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", mapping.Name, err))
+				continue
 			}
-		default:
-			return fmt.Errorf("unknown mapping type %s", mapping.Type)
 		}
+
+		if err := registry.Register(collector); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", mapping.Name, err))
+			continue
+		}
+		custom[i] = collector
+	}
+
+	v.custom = custom
+	v.customRegistry = registry
+
+	if len(errs) > 0 {
+		return fmt.Errorf("custom mappings registration: %s", strings.Join(errs, "; "))
 	}
 	return nil
 }
 
-func (v *MetricsVault) StoreHistogram(index int, labels []string, count uint64, sum float64, buckets map[float64]uint64) error {
-	binding := v.metrics[index]
-	if binding.GetType() != HistogramMapping {
-		return fmt.Errorf("wrong mapping for index #%v", index)
+// Gather collects metrics of custom mappings
+func (v *MetricsVault) Gather() ([]*dto.MetricFamily, error) {
+	v.customMtx.RLock()
+	registry := v.customRegistry
+	v.customMtx.RUnlock()
+
+	if registry == nil {
+		return nil, nil
 	}
-	binding.Store(hashLabels(labels), labels, v.now(), BucketValue{Count: count, Sum: sum, Buckets: buckets})
-	return nil
+	return registry.Gather()
 }
 
-func (v *MetricsVault) StoreCounter(index int, labels []string, value uint64) error {
-	binding := v.metrics[index]
-	if binding.GetType() != CounterMapping {
-		return fmt.Errorf("wrong mapping for index #%v", index)
+func (v *MetricsVault) binding(index int, annotations map[string]string) (ConstMetricCollector, error) {
+	if index >= 0 && index < len(v.metrics) {
+		return v.metrics[index], nil
 	}
-	binding.Store(hashLabels(labels), labels, v.now(), value)
-	return nil
+
+	if index < CustomMappingsIndexOffset {
+		return nil, fmt.Errorf("no mapping for index #%v", index)
+	}
+
+	v.customMtx.RLock()
+	defer v.customMtx.RUnlock()
+
+	index -= CustomMappingsIndexOffset
+	if index >= len(v.custom) || v.custom[index] == nil {
+		return nil, fmt.Errorf("no custom mapping for index #%v", index)
+	}
+
+	binding := v.custom[index]
+	if annotations[MappingAnnotation] != binding.GetMapping().Name {
+		return nil, fmt.Errorf("custom mapping #%v is %s, message is for %s", index, binding.GetMapping().Name, annotations[MappingAnnotation])
+	}
+	return binding, nil
 }
 
-func (v *MetricsVault) StoreGauge(index int, labels []string, value float64) error {
-	binding := v.metrics[index]
-	if binding.GetType() != GaugeMapping {
-		return fmt.Errorf("wrong mapping for index #%v", index)
+func (v *MetricsVault) StoreHistogram(index int, annotations map[string]string, labels []string, count uint64, sum float64, buckets map[float64]uint64) error {
+	binding, err := v.checkedBinding(index, annotations, HistogramMapping, labels)
+	if err != nil {
+		return err
 	}
-	binding.Store(hashLabels(labels), labels, v.now(), value)
-	return nil
+	return binding.Store(hashLabels(labels), labels, v.now(), BucketValue{Count: count, Sum: sum, Buckets: buckets})
+}
+
+func (v *MetricsVault) StoreCounter(index int, annotations map[string]string, labels []string, value uint64) error {
+	binding, err := v.checkedBinding(index, annotations, CounterMapping, labels)
+	if err != nil {
+		return err
+	}
+	return binding.Store(hashLabels(labels), labels, v.now(), value)
+}
+
+func (v *MetricsVault) StoreGauge(index int, annotations map[string]string, labels []string, value float64) error {
+	binding, err := v.checkedBinding(index, annotations, GaugeMapping, labels)
+	if err != nil {
+		return err
+	}
+	return binding.Store(hashLabels(labels), labels, v.now(), value)
+}
+
+func (v *MetricsVault) checkedBinding(index int, annotations map[string]string, mappingType MappingType, labels []string) (ConstMetricCollector, error) {
+	binding, err := v.binding(index, annotations)
+	if err != nil {
+		return nil, err
+	}
+	if binding.GetType() != mappingType {
+		return nil, fmt.Errorf("wrong mapping for index #%v", index)
+	}
+	if len(labels) != len(binding.GetMapping().LabelNames) {
+		return nil, fmt.Errorf("wrong labels count for index #%v", index)
+	}
+	return binding, nil
 }
 
 func hashLabels(labels []string) uint64 {
@@ -112,4 +232,13 @@ func (v *MetricsVault) RemoveStaleMetrics() {
 	for _, m := range v.metrics {
 		m.Clear(currentTime)
 	}
+
+	v.customMtx.RLock()
+	defer v.customMtx.RUnlock()
+
+	for _, m := range v.custom {
+		if m != nil {
+			m.Clear(currentTime)
+		}
+	}
 }
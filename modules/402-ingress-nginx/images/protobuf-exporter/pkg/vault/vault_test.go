@@ -0,0 +1,122 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"errors"
+	"testing"
+)
+
+func customAnnotations(name string) map[string]string {
+	return map[string]string{MappingAnnotation: name}
+}
+
+func counterValue(t *testing.T, v *MetricsVault, index int, labels []string) uint64 {
+	t.Helper()
+
+	collector := v.custom[index].(*ConstCounterCollector)
+	return collector.collection[hashLabels(labels)].Value
+}
+
+func TestReplaceCustomMappings(t *testing.T) {
+	v := NewVault()
+
+	kept := Mapping{Name: "test_custom_kept_total", Type: CounterMapping, LabelNames: []string{"path"}}
+	changed := Mapping{Name: "test_custom_changed_total", Type: CounterMapping, LabelNames: []string{"path"}}
+
+	if err := v.ReplaceCustomMappings([]Mapping{kept, changed}); err != nil {
+		t.Fatalf("replace custom mappings: %v", err)
+	}
+
+	for i, mapping := range []Mapping{kept, changed} {
+		err := v.StoreCounter(CustomMappingsIndexOffset+i, customAnnotations(mapping.Name), []string{"/"}, 5)
+		if err != nil {
+			t.Fatalf("store counter %s: %v", mapping.Name, err)
+		}
+	}
+
+	changed.LabelNames = []string{"path", "method"}
+	if err := v.ReplaceCustomMappings([]Mapping{changed, kept}); err != nil {
+		t.Fatalf("replace custom mappings: %v", err)
+	}
+
+	if value := counterValue(t, v, 1, []string{"/"}); value != 5 {
+		t.Errorf("unchanged mapping value: got %d | expected 5", value)
+	}
+	if value := counterValue(t, v, 0, []string{"/"}); value != 0 {
+		t.Errorf("changed mapping value: got %d | expected it to be reset", value)
+	}
+
+	// a message sent for the previous mappings
+	err := v.StoreCounter(CustomMappingsIndexOffset, customAnnotations(kept.Name), []string{"/"}, 1)
+	if err == nil {
+		t.Errorf("message for another mapping must be rejected")
+	}
+
+	err = v.StoreCounter(CustomMappingsIndexOffset, customAnnotations(changed.Name), []string{"/"}, 1)
+	if err == nil {
+		t.Errorf("message with wrong labels count must be rejected")
+	}
+
+	if err := v.ReplaceCustomMappings(nil); err != nil {
+		t.Fatalf("remove custom mappings: %v", err)
+	}
+	err = v.StoreCounter(CustomMappingsIndexOffset, customAnnotations(changed.Name), []string{"/", "GET"}, 1)
+	if err == nil {
+		t.Errorf("message for removed mapping must be rejected")
+	}
+}
+
+func TestReplaceCustomMappingsInvalid(t *testing.T) {
+	v := NewVault()
+
+	mappings := []Mapping{
+		{Name: "test_custom_invalid", Type: "Summary"},
+		{Name: "test_custom_valid_total", Type: CounterMapping},
+	}
+
+	if err := v.ReplaceCustomMappings(mappings); err == nil {
+		t.Fatalf("expected an error for invalid mapping")
+	}
+
+	if err := v.StoreCounter(CustomMappingsIndexOffset+1, customAnnotations("test_custom_valid_total"), nil, 1); err != nil {
+		t.Errorf("valid mapping must be registered: %v", err)
+	}
+}
+
+func TestSeriesLimit(t *testing.T) {
+	v := NewVault()
+
+	mapping := Mapping{Name: "test_custom_limited_seconds", Type: HistogramMapping, LabelNames: []string{"path"}, Buckets: []float64{1, 2}, MaxSeries: 2}
+	if err := v.ReplaceCustomMappings([]Mapping{mapping}); err != nil {
+		t.Fatalf("replace custom mappings: %v", err)
+	}
+
+	store := func(path string) error {
+		return v.StoreHistogram(CustomMappingsIndexOffset, customAnnotations(mapping.Name), []string{path}, 1, 1, map[float64]uint64{1: 1})
+	}
+
+	for _, path := range []string{"/a", "/b", "/a"} {
+		if err := store(path); err != nil {
+			t.Fatalf("store %s: %v", path, err)
+		}
+	}
+
+	if err := store("/c"); !errors.Is(err, ErrSeriesLimitExceeded) {
+		t.Errorf("new series over the limit: got %v | expected %v", err, ErrSeriesLimitExceeded)
+	}
+}
@@ -310,6 +310,62 @@ memory: 500Mi`))
 			Expect(waitLbZeroDs.Field("spec.template.spec.containers.0.args").Array()).To(ContainElement(ContainSubstring(`--shutdown-grace-period=0`)))
 		})
 
+		Context("With custom metric mappings", func() {
+			BeforeEach(func() {
+				hec.ValuesSetFromYaml("ingressNginx.internal.metricMappings", `
+- ingressControllerName: ""
+  resource: a
+  name: ingress_nginx_custom_requests_total
+  type: Counter
+  help: Requests
+  labels: [path]
+  labelSources: [{name: path, source: Path}]
+  ttl: 1h
+  maxSeries: 1000
+- ingressControllerName: test
+  resource: b
+  name: ingress_nginx_custom_requests_total
+  type: Counter
+  help: Duplicate
+  labels: []
+  labelSources: []
+  ttl: 1h
+  maxSeries: 1000
+- ingressControllerName: solid
+  resource: c
+  name: ingress_nginx_solid_request_seconds
+  type: Histogram
+  help: Request time
+  labels: []
+  labelSources: []
+  value: RequestTime
+  buckets: [0.1, 1]
+  ttl: 1h
+  maxSeries: 10
+`)
+				hec.HelmRender()
+			})
+
+			It("Should render mappings for each controller", func() {
+				Expect(hec.RenderError).ShouldNot(HaveOccurred())
+
+				testCm := hec.KubernetesResource("ConfigMap", "d8-ingress-nginx", "test-custom-metric-mappings")
+				Expect(testCm.Field("data.mappings\\.json").String()).To(MatchJSON(`[
+{"name": "ingress_nginx_custom_requests_total", "type": "Counter", "help": "Requests", "labels": ["path"], "labelSources": [{"name": "path", "source": "Path"}], "ttl": "1h", "maxSeries": 1000}
+]`))
+
+				solidCm := hec.KubernetesResource("ConfigMap", "d8-ingress-nginx", "solid-custom-metric-mappings")
+				Expect(solidCm.Field("data.mappings\\.json").String()).To(MatchJSON(`[
+{"name": "ingress_nginx_custom_requests_total", "type": "Counter", "help": "Requests", "labels": ["path"], "labelSources": [{"name": "path", "source": "Path"}], "ttl": "1h", "maxSeries": 1000},
+{"name": "ingress_nginx_solid_request_seconds", "type": "Histogram", "help": "Request time", "labels": [], "labelSources": [], "value": "RequestTime", "buckets": [0.1, 1], "ttl": "1h", "maxSeries": 10}
+]`))
+
+				testD := hec.KubernetesResource("DaemonSet", "d8-ingress-nginx", "controller-test")
+				Expect(testD.Field("spec.template.spec.containers.1.args").AsStringSlice()).To(Equal([]string{"-custom-mappings", "/var/custom-metric-mappings/mappings.json"}))
+				Expect(testD.Field(`spec.template.spec.volumes.#(name=="custom-metric-mappings").configMap.name`).String()).To(Equal("test-custom-metric-mappings"))
+			})
+		})
+
 		Context("Vertical pod autoscaler CRD is disabled", func() {
 			BeforeEach(func() {
 				hec.ValuesSet("global.enabledModules", []string{"cert-manager"})
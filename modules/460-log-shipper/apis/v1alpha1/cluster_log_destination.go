@@ -38,7 +38,7 @@ type ClusterLogDestination struct {
 }
 
 type ClusterLogDestinationSpec struct {
-	// Type of cluster log source: Loki, Elasticsearch, Logstash, Vector
+	// Type of cluster log source: Loki, Elasticsearch, Logstash, Vector, Kafka, Splunk, OTLP, Syslog, GELF, S3
 	Type string `json:"type,omitempty"`
 
 	// Loki describes spec for loki endpoint
@@ -59,6 +59,18 @@ type ClusterLogDestinationSpec struct {
 	// Vector spec for the Vector endpoint
 	Vector VectorSpec `json:"vector"`
 
+	// OTLP spec for the OpenTelemetry collector endpoint
+	OTLP OTLPSpec `json:"otlp"`
+
+	// Syslog spec for the syslog server endpoint
+	Syslog SyslogSpec `json:"syslog"`
+
+	// GELF spec for the Graylog endpoint
+	GELF GELFSpec `json:"gelf"`
+
+	// S3 spec for the S3-compatible object storage
+	S3 S3Spec `json:"s3"`
+
 	// Add extra labels for sources
 	ExtraLabels map[string]string `json:"extraLabels,omitempty"`
 
@@ -69,8 +81,31 @@ type ClusterLogDestinationSpec struct {
 }
 
 type ClusterLogDestinationStatus struct {
+	// Health is a summarized delivery state of the destination: Healthy, Degraded, Failing or Unknown
+	Health DestinationHealth `json:"health,omitempty"`
+	// SentEvents is the number of events sent by all agents during the last check period
+	SentEvents int64 `json:"sentEvents"`
+	// DroppedEvents is the number of events discarded by all agents during the last check period
+	DroppedEvents int64 `json:"droppedEvents"`
+	// Errors is the number of sending errors of all agents during the last check period
+	Errors int64 `json:"errors"`
+	// LastError describes the most frequent error of the last check period
+	LastError string `json:"lastError,omitempty"`
+	// LastSuccessfulSendTime is the last time events were sent to the destination
+	LastSuccessfulSendTime *metav1.Time `json:"lastSuccessfulSendTime,omitempty"`
+	// LastCheckTime is the time of the last status update
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
 }
 
+type DestinationHealth string
+
+const (
+	DestinationHealthy  DestinationHealth = "Healthy"
+	DestinationDegraded DestinationHealth = "Degraded"
+	DestinationFailing  DestinationHealth = "Failing"
+	DestinationUnknown  DestinationHealth = "Unknown"
+)
+
 type LokiAuthSpec struct {
 	Password string `json:"password,omitempty"`
 	Strategy string `json:"strategy,omitempty"`
@@ -193,6 +228,103 @@ type SplunkSpec struct {
 	TLS CommonTLSSpec `json:"tls,omitempty"`
 }
 
+type OTLPAuthSpec struct {
+	Password string `json:"password,omitempty"`
+	Strategy string `json:"strategy,omitempty"`
+	Token    string `json:"token,omitempty"`
+	User     string `json:"user,omitempty"`
+}
+
+type OTLPSpec struct {
+	// Endpoint is the OTLP receiver URL. The /v1/logs path is used if the OTLP/HTTP URL has no path.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Protocol is the OTLP transport: HTTP or GRPC
+	Protocol OTLPProtocol `json:"protocol,omitempty"`
+
+	Headers map[string]string `json:"headers,omitempty"`
+
+	Auth OTLPAuthSpec `json:"auth,omitempty"`
+
+	TLS CommonTLSSpec `json:"tls,omitempty"`
+}
+
+type OTLPProtocol = string
+
+const (
+	OTLPProtocolHTTP OTLPProtocol = "HTTP"
+	OTLPProtocolGRPC OTLPProtocol = "GRPC"
+)
+
+type SocketMode = string
+
+const (
+	SocketModeTCP SocketMode = "TCP"
+	SocketModeUDP SocketMode = "UDP"
+)
+
+type SyslogSpec struct {
+	Endpoint string `json:"endpoint,omitempty"`
+
+	Mode SocketMode `json:"mode,omitempty"`
+
+	// Facility is the syslog facility name, e.g., local0
+	Facility string `json:"facility,omitempty"`
+
+	TLS CommonTLSSpec `json:"tls,omitempty"`
+}
+
+type GELFSpec struct {
+	Endpoint string `json:"endpoint,omitempty"`
+
+	Mode SocketMode `json:"mode,omitempty"`
+
+	TLS CommonTLSSpec `json:"tls,omitempty"`
+}
+
+type S3Compression = string
+
+const (
+	S3CompressionGzip S3Compression = "Gzip"
+	S3CompressionZstd S3Compression = "Zstd"
+	S3CompressionNone S3Compression = "None"
+)
+
+type S3Spec struct {
+	// Endpoint of the S3-compatible storage, the AWS endpoint for the region is used if empty
+	Endpoint string `json:"endpoint,omitempty"`
+
+	Region string `json:"region,omitempty"`
+
+	Bucket string `json:"bucket,omitempty"`
+
+	// KeyPrefix is a template of the object key prefix, e.g., {{ namespace }}/%F/
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+
+	Compression S3Compression `json:"compression,omitempty"`
+
+	Encoding CommonEncoding `json:"encoding,omitempty"`
+
+	Batch S3Batch `json:"batch,omitempty"`
+
+	Auth S3Auth `json:"auth,omitempty"`
+
+	TLS CommonTLSSpec `json:"tls,omitempty"`
+}
+
+type S3Batch struct {
+	// The maximum size of an object before compression.
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// The maximum time to collect events for an object.
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+type S3Auth struct {
+	AccessKeyID     string `json:"accessKeyID,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+}
+
 type Buffer struct {
 	// The type of buffer to use.
 	Type BufferType `json:"type,omitempty"`
@@ -38,7 +38,7 @@ type ClusterLoggingConfig struct {
 }
 
 type ClusterLoggingConfigSpec struct {
-	// Type of cluster log source: KubernetesPods, File
+	// Type of cluster log source: KubernetesPods, File, KubernetesEvents, Journald
 	Type string `json:"type,omitempty"`
 
 	// KubernetesPods describes spec for kubernetes pod source
@@ -47,6 +47,9 @@ type ClusterLoggingConfigSpec struct {
 	// File describes spec for file source
 	File FileSpec `json:"file,omitempty"`
 
+	// Journald describes spec for journald source
+	Journald JournaldSpec `json:"journald,omitempty"`
+
 	// Filters
 	LogFilters   []Filter `json:"logFilter,omitempty"`
 	LabelFilters []Filter `json:"labelFilter,omitempty"`
@@ -54,6 +57,15 @@ type ClusterLoggingConfigSpec struct {
 	// Multiline parsers
 	MultiLineParser MultiLineParser `json:"multilineParser,omitempty"`
 
+	// Parser extracts fields from messages of a well-known format
+	Parser Parser `json:"parser,omitempty"`
+
+	// Redaction masks sensitive data in messages
+	Redaction Redaction `json:"redaction,omitempty"`
+
+	// Sampling reduces the number of messages
+	Sampling Sampling `json:"sampling,omitempty"`
+
 	// DestinationRefs slice of ClusterLogDestination names
 	DestinationRefs []string `json:"destinationRefs,omitempty"`
 }
@@ -79,3 +91,8 @@ type FileSpec struct {
 	Exclude       []string `json:"exclude,omitempty"`
 	LineDelimiter string   `json:"lineDelimiter,omitempty"`
 }
+
+type JournaldSpec struct {
+	IncludeUnits []string `json:"includeUnits,omitempty"`
+	ExcludeUnits []string `json:"excludeUnits,omitempty"`
+}
@@ -32,6 +32,9 @@ func NamespacedToCluster(namespaced PodLoggingConfig) ClusterLoggingConfig {
 			LabelFilters:    namespaced.Spec.LabelFilters,
 			LogFilters:      namespaced.Spec.LogFilters,
 			MultiLineParser: namespaced.Spec.MultiLineParser,
+			Parser:          namespaced.Spec.Parser,
+			Redaction:       namespaced.Spec.Redaction,
+			Sampling:        namespaced.Spec.Sampling,
 
 			KubernetesPods: KubernetesPodsSpec{
 				NamespaceSelector: NamespaceSelector{MatchNames: []string{namespaced.Namespace}},
@@ -0,0 +1,36 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+type Parser struct {
+	Type ParserType `json:"type,omitempty"`
+	// Regex is a regular expression with named capture groups, required for the Regex parser
+	Regex string `json:"regex,omitempty"`
+}
+
+type ParserType string
+
+const (
+	ParserTypeNone           ParserType = "None"
+	ParserTypeLogfmt         ParserType = "Logfmt"
+	ParserTypeKlog           ParserType = "Klog"
+	ParserTypeNginxCombined  ParserType = "NginxCombined"
+	ParserTypeApacheCombined ParserType = "ApacheCombined"
+	ParserTypeSyslog         ParserType = "Syslog"
+	ParserTypeCRI            ParserType = "CRI"
+	ParserTypeRegex          ParserType = "Regex"
+)
@@ -46,6 +46,15 @@ type PodLoggingConfigSpec struct {
 	// Multiline parsers
 	MultiLineParser MultiLineParser `json:"multilineParser,omitempty"`
 
+	// Parser extracts fields from messages of a well-known format
+	Parser Parser `json:"parser,omitempty"`
+
+	// Redaction masks sensitive data in messages
+	Redaction Redaction `json:"redaction,omitempty"`
+
+	// Sampling reduces the number of messages
+	Sampling Sampling `json:"sampling,omitempty"`
+
 	// ClusterDestinationRefs slice of ClusterLogDestination names
 	ClusterDestinationRefs []string `json:"clusterDestinationRefs,omitempty"`
 }
@@ -0,0 +1,43 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+type Redaction struct {
+	// Patterns is a list of built-in patterns of sensitive data to mask
+	Patterns []RedactionPattern `json:"patterns,omitempty"`
+	// Custom is a list of regular expressions to mask
+	Custom []string `json:"custom,omitempty"`
+	// DropFields is a list of fields to remove from the parsed message
+	DropFields []string `json:"dropFields,omitempty"`
+}
+
+type RedactionPattern string
+
+const (
+	RedactionPatternEmail       RedactionPattern = "Email"
+	RedactionPatternCreditCard  RedactionPattern = "CreditCard"
+	RedactionPatternBearerToken RedactionPattern = "BearerToken"
+)
+
+type Sampling struct {
+	// Rate keeps one of N messages, messages are not sampled if the rate is lower than 2
+	Rate int32 `json:"rate,omitempty"`
+	// KeyField is a field of the parsed message to sample messages by, messages with the same key value are kept or dropped together
+	KeyField string `json:"keyField,omitempty"`
+	// AlwaysKeepLevels is a list of levels of messages that should never be dropped
+	AlwaysKeepLevels []string `json:"alwaysKeepLevels,omitempty"`
+}
@@ -23,9 +23,15 @@ const (
 	DestVector        = "Vector"
 	DestKafka         = "Kafka"
 	DestSplunk        = "Splunk"
+	DestOTLP          = "OTLP"
+	DestSyslog        = "Syslog"
+	DestGELF          = "GELF"
+	DestS3            = "S3"
 )
 
 const (
-	SourceKubernetesPods = "KubernetesPods"
-	SourceFile           = "File"
+	SourceKubernetesPods   = "KubernetesPods"
+	SourceFile             = "File"
+	SourceKubernetesEvents = "KubernetesEvents"
+	SourceJournald         = "Journald"
 )
@@ -0,0 +1,267 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
+	"github.com/flant/addon-operator/pkg/module_manager/go_hook/metrics"
+	"github.com/flant/addon-operator/sdk"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/pointer"
+
+	"github.com/deckhouse/deckhouse/go_lib/dependency"
+	d8http "github.com/deckhouse/deckhouse/go_lib/dependency/http"
+	"github.com/deckhouse/deckhouse/go_lib/module"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+// This hook aggregates delivery metrics of all log-shipper agents from Prometheus
+// and stores the summarized state to the status of each ClusterLogDestination.
+
+const (
+	destinationHealthMetricsGroup = "log_shipper_destination_health"
+	destinationSinkPrefix         = "destination/cluster/"
+
+	// Vector metrics are counters of events since the agent start, so the increase over the period is the number
+	// of events in the last 5 minutes. Counter resets on agent restarts are handled by increase().
+	destinationStatusQuery = `
+label_replace(sum by (component_id) (increase(vector_component_sent_events_total{component_kind="sink", job="log-shipper-agent"}[5m])), "counter", "sent", "", "")
+or
+label_replace(sum by (component_id) (increase(vector_component_discarded_events_total{component_kind="sink", job="log-shipper-agent"}[5m])), "counter", "dropped", "", "")
+or
+label_replace(sum by (component_id) (increase(vector_buffer_discarded_events_total{component_kind="sink", job="log-shipper-agent"}[5m])), "counter", "buffer_dropped", "", "")
+or
+label_replace(sum by (component_id, error_type, stage) (increase(vector_component_errors_total{component_kind="sink", job="log-shipper-agent"}[5m])), "counter", "errors", "", "")
+`
+)
+
+type destinationStatusSnapshot struct {
+	Name                   string
+	LastSuccessfulSendTime *metav1.Time
+}
+
+func filterDestinationStatus(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	var dst v1alpha1.ClusterLogDestination
+
+	err := sdk.FromUnstructured(obj, &dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return destinationStatusSnapshot{
+		Name:                   dst.Name,
+		LastSuccessfulSendTime: dst.Status.LastSuccessfulSendTime,
+	}, nil
+}
+
+var _ = sdk.RegisterFunc(&go_hook.HookConfig{
+	Queue: "/modules/log-shipper/destination_status",
+	Schedule: []go_hook.ScheduleConfig{
+		{
+			Name:    "destination_status",
+			Crontab: "* * * * *", // every minute
+		},
+	},
+	Kubernetes: []go_hook.KubernetesConfig{
+		{
+			Name:                         "destinations",
+			ApiVersion:                   "deckhouse.io/v1alpha1",
+			Kind:                         "ClusterLogDestination",
+			ExecuteHookOnSynchronization: pointer.Bool(false),
+			ExecuteHookOnEvents:          pointer.Bool(false),
+			FilterFunc:                   filterDestinationStatus,
+		},
+	},
+}, dependency.WithExternalDependencies(updateDestinationStatus))
+
+func updateDestinationStatus(input *go_hook.HookInput, dc dependency.Container) error {
+	input.MetricsCollector.Expire(destinationHealthMetricsGroup)
+
+	if !input.Values.Get("logShipper.internal.activated").Bool() || !module.IsEnabled("prometheus", input) {
+		return nil
+	}
+
+	snapshot := input.Snapshots["destinations"]
+	if len(snapshot) == 0 {
+		return nil
+	}
+
+	response, err := queryDestinationMetrics(dc)
+	if err != nil {
+		input.LogEntry.Warnf("Prometheus request for log-shipper destination metrics failed: %s", err)
+		return nil // don't fail the hook
+	}
+
+	counters := aggregateDestinationCounters(response)
+	now := metav1.NewTime(time.Now().UTC().Truncate(time.Second))
+
+	for _, s := range snapshot {
+		dst := s.(destinationStatusSnapshot)
+
+		status := counters[dst.Name].toStatus(dst.LastSuccessfulSendTime, now)
+
+		input.MetricsCollector.Set("d8_log_shipper_destination_health", 1, map[string]string{
+			"destination": dst.Name,
+			"health":      string(status.Health),
+		}, metrics.WithGroup(destinationHealthMetricsGroup))
+
+		input.PatchCollector.MergePatch(
+			map[string]interface{}{"status": status},
+			"deckhouse.io/v1alpha1", "ClusterLogDestination", "", dst.Name,
+			object_patch.WithSubresource("/status"), object_patch.IgnoreMissingObject(),
+		)
+	}
+
+	return nil
+}
+
+type destinationCounters struct {
+	Sent    int64
+	Dropped int64
+	Errors  int64
+
+	lastError      string
+	lastErrorCount int64
+}
+
+func (c *destinationCounters) toStatus(lastSuccessfulSend *metav1.Time, now metav1.Time) v1alpha1.ClusterLogDestinationStatus {
+	status := v1alpha1.ClusterLogDestinationStatus{
+		Health:                 v1alpha1.DestinationUnknown,
+		LastSuccessfulSendTime: lastSuccessfulSend,
+		LastCheckTime:          &now,
+	}
+	if c == nil {
+		return status
+	}
+
+	status.SentEvents = c.Sent
+	status.DroppedEvents = c.Dropped
+	status.Errors = c.Errors
+	status.LastError = c.lastError
+
+	if c.Sent > 0 {
+		status.LastSuccessfulSendTime = &now
+	}
+
+	switch {
+	case c.Errors > 0 && c.Sent == 0:
+		status.Health = v1alpha1.DestinationFailing
+	case c.Errors > 0 || c.Dropped > 0:
+		status.Health = v1alpha1.DestinationDegraded
+	case c.Sent > 0:
+		status.Health = v1alpha1.DestinationHealthy
+	}
+
+	return status
+}
+
+func aggregateDestinationCounters(response *destinationMetrics) map[string]*destinationCounters {
+	res := make(map[string]*destinationCounters)
+
+	for _, record := range response.Data.Result {
+		name := strings.TrimPrefix(record.Metric.ComponentID, destinationSinkPrefix)
+		if name == record.Metric.ComponentID || len(record.Value) < 2 {
+			continue
+		}
+
+		raw, _ := record.Value[1].(string)
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		// increase() is extrapolated to the whole period, so the value is not an integer
+		count := int64(math.Round(value))
+
+		c, ok := res[name]
+		if !ok {
+			c = &destinationCounters{}
+			res[name] = c
+		}
+
+		switch record.Metric.Counter {
+		case "sent":
+			c.Sent += count
+		case "dropped", "buffer_dropped":
+			c.Dropped += count
+		case "errors":
+			c.Errors += count
+			if count > c.lastErrorCount {
+				c.lastErrorCount = count
+				c.lastError = fmt.Sprintf("%s errors during the %s stage", record.Metric.ErrorType, record.Metric.Stage)
+			}
+		}
+	}
+
+	return res
+}
+
+func queryDestinationMetrics(dc dependency.Container) (*destinationMetrics, error) {
+	cl := dc.GetHTTPClient(d8http.WithInsecureSkipVerify())
+
+	promURL := "https://prometheus.d8-monitoring:9090/api/v1/query?" + url.Values{"query": {destinationStatusQuery}}.Encode()
+	req, err := http.NewRequest(http.MethodGet, promURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = d8http.SetKubeAuthToken(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := cl.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	var response destinationMetrics
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+type destinationMetrics struct {
+	Data struct {
+		Result []struct {
+			Metric struct {
+				ComponentID string `json:"component_id"`
+				Counter     string `json:"counter"`
+				ErrorType   string `json:"error_type"`
+				Stage       string `json:"stage"`
+			} `json:"metric"`
+			Value []interface{} `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/deckhouse/deckhouse/go_lib/dependency"
+	. "github.com/deckhouse/deckhouse/testing/hooks"
+)
+
+const destinationStatusManifests = `
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: ClusterLogDestination
+metadata:
+  name: loki-storage
+spec:
+  type: Loki
+  loki:
+    endpoint: http://loki.loki:3100
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: ClusterLogDestination
+metadata:
+  name: kafka-storage
+spec:
+  type: Kafka
+  kafka:
+    bootstrapServers: ["kafka:9092"]
+    topic: logs
+status:
+  lastSuccessfulSendTime: "2023-01-01T00:00:00Z"
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: ClusterLogDestination
+metadata:
+  name: es-storage
+spec:
+  type: Elasticsearch
+  elasticsearch:
+    endpoint: http://es:9200
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: ClusterLogDestination
+metadata:
+  name: logstash-storage
+spec:
+  type: Logstash
+  logstash:
+    endpoint: logstash:5044
+`
+
+const destinationStatusPrometheusResponse = `{
+  "status": "success",
+  "data": {
+    "resultType": "vector",
+    "result": [
+      {"metric": {"component_id": "destination/cluster/loki-storage", "counter": "sent"}, "value": [1700000000, "1499.7"]},
+      {"metric": {"component_id": "destination/cluster/kafka-storage", "counter": "errors", "error_type": "request_failed", "stage": "sending"}, "value": [1700000000, "12"]},
+      {"metric": {"component_id": "destination/cluster/kafka-storage", "counter": "errors", "error_type": "encoder_failed", "stage": "processing"}, "value": [1700000000, "3"]},
+      {"metric": {"component_id": "destination/cluster/es-storage", "counter": "sent"}, "value": [1700000000, "100"]},
+      {"metric": {"component_id": "destination/cluster/es-storage", "counter": "buffer_dropped"}, "value": [1700000000, "7"]}
+    ]
+  }
+}`
+
+var _ = Describe("Log shipper :: destination status ::", func() {
+	f := HookExecutionConfigInit(`{"global": {"enabledModules": ["prometheus"]}, "logShipper": {"internal": {"activated": true}}}`, ``)
+	f.RegisterCRD("deckhouse.io", "v1alpha1", "ClusterLogDestination", false)
+
+	Context("With metrics in Prometheus", func() {
+		BeforeEach(func() {
+			dependency.TestDC.HTTPClient.DoMock.
+				Expect(&http.Request{}).
+				Return(&http.Response{
+					Header:     map[string][]string{"Content-Type": {"application/json"}},
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(destinationStatusPrometheusResponse)),
+				}, nil)
+
+			f.KubeStateSet(destinationStatusManifests)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Should set statuses of destinations", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			loki := f.KubernetesGlobalResource("ClusterLogDestination", "loki-storage")
+			Expect(loki.Field("status.health").String()).To(Equal("Healthy"))
+			Expect(loki.Field("status.sentEvents").Int()).To(Equal(int64(1500)))
+			Expect(loki.Field("status.lastSuccessfulSendTime").Exists()).To(BeTrue())
+
+			kafka := f.KubernetesGlobalResource("ClusterLogDestination", "kafka-storage")
+			Expect(kafka.Field("status.health").String()).To(Equal("Failing"))
+			Expect(kafka.Field("status.errors").Int()).To(Equal(int64(15)))
+			Expect(kafka.Field("status.lastError").String()).To(Equal("request_failed errors during the sending stage"))
+			Expect(kafka.Field("status.lastSuccessfulSendTime").String()).To(Equal("2023-01-01T00:00:00Z"))
+
+			es := f.KubernetesGlobalResource("ClusterLogDestination", "es-storage")
+			Expect(es.Field("status.health").String()).To(Equal("Degraded"))
+			Expect(es.Field("status.droppedEvents").Int()).To(Equal(int64(7)))
+
+			logstash := f.KubernetesGlobalResource("ClusterLogDestination", "logstash-storage")
+			Expect(logstash.Field("status.health").String()).To(Equal("Unknown"))
+
+			metrics := f.MetricsCollector.CollectedMetrics()
+			Expect(metrics).To(HaveLen(5))
+			Expect(metrics[2].Labels).To(Equal(map[string]string{"destination": "kafka-storage", "health": "Failing"}))
+		})
+	})
+
+	Context("With Prometheus disabled", func() {
+		BeforeEach(func() {
+			f.ValuesSetFromYaml("global.enabledModules", []byte(`[]`))
+			f.KubeStateSet(destinationStatusManifests)
+			f.BindingContexts.Set(f.GenerateScheduleContext("* * * * *"))
+			f.RunHook()
+		})
+
+		It("Should not touch destinations", func() {
+			Expect(f).To(ExecuteSuccessfully())
+
+			loki := f.KubernetesGlobalResource("ClusterLogDestination", "loki-storage")
+			Expect(loki.Field("status").Exists()).To(BeFalse())
+		})
+	})
+})
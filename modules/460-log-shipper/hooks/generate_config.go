@@ -59,7 +59,13 @@ func filterClusterLogDestination(obj *unstructured.Unstructured) (go_hook.Filter
 	if err != nil {
 		return nil, err
 	}
-	return dst, nil
+
+	// Only the name and the spec are used to generate the config.
+	// Status is updated regularly and should not trigger the config generation.
+	return v1alpha1.ClusterLogDestination{
+		ObjectMeta: metav1.ObjectMeta{Name: dst.Name},
+		Spec:       dst.Spec,
+	}, nil
 }
 
 func filterNamespaceName(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
@@ -109,16 +115,27 @@ func generateConfig(input *go_hook.HookInput) error {
 	if len(input.Snapshots["namespace"]) < 1 {
 		// there is no namespace to manipulate the config map, the hook will create it later on afterHelm
 		input.Values.Set("logShipper.internal.activated", false)
+		input.Values.Set("logShipper.internal.kubernetesEventsEnabled", false)
+		input.Values.Set("logShipper.internal.otlpForwarderEnabled", false)
 		return nil
 	}
 
-	configContent, err := composer.FromInput(input).Do()
+	comp := composer.FromInput(input)
+
+	configContent, err := comp.Do()
+	if err != nil {
+		return err
+	}
+
+	forwarderConfigContent, err := comp.OTLPForwarderConfig()
 	if err != nil {
 		return err
 	}
 
 	activated := len(configContent) != 0
 	input.Values.Set("logShipper.internal.activated", activated)
+	input.Values.Set("logShipper.internal.kubernetesEventsEnabled", activated && comp.HasSourceType(v1alpha1.SourceKubernetesEvents))
+	input.Values.Set("logShipper.internal.otlpForwarderEnabled", activated && len(forwarderConfigContent) != 0)
 
 	if !activated {
 		input.PatchCollector.Delete(
@@ -143,6 +160,9 @@ func generateConfig(input *go_hook.HookInput) error {
 		},
 		Data: map[string][]byte{"vector.json": configContent},
 	}
+	if len(forwarderConfigContent) != 0 {
+		secret.Data["otlp-forwarder.json"] = forwarderConfigContent
+	}
 	input.PatchCollector.Create(secret, object_patch.UpdateIfExists())
 
 	event := &eventsv1.Event{
@@ -116,6 +116,18 @@ spec:
 
 			assert.JSONEq(GinkgoT(), string(goldenFileData), string(d))
 
+			forwarderGoldenFileData, err := os.ReadFile(filepath.Join(folder, "otlp-forwarder.json"))
+			if os.IsNotExist(err) {
+				Expect(secret.Field(`data`).Get("otlp-forwarder\\.json").Exists()).To(BeFalse())
+				Expect(f.ValuesGet("logShipper.internal.otlpForwarderEnabled").Bool()).To(BeFalse())
+			} else {
+				Expect(err).To(BeNil())
+				Expect(f.ValuesGet("logShipper.internal.otlpForwarderEnabled").Bool()).To(BeTrue())
+
+				forwarderConfig, _ := base64.StdEncoding.DecodeString(secret.Field(`data`).Get("otlp-forwarder\\.json").String())
+				assert.JSONEq(GinkgoT(), string(forwarderGoldenFileData), string(forwarderConfig))
+			}
+
 			f.BindingContexts.Set(f.KubeStateSet(namespaceManifest))
 			f.RunHook()
 
@@ -139,6 +151,15 @@ spec:
 		Entry("File to Kafka with client certificate authentication", "file-to-kafka-tls"),
 		Entry("File to Loki", "file-to-loki"),
 		Entry("File to Splunk", "file-to-splunk"),
+		Entry("File to OTLP", "file-to-otlp"),
+		Entry("Pods to OTLP/gRPC", "pods-to-otlp-grpc"),
+		Entry("File to Syslog", "file-to-syslog"),
+		Entry("Pods to GELF", "pods-to-gelf"),
+		Entry("Pods to S3", "pods-to-s3"),
+		Entry("Kubernetes events to Loki", "events-to-loki"),
+		Entry("Journald to Loki", "journald-to-loki"),
+		Entry("Redaction and sampling", "redaction-sampling"),
+		Entry("Parsers", "parsers"),
 		Entry("Two sources to single destination", "many-to-one"),
 		Entry("Throttle Transform with filter", "throttle-with-filter"),
 	)
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// pipeline-tester composes the vector config from log-shipper custom resources offline.
+//
+// Usage:
+//
+//	go run ./modules/460-log-shipper/hooks/internal/cmd/pipeline-tester -f manifests.yaml
+//
+// Prints the generated vector config. With the -validate flag, the config is also checked by the vector binary.
+//
+//	go run ./modules/460-log-shipper/hooks/internal/cmd/pipeline-tester -f manifests.yaml -samples lines.log -source my-config
+//
+// Pushes sample lines through the pipelines of the selected sources (all sources by default) with the vector binary
+// and prints every resulting event with the destination it would be sent to. Lines dropped by filters are not printed.
+// A sample line is either a message or a JSON object with the message field and metadata fields, e.g.:
+//
+//	{"message": "{\"level\":\"error\"}", "namespace": "default", "pod_labels": {"app": "nginx"}}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/hooks/internal/composer"
+)
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func main() {
+	var (
+		manifests stringsFlag
+		sources   stringsFlag
+		samples   string
+		vector    string
+		validate  bool
+	)
+
+	flag.Var(&manifests, "f", "file with manifests of ClusterLoggingConfig, PodLoggingConfig and ClusterLogDestination (can be repeated)")
+	flag.Var(&sources, "source", "name of the source to push samples through, PodLoggingConfig names are <namespace>_<name> (can be repeated)")
+	flag.StringVar(&samples, "samples", "", "file with sample lines to push through the pipelines, \"-\" for stdin")
+	flag.StringVar(&vector, "vector", "vector", "path to the vector binary")
+	flag.BoolVar(&validate, "validate", false, "validate the generated config with the vector binary")
+	flag.Parse()
+
+	if len(manifests) == 0 {
+		fmt.Fprintln(os.Stderr, "at least one file with manifests should be provided with the -f flag")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(manifests, sources, samples, vector, validate); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(manifests, sources []string, samples, vector string, validate bool) error {
+	comp := &composer.Composer{}
+	for _, path := range manifests {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		c, err := composer.FromManifests(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		comp.Source = append(comp.Source, c.Source...)
+		comp.Dest = append(comp.Dest, c.Dest...)
+	}
+
+	printMissingDestinations(comp)
+
+	if samples != "" {
+		return runSamples(comp, sources, samples, vector)
+	}
+
+	config, err := comp.Do()
+	if err != nil {
+		return fmt.Errorf("compose config: %v", err)
+	}
+	if len(config) == 0 {
+		return fmt.Errorf("the config is empty, no sources with existing destinations found")
+	}
+
+	fmt.Print(string(config))
+
+	if validate {
+		return runVector(vector, config, nil, "validate", "--no-environment")
+	}
+	return nil
+}
+
+func printMissingDestinations(comp *composer.Composer) {
+	missing := comp.MissingDestinations()
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "warning: source %q refers to undefined destinations %s, they are skipped\n", name, strings.Join(missing[name], ", "))
+	}
+}
+
+func runSamples(comp *composer.Composer, sources []string, samples, vector string) error {
+	if len(sources) > 0 {
+		selected := make([]v1alpha1.ClusterLoggingConfig, 0, len(sources))
+		for _, s := range comp.Source {
+			for _, name := range sources {
+				if s.Name == name {
+					selected = append(selected, s)
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("sources %s not found", strings.Join(sources, ", "))
+		}
+		comp.Source = selected
+	}
+
+	config, err := comp.DoSample()
+	if err != nil {
+		return fmt.Errorf("compose config: %v", err)
+	}
+	if len(config) == 0 {
+		return fmt.Errorf("the config is empty, no sources with existing destinations found")
+	}
+
+	input := os.Stdin
+	if samples != "-" {
+		input, err = os.Open(samples)
+		if err != nil {
+			return err
+		}
+		defer input.Close()
+	}
+
+	return runVector(vector, config, input, "--quiet")
+}
+
+// runVector runs the vector binary with the config stored to a temporary file.
+func runVector(vector string, config []byte, input *os.File, args ...string) error {
+	dir, err := os.MkdirTemp("", "log-shipper-pipeline-tester")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := dir + "/vector.json"
+	if err := os.WriteFile(configPath, config, 0600); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(vector, append(args, "--config-json", configPath)...)
+	cmd.Stdin = input
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run vector: %v", err)
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package composer
 
 import (
+	"encoding/json"
+
 	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
 
 	"github.com/deckhouse/deckhouse/go_lib/telemetry"
@@ -72,6 +74,16 @@ func customResourceMetric(input *go_hook.HookInput, kind, name, namespace, _type
 	})
 }
 
+// HasSourceType returns true if at least one log source of the given type is configured.
+func (c *Composer) HasSourceType(typ string) bool {
+	for _, s := range c.Source {
+		if s.Spec.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Composer) Do() ([]byte, error) {
 	destinationRefs, err := c.composeDestinations()
 	if err != nil {
@@ -87,6 +99,9 @@ func (c *Composer) Do() ([]byte, error) {
 			MultilineCustomConfig: s.Spec.MultiLineParser.Custom,
 			LabelFilter:           s.Spec.LabelFilters,
 			LogFilter:             s.Spec.LogFilters,
+			Parser:                s.Spec.Parser,
+			Redaction:             s.Spec.Redaction,
+			Sampling:              s.Spec.Sampling,
 		})
 		if err != nil {
 			return nil, err
@@ -121,6 +136,23 @@ func (c *Composer) Do() ([]byte, error) {
 	return file.ConvertToJSON()
 }
 
+// OTLPForwarderConfig returns the otlp-forwarder config of destinations using the OTLP/gRPC protocol,
+// the config is empty if there are no such destinations.
+func (c *Composer) OTLPForwarderConfig() ([]byte, error) {
+	destinations := make(map[string]destination.OTLPForwarderDestination)
+	for _, d := range c.Dest {
+		if d.Spec.Type == v1alpha1.DestOTLP && d.Spec.OTLP.Protocol == v1alpha1.OTLPProtocolGRPC {
+			destinations[d.Name] = destination.NewOTLPForwarderDestination(d.Spec.OTLP)
+		}
+	}
+
+	if len(destinations) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(map[string]interface{}{"destinations": destinations})
+}
+
 func (c *Composer) composeDestinations() (map[string]PipelineDestination, error) {
 	destinationByName := make(map[string]PipelineDestination)
 
@@ -147,6 +179,10 @@ func newLogSource(typ, name string, spec v1alpha1.ClusterLoggingConfigSpec) apis
 		return source.NewFile(name, spec.File)
 	case v1alpha1.SourceKubernetesPods:
 		return source.NewKubernetes(name, spec.KubernetesPods, false)
+	case v1alpha1.SourceKubernetesEvents:
+		return source.NewKubernetesEvents(name)
+	case v1alpha1.SourceJournald:
+		return source.NewJournald(name, spec.Journald)
 	}
 	return nil
 }
@@ -165,6 +201,14 @@ func newLogDest(typ, name string, spec v1alpha1.ClusterLogDestinationSpec) apis.
 		return destination.NewKafka(name, spec)
 	case v1alpha1.DestSplunk:
 		return destination.NewSplunk(name, spec)
+	case v1alpha1.DestOTLP:
+		return destination.NewOTLP(name, spec)
+	case v1alpha1.DestSyslog:
+		return destination.NewSyslog(name, spec)
+	case v1alpha1.DestGELF:
+		return destination.NewGELF(name, spec)
+	case v1alpha1.DestS3:
+		return destination.NewS3(name, spec)
 	}
 	return nil
 }
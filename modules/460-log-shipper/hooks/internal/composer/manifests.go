@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+// FromManifests creates a composer from ClusterLoggingConfig, PodLoggingConfig and ClusterLogDestination manifests.
+// Manifests can be in YAML or JSON format, YAML documents are separated by "---". Other kinds are ignored.
+func FromManifests(data []byte) (*Composer, error) {
+	res := &Composer{
+		Source: make([]v1alpha1.ClusterLoggingConfig, 0),
+		Dest:   make([]v1alpha1.ClusterLogDestination, 0),
+	}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode manifest: %v", err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &typeMeta); err != nil {
+			return nil, fmt.Errorf("decode manifest: %v", err)
+		}
+
+		switch typeMeta.Kind {
+		case "ClusterLoggingConfig":
+			var src v1alpha1.ClusterLoggingConfig
+			if err := json.Unmarshal(raw, &src); err != nil {
+				return nil, fmt.Errorf("decode ClusterLoggingConfig: %v", err)
+			}
+			res.Source = append(res.Source, src)
+
+		case "PodLoggingConfig":
+			var src v1alpha1.PodLoggingConfig
+			if err := json.Unmarshal(raw, &src); err != nil {
+				return nil, fmt.Errorf("decode PodLoggingConfig: %v", err)
+			}
+			res.Source = append(res.Source, v1alpha1.NamespacedToCluster(src))
+
+		case "ClusterLogDestination":
+			var dest v1alpha1.ClusterLogDestination
+			if err := json.Unmarshal(raw, &dest); err != nil {
+				return nil, fmt.Errorf("decode ClusterLogDestination: %v", err)
+			}
+			res.Dest = append(res.Dest, dest)
+		}
+	}
+
+	return res, nil
+}
+
+// MissingDestinations returns destination names referenced by sources but not defined, grouped by source name.
+// Such references are silently skipped while composing the config.
+func (c *Composer) MissingDestinations() map[string][]string {
+	defined := make(map[string]struct{}, len(c.Dest))
+	for _, d := range c.Dest {
+		defined[d.Name] = struct{}{}
+	}
+
+	missing := make(map[string][]string)
+	for _, s := range c.Source {
+		for _, ref := range s.Spec.DestinationRefs {
+			if _, ok := defined[ref]; !ok {
+				missing[s.Name] = append(missing[s.Name], ref)
+			}
+		}
+	}
+
+	return missing
+}
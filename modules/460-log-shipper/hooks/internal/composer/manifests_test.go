@@ -0,0 +1,75 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composer
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromManifests(t *testing.T) {
+	data, err := os.ReadFile("testdata/manifests.yaml")
+	require.NoError(t, err)
+
+	comp, err := FromManifests(data)
+	require.NoError(t, err)
+
+	require.Len(t, comp.Source, 2)
+	assert.Equal(t, "test-source", comp.Source[0].Name)
+	assert.Equal(t, "tests_app", comp.Source[1].Name)
+	assert.Equal(t, []string{"tests"}, comp.Source[1].Spec.KubernetesPods.NamespaceSelector.MatchNames)
+
+	require.Len(t, comp.Dest, 1)
+	assert.Equal(t, "test-loki", comp.Dest[0].Name)
+
+	assert.Equal(t, map[string][]string{"test-source": {"missing-dest"}}, comp.MissingDestinations())
+}
+
+func TestDoSample(t *testing.T) {
+	data, err := os.ReadFile("testdata/manifests.yaml")
+	require.NoError(t, err)
+
+	comp, err := FromManifests(data)
+	require.NoError(t, err)
+
+	content, err := comp.DoSample()
+	require.NoError(t, err)
+
+	var cfg sampleConfig
+	require.NoError(t, json.Unmarshal(content, &cfg))
+
+	assert.Equal(t, map[string]map[string]interface{}{sampleSourceName: {"type": "stdin"}}, cfg.Sources)
+
+	for name, transform := range cfg.Transforms {
+		if name == sampleDecodeName {
+			continue
+		}
+		for _, input := range transform["inputs"].([]interface{}) {
+			assert.NotContains(t, input, "cluster_logging_config/", "transform %s refers to the replaced source", name)
+		}
+	}
+
+	require.Len(t, cfg.Sinks, 1)
+	sink := cfg.Sinks["destination/cluster/test-loki"]
+	assert.Equal(t, "console", sink["type"])
+	assert.Equal(t, []interface{}{"sample/destination/cluster/test-loki"}, sink["inputs"])
+	assert.Contains(t, cfg.Transforms["sample/destination/cluster/test-loki"]["source"], `.destination = "destination/cluster/test-loki"`)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/hooks/internal/vrl"
+)
+
+const (
+	sampleSourceName        = "sample"
+	sampleDecodeName        = "sample_decode"
+	sampleDestinationPrefix = "sample/"
+)
+
+type sampleConfig struct {
+	Sources    map[string]map[string]interface{} `json:"sources"`
+	Transforms map[string]map[string]interface{} `json:"transforms"`
+	Sinks      map[string]map[string]interface{} `json:"sinks"`
+}
+
+// DoSample composes the config to test pipelines with sample events without sending them anywhere.
+//
+// All sources are replaced with the stdin source. Each line of the input is an event. If the line is a JSON object
+// with the message field, it is treated as a full event with metadata, e.g., namespace or pod labels.
+// Otherwise, the line is the message.
+//
+// All sinks are replaced with the console sink. Every output event is a JSON object with the destination field
+// containing the name of the sink the event would be sent to.
+func (c *Composer) DoSample() ([]byte, error) {
+	content, err := c.Do()
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	var cfg sampleConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("decode generated config: %v", err)
+	}
+	if cfg.Transforms == nil {
+		cfg.Transforms = make(map[string]map[string]interface{})
+	}
+
+	sources := make(map[string]struct{}, len(cfg.Sources))
+	for name := range cfg.Sources {
+		sources[name] = struct{}{}
+	}
+
+	replaceInputs := func(component map[string]interface{}) {
+		inputs, _ := component["inputs"].([]interface{})
+
+		replaced := make([]string, 0, len(inputs))
+		hasSample := false
+		for _, input := range inputs {
+			name, _ := input.(string)
+			if _, ok := sources[name]; ok {
+				if !hasSample {
+					replaced = append(replaced, sampleDecodeName)
+					hasSample = true
+				}
+				continue
+			}
+			replaced = append(replaced, name)
+		}
+		sort.Strings(replaced)
+
+		component["inputs"] = replaced
+	}
+
+	for _, t := range cfg.Transforms {
+		replaceInputs(t)
+	}
+
+	cfg.Sources = map[string]map[string]interface{}{
+		sampleSourceName: {"type": "stdin"},
+	}
+	cfg.Transforms[sampleDecodeName] = map[string]interface{}{
+		"type":          "remap",
+		"inputs":        []string{sampleSourceName},
+		"source":        vrl.SampleDecodeRule.String(),
+		"drop_on_abort": false,
+	}
+
+	sinks := make(map[string]map[string]interface{}, len(cfg.Sinks))
+	for name, sink := range cfg.Sinks {
+		replaceInputs(sink)
+
+		rule, err := vrl.SampleDestinationRule.Render(vrl.Args{"destination": name})
+		if err != nil {
+			return nil, err
+		}
+
+		transformName := sampleDestinationPrefix + name
+		cfg.Transforms[transformName] = map[string]interface{}{
+			"type":          "remap",
+			"inputs":        sink["inputs"],
+			"source":        rule,
+			"drop_on_abort": false,
+		}
+
+		sinks[name] = map[string]interface{}{
+			"type":     "console",
+			"inputs":   []string{transformName},
+			"encoding": map[string]interface{}{"codec": "json"},
+		}
+	}
+	cfg.Sinks = sinks
+
+	return json.MarshalIndent(cfg, "", "  ")
+}
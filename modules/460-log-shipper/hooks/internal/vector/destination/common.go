@@ -81,6 +81,15 @@ type CommonTLS struct {
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+type Framing struct {
+	Method             string                     `json:"method"`
+	CharacterDelimited *CharacterDelimitedFraming `json:"character_delimited,omitempty"`
+}
+
+type CharacterDelimitedFraming struct {
+	Delimiter string `json:"delimiter"`
+}
+
 type Buffer struct {
 	MaxSize   uint32 `json:"max_size,omitempty"`
 	Type      string `json:"type,omitempty"`
@@ -88,6 +97,40 @@ type Buffer struct {
 	WhenFull  string `json:"when_full,omitempty"`
 }
 
+// socketMode returns the mode of the socket sink, TCP is used by default
+func socketMode(mode v1alpha1.SocketMode) v1alpha1.SocketMode {
+	if mode == "" {
+		return v1alpha1.SocketModeTCP
+	}
+	return mode
+}
+
+// buildSocketTLS returns TLS settings for the socket sink, TLS is only supported in the TCP mode
+func buildSocketTLS(mode string, spec v1alpha1.CommonTLSSpec) *CommonTLS {
+	if mode == v1alpha1.SocketModeUDP {
+		return nil
+	}
+
+	tls := &CommonTLS{
+		CAFile:            decodeB64(spec.CAFile),
+		CertFile:          decodeB64(spec.CertFile),
+		KeyFile:           decodeB64(spec.KeyFile),
+		KeyPass:           decodeB64(spec.KeyPass),
+		VerifyCertificate: true,
+		VerifyHostname:    true,
+	}
+	if spec.VerifyCertificate != nil {
+		tls.VerifyCertificate = *spec.VerifyCertificate
+	}
+	if spec.VerifyHostname != nil {
+		tls.VerifyHostname = *spec.VerifyHostname
+	}
+	if len(tls.CAFile) > 0 || len(tls.CertFile) > 0 {
+		tls.Enabled = true
+	}
+	return tls
+}
+
 func decodeB64(input string) string {
 	res, _ := base64.StdEncoding.DecodeString(input)
 	return string(res)
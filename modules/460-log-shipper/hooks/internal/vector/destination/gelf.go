@@ -0,0 +1,70 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package destination
+
+import (
+	"strings"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+// GELF is the socket sink encoding events to the Graylog Extended Log Format.
+type GELF struct {
+	CommonSettings
+
+	Address string `json:"address"`
+
+	Mode string `json:"mode"`
+
+	Encoding Encoding `json:"encoding"`
+
+	Framing *Framing `json:"framing,omitempty"`
+
+	TLS *CommonTLS `json:"tls,omitempty"`
+}
+
+func NewGELF(name string, cspec v1alpha1.ClusterLogDestinationSpec) *GELF {
+	spec := cspec.GELF
+
+	mode := socketMode(spec.Mode)
+
+	var framing *Framing
+	if mode != v1alpha1.SocketModeUDP {
+		// GELF TCP messages are delimited by the null byte
+		framing = &Framing{
+			Method:             "character_delimited",
+			CharacterDelimited: &CharacterDelimitedFraming{Delimiter: "\x00"},
+		}
+	}
+
+	return &GELF{
+		CommonSettings: CommonSettings{
+			Name:   ComposeName(name),
+			Type:   "socket",
+			Inputs: set.New(),
+			Buffer: buildVectorBuffer(cspec.Buffer),
+		},
+		Address: spec.Endpoint,
+		Mode:    strings.ToLower(mode),
+		Encoding: Encoding{
+			Codec: "gelf",
+		},
+		Framing: framing,
+		TLS:     buildSocketTLS(mode, spec.TLS),
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package destination
+
+import (
+	"encoding/base64"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+const (
+	otlpLogsPath = "/v1/logs"
+	otlpGRPCPort = "4317"
+
+	// OTLPForwarderAddress is the address of the otlp-forwarder container of the agent Pod
+	OTLPForwarderAddress = "127.0.0.1:4318"
+	// otlpBatchField is added by the otlp transform to group events into batches
+	otlpBatchField = "otlp_batch"
+)
+
+// OTLP is the http sink sending events encoded to the OTLP JSON format by the otlp transform.
+type OTLP struct {
+	CommonSettings
+
+	URI string `json:"uri"`
+
+	Method string `json:"method"`
+
+	Encoding Encoding `json:"encoding"`
+
+	Framing *Framing `json:"framing"`
+
+	Batch OTLPBatch `json:"batch"`
+
+	Compression string `json:"compression,omitempty"`
+
+	Request OTLPRequest `json:"request"`
+
+	Auth *OTLPAuth `json:"auth,omitempty"`
+
+	TLS CommonTLS `json:"tls"`
+}
+
+type OTLPBatch struct {
+	MaxEvents int `json:"max_events"`
+}
+
+type OTLPRequest struct {
+	Headers map[string]string `json:"headers"`
+}
+
+type OTLPAuth struct {
+	Password string `json:"password,omitempty"`
+	Strategy string `json:"strategy"`
+	Token    string `json:"token,omitempty"`
+	User     string `json:"user,omitempty"`
+}
+
+func NewOTLP(name string, cspec v1alpha1.ClusterLogDestinationSpec) *OTLP {
+	spec := cspec.OTLP
+
+	if spec.Protocol == v1alpha1.OTLPProtocolGRPC {
+		return newOTLPForwarded(name, cspec)
+	}
+
+	tls := otlpTLS(spec.TLS)
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	for k, v := range spec.Headers {
+		headers[k] = v
+	}
+
+	var auth *OTLPAuth
+	if spec.Auth.Strategy != "" {
+		auth = &OTLPAuth{
+			User:     spec.Auth.User,
+			Token:    spec.Auth.Token,
+			Strategy: strings.ToLower(spec.Auth.Strategy),
+			Password: decodeB64(spec.Auth.Password),
+		}
+	}
+
+	return newOTLPSink(name, cspec, otlpLogsURI(spec.Endpoint), headers, auth, tls)
+}
+
+// newOTLPForwarded sends logs to the otlp-forwarder, which exports them using OTLP/gRPC.
+// Authentication, headers and TLS settings are applied by the forwarder.
+func newOTLPForwarded(name string, cspec v1alpha1.ClusterLogDestinationSpec) *OTLP {
+	uri := "http://" + OTLPForwarderAddress + "/" + name + otlpLogsPath
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	return newOTLPSink(name, cspec, uri, headers, nil, CommonTLS{VerifyCertificate: true, VerifyHostname: true})
+}
+
+func newOTLPSink(name string, cspec v1alpha1.ClusterLogDestinationSpec, uri string, headers map[string]string, auth *OTLPAuth, tls CommonTLS) *OTLP {
+	return &OTLP{
+		CommonSettings: CommonSettings{
+			Name:   ComposeName(name),
+			Type:   "http",
+			Inputs: set.New(),
+			Buffer: buildVectorBuffer(cspec.Buffer),
+		},
+		URI:    uri,
+		Method: "post",
+		Encoding: Encoding{
+			Codec:        "json",
+			ExceptFields: []string{otlpBatchField},
+		},
+		// The OTLP request is a single JSON object, so events cannot be sent as a JSON array.
+		// Every event is a batch of records merged by the otlp_batch transform.
+		Framing:     &Framing{Method: "bytes"},
+		Batch:       OTLPBatch{MaxEvents: 1},
+		Compression: "gzip",
+		Request:     OTLPRequest{Headers: headers},
+		Auth:        auth,
+		TLS:         tls,
+	}
+}
+
+// OTLPForwarderDestination is the otlp-forwarder config of a destination using the OTLP/gRPC protocol.
+type OTLPForwarderDestination struct {
+	// Endpoint is the host:port of the collector
+	Endpoint string            `json:"endpoint"`
+	Insecure bool              `json:"insecure,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+
+	CommonTLS
+}
+
+func NewOTLPForwarderDestination(spec v1alpha1.OTLPSpec) OTLPForwarderDestination {
+	dest := OTLPForwarderDestination{
+		Endpoint:  spec.Endpoint,
+		CommonTLS: otlpTLS(spec.TLS),
+	}
+
+	if u, err := url.Parse(spec.Endpoint); err == nil {
+		dest.Insecure = u.Scheme == "http"
+		dest.Endpoint = u.Host
+		if u.Port() == "" {
+			dest.Endpoint = net.JoinHostPort(u.Hostname(), otlpGRPCPort)
+		}
+	}
+
+	headers := make(map[string]string, len(spec.Headers)+1)
+	for k, v := range spec.Headers {
+		headers[k] = v
+	}
+
+	switch spec.Auth.Strategy {
+	case "Basic":
+		credentials := spec.Auth.User + ":" + decodeB64(spec.Auth.Password)
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	case "Bearer":
+		headers["Authorization"] = "Bearer " + spec.Auth.Token
+	}
+
+	if len(headers) > 0 {
+		dest.Headers = headers
+	}
+
+	return dest
+}
+
+func otlpTLS(spec v1alpha1.CommonTLSSpec) CommonTLS {
+	tls := CommonTLS{
+		CAFile:            decodeB64(spec.CAFile),
+		CertFile:          decodeB64(spec.CertFile),
+		KeyFile:           decodeB64(spec.KeyFile),
+		KeyPass:           decodeB64(spec.KeyPass),
+		VerifyCertificate: true,
+		VerifyHostname:    true,
+	}
+	if spec.VerifyCertificate != nil {
+		tls.VerifyCertificate = *spec.VerifyCertificate
+	}
+	if spec.VerifyHostname != nil {
+		tls.VerifyHostname = *spec.VerifyHostname
+	}
+
+	return tls
+}
+
+// otlpLogsURI adds the default OTLP/HTTP logs path to the endpoint without a path
+func otlpLogsURI(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Path != "" && u.Path != "/") {
+		return endpoint
+	}
+	u.Path = otlpLogsPath
+	return u.String()
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package destination
+
+import (
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+type S3 struct {
+	CommonSettings
+
+	Bucket string `json:"bucket"`
+
+	KeyPrefix string `json:"key_prefix,omitempty"`
+
+	Region string `json:"region,omitempty"`
+
+	Endpoint string `json:"endpoint,omitempty"`
+
+	Compression string `json:"compression"`
+
+	Encoding Encoding `json:"encoding"`
+
+	Framing *Framing `json:"framing"`
+
+	Batch *S3Batch `json:"batch,omitempty"`
+
+	Auth *S3Auth `json:"auth,omitempty"`
+
+	TLS CommonTLS `json:"tls"`
+}
+
+type S3Batch struct {
+	MaxBytes    uint64 `json:"max_bytes,omitempty"`
+	TimeoutSecs int32  `json:"timeout_secs,omitempty"`
+}
+
+type S3Auth struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+func NewS3(name string, cspec v1alpha1.ClusterLogDestinationSpec) *S3 {
+	spec := cspec.S3
+
+	tls := CommonTLS{
+		CAFile:            decodeB64(spec.TLS.CAFile),
+		CertFile:          decodeB64(spec.TLS.CertFile),
+		KeyFile:           decodeB64(spec.TLS.KeyFile),
+		KeyPass:           decodeB64(spec.TLS.KeyPass),
+		VerifyCertificate: true,
+		VerifyHostname:    true,
+	}
+	if spec.TLS.VerifyCertificate != nil {
+		tls.VerifyCertificate = *spec.TLS.VerifyCertificate
+	}
+	if spec.TLS.VerifyHostname != nil {
+		tls.VerifyHostname = *spec.TLS.VerifyHostname
+	}
+
+	encoding := Encoding{
+		Codec:           "json",
+		TimestampFormat: "rfc3339",
+	}
+	if spec.Encoding.Codec == v1alpha1.EncodingCodecText {
+		encoding = Encoding{
+			Codec:      "text",
+			OnlyFields: []string{"message"},
+		}
+	}
+
+	compression := toVectorValue(spec.Compression)
+	if compression == "" {
+		compression = toVectorValue(v1alpha1.S3CompressionGzip)
+	}
+
+	var batch *S3Batch
+	if spec.Batch.MaxSize != nil || spec.Batch.TimeoutSeconds != nil {
+		batch = &S3Batch{}
+		if spec.Batch.MaxSize != nil {
+			batch.MaxBytes = uint64(spec.Batch.MaxSize.Value())
+		}
+		if spec.Batch.TimeoutSeconds != nil {
+			batch.TimeoutSecs = *spec.Batch.TimeoutSeconds
+		}
+	}
+
+	var auth *S3Auth
+	if spec.Auth.AccessKeyID != "" {
+		auth = &S3Auth{
+			AccessKeyID:     decodeB64(spec.Auth.AccessKeyID),
+			SecretAccessKey: decodeB64(spec.Auth.SecretAccessKey),
+		}
+	}
+
+	return &S3{
+		CommonSettings: CommonSettings{
+			Name:   ComposeName(name),
+			Type:   "aws_s3",
+			Inputs: set.New(),
+			Buffer: buildVectorBuffer(cspec.Buffer),
+		},
+		Bucket:      spec.Bucket,
+		KeyPrefix:   spec.KeyPrefix,
+		Region:      spec.Region,
+		Endpoint:    spec.Endpoint,
+		Compression: compression,
+		Encoding:    encoding,
+		// Every event is written on a separate line of an object
+		Framing: &Framing{Method: "newline_delimited"},
+		Batch:   batch,
+		Auth:    auth,
+		TLS:     tls,
+	}
+}
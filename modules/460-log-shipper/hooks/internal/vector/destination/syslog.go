@@ -0,0 +1,67 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package destination
+
+import (
+	"strings"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+// Syslog is the socket sink sending messages formatted by the syslog transform.
+type Syslog struct {
+	CommonSettings
+
+	Address string `json:"address"`
+
+	Mode string `json:"mode"`
+
+	Encoding Encoding `json:"encoding"`
+
+	Framing *Framing `json:"framing,omitempty"`
+
+	TLS *CommonTLS `json:"tls,omitempty"`
+}
+
+func NewSyslog(name string, cspec v1alpha1.ClusterLogDestinationSpec) *Syslog {
+	spec := cspec.Syslog
+
+	mode := socketMode(spec.Mode)
+
+	var framing *Framing
+	if mode != v1alpha1.SocketModeUDP {
+		// Messages are prefixed with their length by the syslog transform (octet counting)
+		framing = &Framing{Method: "bytes"}
+	}
+
+	return &Syslog{
+		CommonSettings: CommonSettings{
+			Name:   ComposeName(name),
+			Type:   "socket",
+			Inputs: set.New(),
+			Buffer: buildVectorBuffer(cspec.Buffer),
+		},
+		Address: spec.Endpoint,
+		Mode:    strings.ToLower(mode),
+		Encoding: Encoding{
+			Codec: "text",
+		},
+		Framing: framing,
+		TLS:     buildSocketTLS(mode, spec.TLS),
+	}
+}
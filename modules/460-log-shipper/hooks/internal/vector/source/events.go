@@ -0,0 +1,50 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis"
+)
+
+// kubernetesEventsDir is the directory where the events-watcher sidecar writes Kubernetes events.
+// The directory is shared between the events-watcher and vector containers.
+const kubernetesEventsDir = "/var/run/log-shipper/events"
+
+var _ apis.LogSource = (*KubernetesEvents)(nil)
+
+// KubernetesEvents represents `file` vector source reading Kubernetes events.
+// Events are collected by the events-watcher sidecar (only the leader instance writes them)
+// and stored as JSON lines.
+type KubernetesEvents struct {
+	File
+}
+
+func NewKubernetesEvents(name string) *KubernetesEvents {
+	return &KubernetesEvents{
+		File: File{
+			commonSource: commonSource{
+				Name: "cluster_logging_config/" + name,
+				Type: "file",
+			},
+			Include: []string{kubernetesEventsDir + "/*.log"},
+		},
+	}
+}
+
+func (e *KubernetesEvents) BuildSources() []apis.LogSource {
+	return []apis.LogSource{e}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+var _ apis.LogSource = (*Journald)(nil)
+
+// Journald represents `journald` vector source
+// https://vector.dev/docs/reference/configuration/sources/journald/
+type Journald struct {
+	commonSource
+
+	IncludeUnits    []string `json:"include_units,omitempty"`
+	ExcludeUnits    []string `json:"exclude_units,omitempty"`
+	CurrentBootOnly bool     `json:"current_boot_only"`
+}
+
+func NewJournald(name string, spec v1alpha1.JournaldSpec) *Journald {
+	return &Journald{
+		commonSource: commonSource{
+			Name: "cluster_logging_config/" + name,
+			Type: "journald",
+		},
+		IncludeUnits:    spec.IncludeUnits,
+		ExcludeUnits:    spec.ExcludeUnits,
+		CurrentBootOnly: true,
+	}
+}
+
+func (j *Journald) BuildSources() []apis.LogSource {
+	return []apis.LogSource{j}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"fmt"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/hooks/internal/vrl"
+)
+
+// otlpResourceAttributes maps Kubernetes metadata of an event to OpenTelemetry resource attributes.
+var otlpResourceAttributes = map[string]string{
+	"container.image.name": "image",
+	"host.name":            "host",
+	"k8s.container.name":   "container",
+	"k8s.namespace.name":   "namespace",
+	"k8s.node.name":        "node",
+	"k8s.pod.ip":           "pod_ip",
+	"k8s.pod.name":         "pod",
+}
+
+const (
+	// otlpBatchField groups events sent in the same second into a single OTLP request, it is excluded from the request by the sink.
+	otlpBatchField = "otlp_batch"
+
+	otlpBatchTimeout = 1000 // ms
+)
+
+// OTLPTransform converts events to the OTLP JSON format. It must be the last transform of a destination,
+// because the original fields of the event are not available after it.
+func OTLPTransform(extraLabels map[string]string) (*DynamicTransform, error) {
+	labels := make([]map[string]string, 0, len(extraLabels))
+	for _, k := range mapKeys(extraLabels) {
+		labels = append(labels, map[string]string{"key": k, "field": escapeVectorString(k)})
+	}
+
+	source, err := vrl.OTLPRule.Render(vrl.Args{
+		"resource": otlpResourceAttributes,
+		"labels":   labels,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render otlp rule: %v", err)
+	}
+
+	return &DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "otlp",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        source,
+			"drop_on_abort": false,
+		},
+	}, nil
+}
+
+// OTLPBatchTransform merges OTLP envelopes of events sent in the same second, so a request is sent per batch instead of a request per event.
+// The http sink can only send events as a JSON array, which is not a valid OTLP request.
+func OTLPBatchTransform() *DynamicTransform {
+	return &DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "otlp_batch",
+			Type:   "reduce",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"group_by": []string{otlpBatchField},
+			"merge_strategies": map[string]string{
+				"resourceLogs": "concat",
+			},
+			"expire_after_ms": otlpBatchTimeout,
+			"flush_period_ms": otlpBatchTimeout,
+		},
+	}
+}
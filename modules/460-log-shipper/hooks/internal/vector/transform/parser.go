@@ -0,0 +1,144 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/hooks/internal/vrl"
+)
+
+// parseFunctions are VRL functions to parse the message for each parser type.
+var parseFunctions = map[v1alpha1.ParserType]string{
+	v1alpha1.ParserTypeLogfmt:         `parse_logfmt(.message)`,
+	v1alpha1.ParserTypeKlog:           `parse_klog(.message)`,
+	v1alpha1.ParserTypeNginxCombined:  `parse_nginx_log(.message, format: "combined")`,
+	v1alpha1.ParserTypeApacheCombined: `parse_apache_log(.message, format: "combined")`,
+	v1alpha1.ParserTypeSyslog:         `parse_syslog(.message)`,
+}
+
+func CreateParserTransforms(parser v1alpha1.Parser) ([]apis.LogTransform, error) {
+	var parse string
+
+	switch parser.Type {
+	case "", v1alpha1.ParserTypeNone:
+		return []apis.LogTransform{}, nil
+	case v1alpha1.ParserTypeCRI:
+		return createCRITransforms(), nil
+	case v1alpha1.ParserTypeRegex:
+		if err := validateParserRegex(parser.Regex); err != nil {
+			return nil, err
+		}
+		parse = fmt.Sprintf("parse_regex(.message, r'%s')", parser.Regex)
+	default:
+		var ok bool
+		parse, ok = parseFunctions[parser.Type]
+		if !ok {
+			return nil, fmt.Errorf("unknown parser type %q", parser.Type)
+		}
+	}
+
+	rule, err := vrl.ParserRule.Render(vrl.Args{"parse": parse})
+	if err != nil {
+		return nil, err
+	}
+
+	return []apis.LogTransform{&DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "parser",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        rule,
+			"drop_on_abort": false,
+		},
+	}}, nil
+}
+
+func validateParserRegex(regex string) error {
+	if regex == "" {
+		return fmt.Errorf("parser.regex should be provided for the Regex parser")
+	}
+
+	if err := validateRawString(regex); err != nil {
+		return fmt.Errorf("invalid parser.regex: %v", err)
+	}
+
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return fmt.Errorf("invalid parser.regex: %v", err)
+	}
+
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("parser.regex should contain at least one named capture group")
+}
+
+// createCRITransforms removes the CRI prefix and merges partial lines, which container runtimes split by 16KiB.
+func createCRITransforms() []apis.LogTransform {
+	return []apis.LogTransform{
+		&DynamicTransform{
+			CommonTransform: CommonTransform{
+				Name:   "cri",
+				Type:   "remap",
+				Inputs: set.New(),
+			},
+			DynamicArgsMap: map[string]interface{}{
+				"source":        vrl.CRIRule.String(),
+				"drop_on_abort": false,
+			},
+		},
+		&DynamicTransform{
+			CommonTransform: CommonTransform{
+				Name:   "cri_merge",
+				Type:   "reduce",
+				Inputs: set.New(),
+			},
+			DynamicArgsMap: map[string]interface{}{
+				"group_by": []string{
+					"file",
+					"stream",
+				},
+				"merge_strategies": map[string]string{
+					"message": "concat_raw",
+					"partial": "retain",
+				},
+				"ends_when": vrl.CRIMergeRule.String(),
+			},
+		},
+		&DynamicTransform{
+			CommonTransform: CommonTransform{
+				Name:   "cri_clean_up",
+				Type:   "remap",
+				Inputs: set.New(),
+			},
+			DynamicArgsMap: map[string]interface{}{
+				"source":        vrl.CRICleanUpRule.String(),
+				"drop_on_abort": false,
+			},
+		},
+	}
+}
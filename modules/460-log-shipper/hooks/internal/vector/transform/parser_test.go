@@ -0,0 +1,93 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+func TestCreateParserTransforms(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  v1alpha1.Parser
+		count   int
+		source  string
+		wantErr string
+	}{
+		{
+			name:   "none",
+			parser: v1alpha1.Parser{Type: v1alpha1.ParserTypeNone},
+		},
+		{
+			name:   "klog",
+			parser: v1alpha1.Parser{Type: v1alpha1.ParserTypeKlog},
+			count:  1,
+			source: "structured, err = parse_klog(.message)",
+		},
+		{
+			name:   "regex",
+			parser: v1alpha1.Parser{Type: v1alpha1.ParserTypeRegex, Regex: `^(?P<level>\w+): (?P<text>.*)$`},
+			count:  1,
+			source: `structured, err = parse_regex(.message, r'^(?P<level>\w+): (?P<text>.*)$')`,
+		},
+		{
+			name:   "cri",
+			parser: v1alpha1.Parser{Type: v1alpha1.ParserTypeCRI},
+			count:  3,
+		},
+		{
+			name:    "regex::empty",
+			parser:  v1alpha1.Parser{Type: v1alpha1.ParserTypeRegex},
+			wantErr: "parser.regex should be provided for the Regex parser",
+		},
+		{
+			name:    "regex::no::named::groups",
+			parser:  v1alpha1.Parser{Type: v1alpha1.ParserTypeRegex, Regex: `^(\w+): (.*)$`},
+			wantErr: "parser.regex should contain at least one named capture group",
+		},
+		{
+			name:    "regex::single::quote",
+			parser:  v1alpha1.Parser{Type: v1alpha1.ParserTypeRegex, Regex: `^(?P<text>.*)') + del(.message) + r'$`},
+			wantErr: "invalid parser.regex: single quotes are not allowed",
+		},
+		{
+			name:    "unknown",
+			parser:  v1alpha1.Parser{Type: "Unknown"},
+			wantErr: `unknown parser type "Unknown"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transforms, err := CreateParserTransforms(tt.parser)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, transforms, tt.count)
+
+			if tt.source != "" {
+				assert.Contains(t, transforms[0].(*DynamicTransform).DynamicArgsMap["source"], tt.source)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/hooks/internal/vrl"
+)
+
+// redactionPatterns are regular expressions for built-in patterns of sensitive data.
+var redactionPatterns = map[v1alpha1.RedactionPattern]string{
+	v1alpha1.RedactionPatternEmail:       `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`,
+	v1alpha1.RedactionPatternCreditCard:  `\b(?:\d[ -]?){12,18}\d\b`,
+	v1alpha1.RedactionPatternBearerToken: `(?i)bearer\s+[a-z0-9._~+/=-]+`,
+}
+
+// validDropField is a path to a field of the parsed message, the same as in extraLabels templates.
+var validDropField = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9\[\]_\\\-\.]*$`)
+
+// defaultSamplingKeepLevels are levels of messages that are never sampled out if levels are not specified.
+var defaultSamplingKeepLevels = []string{"emerg", "alert", "crit", "critical", "err", "error", "fatal", "panic"}
+
+func CreateRedactionTransforms(redaction v1alpha1.Redaction) ([]apis.LogTransform, error) {
+	patterns := make([]string, 0, len(redaction.Patterns)+len(redaction.Custom))
+	for _, pattern := range redaction.Patterns {
+		if regex, ok := redactionPatterns[pattern]; ok {
+			patterns = append(patterns, regex)
+		}
+	}
+	for _, pattern := range redaction.Custom {
+		if err := validateRawString(pattern); err != nil {
+			return nil, fmt.Errorf("invalid redaction.custom pattern %q: %v", pattern, err)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid redaction.custom pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	dropFields := make([]string, 0, len(redaction.DropFields))
+	for _, field := range redaction.DropFields {
+		if !validDropField.MatchString(field) || strings.Contains(field, "..") || strings.HasSuffix(field, ".") {
+			return nil, fmt.Errorf("invalid redaction.dropFields field %q", field)
+		}
+		dropFields = append(dropFields, generateDataField(field))
+	}
+
+	if len(patterns) == 0 && len(dropFields) == 0 {
+		return []apis.LogTransform{}, nil
+	}
+
+	rule, err := vrl.RedactionRule.Render(vrl.Args{
+		"patterns":   patterns,
+		"dropFields": dropFields,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []apis.LogTransform{&DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "redaction",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        rule,
+			"drop_on_abort": false,
+		},
+	}}, nil
+}
+
+// validateRawString checks that the string can be put into a VRL raw string literal.
+func validateRawString(s string) error {
+	if strings.Contains(s, "'") {
+		return fmt.Errorf("single quotes are not allowed")
+	}
+	return nil
+}
+
+func CreateSamplingTransforms(sampling v1alpha1.Sampling) ([]apis.LogTransform, error) {
+	if sampling.Rate < 2 {
+		return []apis.LogTransform{}, nil
+	}
+
+	levels := defaultSamplingKeepLevels
+	if len(sampling.AlwaysKeepLevels) > 0 {
+		levels = make([]string, 0, len(sampling.AlwaysKeepLevels))
+		for _, level := range sampling.AlwaysKeepLevels {
+			levels = append(levels, strings.ToLower(level))
+		}
+	}
+
+	exclude, err := vrl.SamplingExcludeRule.Render(vrl.Args{"levels": levels})
+	if err != nil {
+		return nil, err
+	}
+
+	args := map[string]interface{}{
+		"rate":    sampling.Rate,
+		"exclude": exclude,
+	}
+	if sampling.KeyField != "" {
+		// the key field is a field of the parsed message, the same as for log filters
+		args["key_field"] = "parsed_data." + sampling.KeyField
+	}
+
+	return []apis.LogTransform{&DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "sampling",
+			Type:   "sample",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: args,
+	}}, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/apis/v1alpha1"
+)
+
+func TestCreateRedactionTransforms(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		transforms, err := CreateRedactionTransforms(v1alpha1.Redaction{})
+		require.NoError(t, err)
+		assert.Empty(t, transforms)
+	})
+
+	t.Run("patterns and fields", func(t *testing.T) {
+		transforms, err := CreateRedactionTransforms(v1alpha1.Redaction{
+			Patterns:   []v1alpha1.RedactionPattern{v1alpha1.RedactionPatternEmail},
+			Custom:     []string{`password=\S+`},
+			DropFields: []string{"user.password"},
+		})
+		require.NoError(t, err)
+		require.Len(t, transforms, 1)
+
+		source := transforms[0].(*DynamicTransform).DynamicArgsMap["source"].(string)
+		assert.Contains(t, source, "del(.parsed_data.user.password)")
+		assert.Contains(t, source, `filters: [r'[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}', r'password=\S+']`)
+	})
+
+	t.Run("escaped fields", func(t *testing.T) {
+		transforms, err := CreateRedactionTransforms(v1alpha1.Redaction{
+			DropFields: []string{`request.x-api-key`, `pay\.load.token`, `items[0]`},
+		})
+		require.NoError(t, err)
+		require.Len(t, transforms, 1)
+
+		source := transforms[0].(*DynamicTransform).DynamicArgsMap["source"].(string)
+		assert.Contains(t, source, `del(.parsed_data.request."x-api-key")`)
+		assert.Contains(t, source, `del(.parsed_data."pay.load".token)`)
+		assert.Contains(t, source, `del(.parsed_data.items[0])`)
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		for _, field := range []string{"", "user) ?? del(.message", `user "name"`, "user..password", "user."} {
+			_, err := CreateRedactionTransforms(v1alpha1.Redaction{DropFields: []string{field}})
+			assert.ErrorContains(t, err, "invalid redaction.dropFields field", field)
+		}
+	})
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		_, err := CreateRedactionTransforms(v1alpha1.Redaction{Custom: []string{`password='\S+'`}})
+		assert.ErrorContains(t, err, "single quotes are not allowed")
+
+		_, err = CreateRedactionTransforms(v1alpha1.Redaction{Custom: []string{`password=(\S+`}})
+		assert.ErrorContains(t, err, "invalid redaction.custom pattern")
+	})
+}
+
+func TestCreateSamplingTransforms(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		transforms, err := CreateSamplingTransforms(v1alpha1.Sampling{Rate: 1})
+		require.NoError(t, err)
+		assert.Empty(t, transforms)
+	})
+
+	t.Run("custom levels and key", func(t *testing.T) {
+		transforms, err := CreateSamplingTransforms(v1alpha1.Sampling{
+			Rate:             10,
+			KeyField:         "trace_id",
+			AlwaysKeepLevels: []string{"ERROR", "Warn"},
+		})
+		require.NoError(t, err)
+		require.Len(t, transforms, 1)
+
+		args := transforms[0].(*DynamicTransform).DynamicArgsMap
+		assert.Equal(t, int32(10), args["rate"])
+		assert.Equal(t, "parsed_data.trace_id", args["key_field"])
+		assert.Contains(t, args["exclude"], `includes(["error","warn"]`)
+	})
+}
@@ -67,6 +67,34 @@ func LocalTimezoneAfterSourceTransform() *DynamicTransform {
 	}
 }
 
+func KubernetesEventsSourceTransform() *DynamicTransform {
+	return &DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "kubernetes_events",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        vrl.KubernetesEventsRule.String(),
+			"drop_on_abort": false,
+		},
+	}
+}
+
+func JournaldSourceTransform() *DynamicTransform {
+	return &DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "journald",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        vrl.JournaldRule.String(),
+			"drop_on_abort": false,
+		},
+	}
+}
+
 type LogSourceConfig struct {
 	SourceType string
 
@@ -74,18 +102,36 @@ type LogSourceConfig struct {
 	MultilineCustomConfig v1alpha1.MultilineParserCustom
 	LabelFilter           []v1alpha1.Filter
 	LogFilter             []v1alpha1.Filter
+	Parser                v1alpha1.Parser
+	Redaction             v1alpha1.Redaction
+	Sampling              v1alpha1.Sampling
 }
 
 func CreateLogSourceTransforms(name string, cfg *LogSourceConfig) ([]apis.LogTransform, error) {
 	var transforms []apis.LogTransform
 
-	if cfg.SourceType == v1alpha1.SourceKubernetesPods {
+	switch cfg.SourceType {
+	case v1alpha1.SourceKubernetesPods:
 		transforms = append(transforms, OwnerReferenceSourceTransform())
+	case v1alpha1.SourceKubernetesEvents:
+		transforms = append(transforms, KubernetesEventsSourceTransform())
+	case v1alpha1.SourceJournald:
+		transforms = append(transforms, JournaldSourceTransform())
 	}
 
 	transforms = append(transforms, CleanUpAfterSourceTransform())
 	transforms = append(transforms, LocalTimezoneAfterSourceTransform())
 
+	parserTransforms, err := CreateParserTransforms(cfg.Parser)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering parser transforms: %v", err)
+	}
+
+	// CRI lines should be unwrapped before joining multiline messages, other formats are parsed after
+	if cfg.Parser.Type == v1alpha1.ParserTypeCRI {
+		transforms = append(transforms, parserTransforms...)
+	}
+
 	multilineTransforms, err := CreateMultiLineTransforms(cfg.MultilineType, cfg.MultilineCustomConfig)
 	if err != nil {
 		return nil, fmt.Errorf("error rendering multi line transforms: %v", err)
@@ -93,6 +139,10 @@ func CreateLogSourceTransforms(name string, cfg *LogSourceConfig) ([]apis.LogTra
 
 	transforms = append(transforms, multilineTransforms...)
 
+	if cfg.Parser.Type != v1alpha1.ParserTypeCRI {
+		transforms = append(transforms, parserTransforms...)
+	}
+
 	labelFilterTransforms, err := CreateLabelFilterTransforms(cfg.LabelFilter)
 	if err != nil {
 		return nil, err
@@ -105,6 +155,23 @@ func CreateLogSourceTransforms(name string, cfg *LogSourceConfig) ([]apis.LogTra
 	}
 	transforms = append(transforms, logFilterTransforms...)
 
+	redactionTransforms, err := CreateRedactionTransforms(cfg.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering redaction transforms: %v", err)
+	}
+
+	samplingTransforms, err := CreateSamplingTransforms(cfg.Sampling)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering sampling transforms: %v", err)
+	}
+
+	// log filters already provide the parsed message
+	if len(logFilterTransforms) == 0 && len(redactionTransforms)+len(samplingTransforms) > 0 {
+		transforms = append(transforms, CreateParseDataTransforms())
+	}
+	transforms = append(transforms, redactionTransforms...)
+	transforms = append(transforms, samplingTransforms...)
+
 	sTransforms, err := BuildFromMapSlice("source", name, transforms)
 	if err != nil {
 		return nil, fmt.Errorf("add source transforms: %v", err)
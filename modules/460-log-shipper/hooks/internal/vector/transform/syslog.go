@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deckhouse/deckhouse/go_lib/set"
+	"github.com/deckhouse/deckhouse/modules/460-log-shipper/hooks/internal/vrl"
+)
+
+// syslogSDID is the ID of the structured data element with Kubernetes metadata and extra labels.
+const syslogSDID = "k8s@32473"
+
+// syslogMetadataParams are Kubernetes metadata fields sent as structured data parameters.
+var syslogMetadataParams = []string{"namespace", "pod", "pod_ip", "pod_owner", "container", "image", "node", "stream"}
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "ntp": 12, "security": 13, "console": 14, "solaris-cron": 15,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogTransform formats events as RFC5424 messages with the specified facility.
+// It must be the last transform of a destination, because only the message field is left in the event.
+func SyslogTransform(facility string, octetCounting bool, extraLabels map[string]string) (*DynamicTransform, error) {
+	code, ok := syslogFacilities[facility]
+	if !ok {
+		code = syslogFacilities["user"]
+	}
+
+	params := make([]map[string]string, 0, len(syslogMetadataParams)+len(extraLabels))
+	for _, field := range syslogMetadataParams {
+		params = append(params, map[string]string{"name": field, "field": field})
+	}
+	for _, k := range mapKeys(extraLabels) {
+		params = append(params, map[string]string{"name": syslogParamName(k), "field": escapeVectorString(k)})
+	}
+
+	rule, err := vrl.SyslogRule.Render(vrl.Args{
+		"facility":      code,
+		"octetCounting": octetCounting,
+		"params":        params,
+		"sdID":          syslogSDID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render syslog rule: %v", err)
+	}
+
+	return &DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "syslog",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        vrl.Combine(vrl.SeverityRule, vrl.Rule(rule)).String(),
+			"drop_on_abort": false,
+		},
+	}, nil
+}
+
+// GELFTransform sets the level of events and flattens them to be accepted by the GELF encoder.
+func GELFTransform() *DynamicTransform {
+	return &DynamicTransform{
+		CommonTransform: CommonTransform{
+			Name:   "gelf",
+			Type:   "remap",
+			Inputs: set.New(),
+		},
+		DynamicArgsMap: map[string]interface{}{
+			"source":        vrl.Combine(vrl.SeverityRule, vrl.GELFRule).String(),
+			"drop_on_abort": false,
+		},
+	}
+}
+
+// syslogParamName replaces characters not allowed in structured data parameter names, see RFC5424 section 6.3.3
+func syslogParamName(name string) string {
+	res := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if len(res) > 32 {
+		res = res[:32]
+	}
+	return res
+}
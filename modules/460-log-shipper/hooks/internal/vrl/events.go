@@ -0,0 +1,32 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrl
+
+// KubernetesEventsRule parses events written by the events-watcher sidecar.
+// Event fields are placed on the top level, the message field is replaced with the event message.
+const KubernetesEventsRule Rule = `
+structured, err = parse_json(.message)
+if err == null && is_object(structured) {
+    . = merge!(., structured)
+    if exists(.timestamp) {
+        parsed_timestamp, err = parse_timestamp(.timestamp, format: "%+")
+        if err == null {
+            .timestamp = parsed_timestamp
+        }
+    }
+}
+`
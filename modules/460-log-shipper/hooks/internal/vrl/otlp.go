@@ -0,0 +1,71 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrl
+
+// OTLPRule wraps an event into an OTLP/HTTP JSON logs request.
+// Kubernetes metadata is mapped to resource attributes according to the OpenTelemetry semantic conventions,
+// extra labels are added as resource attributes with their own names.
+//
+// There is a single record in the envelope, envelopes are merged into batches by the reduce transform grouping them by the otlp_batch field.
+const OTLPRule Rule = `
+resource = []
+{{- range $attribute, $field := .resource }}
+if exists(.{{ $field }}) {
+    resource = push(resource, {"key": "{{ $attribute }}", "value": {"stringValue": to_string(.{{ $field }}) ?? encode_json(.{{ $field }})}})
+}
+{{- end }}
+if is_object(.pod_labels) {
+    for_each(object!(.pod_labels)) -> |key, value| {
+        resource = push(resource, {"key": "k8s.pod.label." + key, "value": {"stringValue": to_string(value) ?? encode_json(value)}})
+    }
+}
+{{- range $label := .labels }}
+if exists(.{{ $label.field }}) {
+    resource = push(resource, {"key": {{ $label.key | quote }}, "value": {"stringValue": to_string(.{{ $label.field }}) ?? encode_json(.{{ $label.field }})}})
+}
+{{- end }}
+
+attributes = []
+if exists(.stream) {
+    attributes = push(attributes, {"key": "log.iostream", "value": {"stringValue": to_string(.stream) ?? ""}})
+}
+
+body = ""
+if is_string(.message) {
+    body = string!(.message)
+} else if exists(.message) {
+    body = encode_json(.message)
+}
+
+ts = parse_timestamp(.timestamp, format: "%+") ?? now()
+
+. = {
+    "resourceLogs": [{
+        "resource": {"attributes": resource},
+        "scopeLogs": [{
+            "scope": {"name": "log-shipper"},
+            "logRecords": [{
+                "timeUnixNano": to_string(to_unix_timestamp(ts, unit: "nanoseconds")),
+                "observedTimeUnixNano": to_string(to_unix_timestamp(now(), unit: "nanoseconds")),
+                "body": {"stringValue": body},
+                "attributes": attributes
+            }]
+        }]
+    }],
+    "otlp_batch": to_string(to_unix_timestamp(now()))
+}
+`
@@ -0,0 +1,55 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrl
+
+// ParserRule extracts fields from the message with the parse function.
+// Parsed fields are stored the same way as parsed JSON data to be used in filters and labels.
+// The message remains untouched, and JSON parsing is tried later if the message does not match the format.
+const ParserRule Rule = `
+if !exists(.parsed_data) {
+    structured, err = {{ $.parse }}
+    if err == null {
+        .parsed_data = structured
+    }
+}
+`
+
+// CRIRule parses lines of the CRI log format written by container runtimes.
+// The log line prefix is removed, and the partial flag marks lines to be merged with the following ones.
+//
+// Example:
+// ---
+// 2023-01-01T00:00:00.000000000Z stdout P first part of a long line
+// 2023-01-01T00:00:00.000000000Z stdout F and the end
+const CRIRule Rule = `
+structured, err = parse_regex(.message, r'^(?P<timestamp>\S+) (?P<stream>stdout|stderr) (?P<tag>[FP]) (?P<message>.*)$')
+if err == null {
+    .message = structured.message
+    .stream = structured.stream
+    .partial = structured.tag == "P"
+}
+`
+
+// CRIMergeRule is a condition for the last part of a CRI log line.
+const CRIMergeRule Rule = `
+.partial != true
+`
+
+// CRICleanUpRule removes the partial flag after merging lines.
+const CRICleanUpRule Rule = `
+del(.partial)
+`
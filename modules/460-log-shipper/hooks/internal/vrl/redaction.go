@@ -0,0 +1,46 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrl
+
+// RedactionRule removes fields from the parsed message and masks sensitive data matching the patterns.
+// The message is rebuilt from the parsed data to make the changes visible for all destinations.
+const RedactionRule Rule = `
+{{- if $.dropFields }}
+if is_object(.parsed_data) {
+{{- range $field := $.dropFields }}
+    del(.parsed_data.{{ $field }})
+{{- end }}
+}
+{{- end }}
+{{- if $.patterns }}
+redacted, err = redact(.parsed_data, filters: [{{ range $index, $pattern := $.patterns }}{{ if ne $index 0 }}, {{ end }}r'{{ $pattern }}'{{ end }}])
+if err == null {
+    .parsed_data = redacted
+}
+{{- end }}
+if is_string(.parsed_data) {
+    .message = .parsed_data
+} else {
+    .message = encode_json(.parsed_data)
+}
+`
+
+// SamplingExcludeRule is a condition for messages that should never be sampled out.
+// The level is taken from the level or severity field of the parsed message.
+const SamplingExcludeRule Rule = `
+includes({{ $.levels | toJson }}, downcase(string(.parsed_data.level) ?? string(.parsed_data.severity) ?? ""))
+`
@@ -0,0 +1,31 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrl
+
+// SampleDecodeRule converts sample lines to events to test pipelines.
+// JSON objects with the message field are full events, other lines are messages.
+const SampleDecodeRule Rule = `
+structured, err = parse_json(.message)
+if err == null && is_object(structured) && is_string(structured.message) {
+    . = merge(., object!(structured))
+}
+`
+
+// SampleDestinationRule adds the name of the destination to test pipelines.
+const SampleDestinationRule Rule = `
+.destination = "{{ $.destination }}"
+`
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vrl
+
+// SeverityRule detects the syslog severity of a record by the level of a JSON log message.
+// The informational severity is used if the level is not recognized.
+const SeverityRule Rule = `
+if !exists(.parsed_data) {
+    structured, err = parse_json(.message)
+    if err == null {
+        .parsed_data = structured
+    } else {
+        .parsed_data = .message
+    }
+}
+
+level = .parsed_data.level
+if level == null {
+    level = .parsed_data.severity
+}
+level = downcase(to_string(level) ?? "")
+
+severity = 6
+if starts_with(level, "emerg") || level == "panic" {
+    severity = 0
+} else if level == "alert" {
+    severity = 1
+} else if starts_with(level, "crit") || level == "fatal" {
+    severity = 2
+} else if starts_with(level, "err") {
+    severity = 3
+} else if starts_with(level, "warn") {
+    severity = 4
+} else if level == "notice" {
+    severity = 5
+} else if starts_with(level, "debug") || level == "trace" {
+    severity = 7
+}
+`
+
+// SyslogRule formats a record as an RFC5424 message.
+// Kubernetes metadata and extra labels are sent as parameters of the structured data element.
+// If octet counting is enabled, the message length is prepended as described in RFC6587.
+const SyslogRule Rule = `
+hostname = "-"
+if is_string(.node) {
+    hostname = string!(.node)
+} else if is_string(.host) {
+    hostname = string!(.host)
+}
+
+appname = "-"
+if is_string(.container) {
+    appname = truncate(string!(.container), limit: 48)
+}
+
+procid = "-"
+if is_string(.pod) {
+    procid = string!(.pod)
+}
+
+sd = ""
+{{- range $param := .params }}
+if exists(.{{ $param.field }}) {
+    value = to_string(.{{ $param.field }}) ?? encode_json(.{{ $param.field }})
+    value = replace(replace(replace(value, "\\", "\\\\"), "\"", "\\\""), "]", "\\]")
+    sd = sd + " {{ $param.name }}=\"" + value + "\""
+}
+{{- end }}
+if sd == "" {
+    sd = "-"
+} else {
+    sd = "[{{ .sdID }}" + sd + "]"
+}
+
+body = ""
+if is_string(.message) {
+    body = string!(.message)
+} else if exists(.message) {
+    body = encode_json(.message)
+}
+
+ts = parse_timestamp(.timestamp, format: "%+") ?? now()
+timestamp = format_timestamp!(ts, format: "%Y-%m-%dT%H:%M:%S%.6f%:z")
+
+pri = {{ .facility }} * 8 + severity
+line = "<" + to_string(pri) + ">1 " + timestamp + " " + hostname + " " + appname + " " + procid + " - " + sd + " " + body
+{{- if .octetCounting }}
+line = to_string(length(line)) + " " + line
+{{- end }}
+
+. = {"message": line}
+`
+
+// GELFRule prepares a record for the GELF encoder, which only accepts flat objects with string or number values.
+// Pod labels are flattened, other nested objects are encoded to JSON strings.
+const GELFRule Rule = `
+.level = severity
+del(.parsed_data)
+
+if !exists(.short_message) {
+    .short_message = del(.message)
+}
+
+if !is_string(.host) {
+    .host = "-"
+    if is_string(.node) {
+        .host = .node
+    }
+}
+
+.timestamp = parse_timestamp(.timestamp, format: "%+") ?? now()
+
+if is_object(.pod_labels) {
+    labels = {}
+    for_each(object!(.pod_labels)) -> |key, value| {
+        labels = set!(labels, ["pod_labels_" + replace(key, r'[^\w\.\-]', "_")], value)
+    }
+    del(.pod_labels)
+    . = merge(., labels)
+}
+
+. = map_values(.) -> |value| {
+    if is_object(value) || is_array(value) {
+        encode_json(value)
+    } else if is_boolean(value) {
+        to_string!(value)
+    } else {
+        value
+    }
+}
+`
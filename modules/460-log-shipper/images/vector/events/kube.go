@@ -0,0 +1,242 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	requestTimeout    = 30 * time.Second
+)
+
+var errNotFound = errors.New("not found")
+
+// errExpired is returned when the resource version of a watch is too old, the events must be listed again.
+var errExpired = errors.New("resource version expired")
+
+// kubeClient is a minimal client of the Kubernetes API using the credentials of the service account.
+type kubeClient struct {
+	host      string
+	tokenFile string
+	client    *http.Client
+}
+
+func newKubeClient() (*kubeClient, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no certificates found in ca.crt")
+	}
+
+	return &kubeClient{
+		host:      "https://" + net.JoinHostPort(host, port),
+		tokenFile: serviceAccountDir + "/token",
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			},
+		},
+	}, nil
+}
+
+// do sends a request and decodes the response to out.
+func (c *kubeClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// request sends a request to the API server. The token is read on every request, because it is rotated.
+func (c *kubeClient) request(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	token, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+string(bytes.TrimSpace(token)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errNotFound
+	case resp.StatusCode == http.StatusGone:
+		resp.Body.Close()
+		return nil, errExpired
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	return resp, nil
+}
+
+type listMeta struct {
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// latestResourceVersion returns the current resource version of events, watching from it skips the existing events.
+func (c *kubeClient) latestResourceVersion() (string, error) {
+	var list struct {
+		Metadata listMeta `json:"metadata"`
+	}
+	if err := c.do(http.MethodGet, "/api/v1/events?limit=1", nil, &list); err != nil {
+		return "", err
+	}
+	return list.Metadata.ResourceVersion, nil
+}
+
+// listEvents calls the handler for every event and returns the resource version of the list.
+func (c *kubeClient) listEvents(handle func(*event)) (string, error) {
+	var resourceVersion, continueToken string
+
+	for {
+		path := "/api/v1/events?limit=500"
+		if continueToken != "" {
+			path += "&continue=" + url.QueryEscape(continueToken)
+		}
+
+		var list struct {
+			Metadata struct {
+				ResourceVersion string `json:"resourceVersion"`
+				Continue        string `json:"continue"`
+			} `json:"metadata"`
+			Items []event `json:"items"`
+		}
+		if err := c.do(http.MethodGet, path, nil, &list); err != nil {
+			return "", err
+		}
+
+		// all pages are the snapshot of the first one
+		if resourceVersion == "" {
+			resourceVersion = list.Metadata.ResourceVersion
+		}
+		for i := range list.Items {
+			handle(&list.Items[i])
+		}
+
+		if list.Metadata.Continue == "" {
+			return resourceVersion, nil
+		}
+		continueToken = list.Metadata.Continue
+	}
+}
+
+// watchEvents calls the handler for every added or modified event until the context is canceled or the watch
+// is finished by the server. It returns the last seen resource version.
+func (c *kubeClient) watchEvents(ctx context.Context, resourceVersion string, handle func(*event)) (string, error) {
+	path := "/api/v1/events?watch=true&allowWatchBookmarks=true&timeoutSeconds=300&resourceVersion=" + resourceVersion
+
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for scanner.Scan() {
+		var we watchEvent
+		if err := json.Unmarshal(scanner.Bytes(), &we); err != nil {
+			return resourceVersion, err
+		}
+
+		if we.Type == "ERROR" {
+			var status struct {
+				Code int `json:"code"`
+			}
+			_ = json.Unmarshal(we.Object, &status)
+			if status.Code == http.StatusGone {
+				return resourceVersion, errExpired
+			}
+			return resourceVersion, fmt.Errorf("watch error: %s", we.Object)
+		}
+
+		var ev event
+		if err := json.Unmarshal(we.Object, &ev); err != nil {
+			return resourceVersion, err
+		}
+		resourceVersion = ev.Metadata.ResourceVersion
+
+		if we.Type == "ADDED" || we.Type == "MODIFIED" {
+			handle(&ev)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return resourceVersion, nil
+	}
+	return resourceVersion, scanner.Err()
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeEventsAPI serves two pages of the events list.
+var fakeEventsAPI = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/events" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("continue") == "" {
+		_, _ = w.Write([]byte(`{"metadata": {"resourceVersion": "200", "continue": "next"}, "items": [
+			{"metadata": {"name": "old", "namespace": "default", "resourceVersion": "90"}, "lastTimestamp": "2023-06-14T09:59:00Z"},
+			{"metadata": {"name": "same", "namespace": "default", "resourceVersion": "100"}, "lastTimestamp": "2023-06-14T10:00:00Z"}
+		]}`))
+		return
+	}
+	_, _ = w.Write([]byte(`{"metadata": {"resourceVersion": "201"}, "items": [
+		{"metadata": {"name": "new", "namespace": "default", "resourceVersion": "150"}, "lastTimestamp": "2023-06-14T10:01:00Z"}
+	]}`))
+})
+
+func TestRelist(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("test-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(fakeEventsAPI)
+	t.Cleanup(server.Close)
+	client := &kubeClient{host: server.URL, tokenFile: tokenFile, client: server.Client()}
+
+	cp := &checkpoint{}
+	cp.setTimestamp(time.Date(2023, 6, 14, 10, 0, 0, 0, time.UTC))
+
+	var written []string
+	rv, err := relist(client, cp, func(ev *event) {
+		written = append(written, ev.Metadata.Name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rv != "200" {
+		t.Errorf("resource version: got %q | expected %q", rv, "200")
+	}
+	// events older than the checkpoint are already written
+	if expected := []string{"same", "new"}; !reflect.DeepEqual(written, expected) {
+		t.Errorf("written events: got %v | expected %v", written, expected)
+	}
+}
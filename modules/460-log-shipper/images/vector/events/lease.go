@@ -0,0 +1,218 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	microTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+	resourceVersionAnnotation = "log-shipper.deckhouse.io/events-resource-version"
+	timestampAnnotation       = "log-shipper.deckhouse.io/events-timestamp"
+)
+
+type lease struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   objectMeta `json:"metadata"`
+	Spec       leaseSpec  `json:"spec"`
+}
+
+type objectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int32  `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int32  `json:"leaseTransitions,omitempty"`
+}
+
+// elector implements leader election with a Lease object, so only one agent in the cluster collects events.
+// Like in client-go, the expiration of the lease is measured by the local clock from the moment the lease
+// was observed to change, so the clock skew between nodes does not matter.
+// The leader saves the checkpoint of the collection to the lease, so the next leader resumes from it.
+type elector struct {
+	client     *kubeClient
+	namespace  string
+	name       string
+	identity   string
+	duration   time.Duration
+	checkpoint *checkpoint
+
+	observedRecord leaseSpec
+	observedTime   time.Time
+}
+
+func (e *elector) path() string {
+	return "/apis/coordination.k8s.io/v1/namespaces/" + e.namespace + "/leases"
+}
+
+// tryAcquireOrRenew returns true if the lease is held by this agent.
+func (e *elector) tryAcquireOrRenew() (bool, error) {
+	now := time.Now()
+
+	var current lease
+	err := e.client.do(http.MethodGet, e.path()+"/"+e.name, nil, &current)
+	if err == errNotFound {
+		l := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   objectMeta{Name: e.name, Namespace: e.namespace, Annotations: e.checkpoint.save(nil)},
+			Spec:       e.record(now, leaseSpec{}),
+		}
+		if err := e.client.do(http.MethodPost, e.path(), l, nil); err != nil {
+			return false, err
+		}
+		e.observe(l.Spec, now)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if current.Spec != e.observedRecord {
+		e.observe(current.Spec, now)
+	}
+
+	held := current.Spec.HolderIdentity != "" && current.Spec.HolderIdentity != e.identity
+	if held && e.observedTime.Add(e.duration).After(now) {
+		return false, nil
+	}
+
+	// Resume from the checkpoint of the previous leader, or of this agent before the restart
+	if current.Spec.HolderIdentity != e.identity || e.checkpoint.empty() {
+		e.checkpoint.load(current.Metadata.Annotations)
+	}
+
+	current.Metadata.Annotations = e.checkpoint.save(current.Metadata.Annotations)
+	current.Spec = e.record(now, current.Spec)
+	if err := e.client.do(http.MethodPut, e.path()+"/"+e.name, current, nil); err != nil {
+		return false, err
+	}
+	e.observe(current.Spec, now)
+	return true, nil
+}
+
+// release gives the lease up, so another agent can acquire it without waiting for the expiration.
+func (e *elector) release() error {
+	var current lease
+	if err := e.client.do(http.MethodGet, e.path()+"/"+e.name, nil, &current); err != nil {
+		return err
+	}
+	if current.Spec.HolderIdentity != e.identity {
+		return nil
+	}
+
+	current.Spec.HolderIdentity = ""
+	current.Spec.LeaseDurationSeconds = 1
+	return e.client.do(http.MethodPut, e.path()+"/"+e.name, current, nil)
+}
+
+func (e *elector) record(now time.Time, prev leaseSpec) leaseSpec {
+	spec := leaseSpec{
+		HolderIdentity:       e.identity,
+		LeaseDurationSeconds: int32(e.duration.Seconds()),
+		AcquireTime:          prev.AcquireTime,
+		RenewTime:            now.UTC().Format(microTimeLayout),
+		LeaseTransitions:     prev.LeaseTransitions,
+	}
+	if prev.HolderIdentity != e.identity {
+		spec.AcquireTime = spec.RenewTime
+		spec.LeaseTransitions++
+	}
+	return spec
+}
+
+func (e *elector) observe(spec leaseSpec, now time.Time) {
+	e.observedRecord = spec
+	e.observedTime = now
+}
+
+// checkpoint is the position of the events collection: the resource version to watch from
+// and the timestamp of the latest written event to skip older events when events are listed again.
+type checkpoint struct {
+	mu              sync.Mutex
+	resourceVersion string
+	timestamp       time.Time
+}
+
+func (c *checkpoint) get() (string, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resourceVersion, c.timestamp
+}
+
+func (c *checkpoint) setResourceVersion(resourceVersion string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resourceVersion = resourceVersion
+}
+
+func (c *checkpoint) setTimestamp(timestamp time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timestamp.After(c.timestamp) {
+		c.timestamp = timestamp
+	}
+}
+
+func (c *checkpoint) empty() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resourceVersion == "" && c.timestamp.IsZero()
+}
+
+func (c *checkpoint) load(annotations map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.resourceVersion = annotations[resourceVersionAnnotation]
+	c.timestamp, _ = time.Parse(time.RFC3339Nano, annotations[timestampAnnotation])
+}
+
+// save adds the checkpoint to the annotations of the lease.
+func (c *checkpoint) save(annotations map[string]string) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resourceVersion == "" && c.timestamp.IsZero() {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string, 2)
+	}
+	annotations[resourceVersionAnnotation] = c.resourceVersion
+	if !c.timestamp.IsZero() {
+		annotations[timestampAnnotation] = c.timestamp.UTC().Format(time.RFC3339Nano)
+	}
+	return annotations
+}
@@ -0,0 +1,296 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testNamespace = "d8-log-shipper"
+	testLeasePath = "/apis/coordination.k8s.io/v1/namespaces/" + testNamespace + "/leases"
+)
+
+// fakeLeaseAPI emulates the Lease API, including the optimistic concurrency on updates.
+type fakeLeaseAPI struct {
+	mu      sync.Mutex
+	lease   *lease
+	version int
+}
+
+func (f *fakeLeaseAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == testLeasePath+"/"+leaseName:
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(f.lease)
+
+	case r.Method == http.MethodPost && r.URL.Path == testLeasePath:
+		if f.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var l lease
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.store(l)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(f.lease)
+
+	case r.Method == http.MethodPut && r.URL.Path == testLeasePath+"/"+leaseName:
+		var l lease
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if l.Metadata.ResourceVersion != f.lease.Metadata.ResourceVersion {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(l)
+		_ = json.NewEncoder(w).Encode(f.lease)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeLeaseAPI) store(l lease) {
+	f.version++
+	l.Metadata.ResourceVersion = strconv.Itoa(f.version)
+	f.lease = &l
+}
+
+func (f *fakeLeaseAPI) annotations() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lease == nil {
+		return nil
+	}
+	return f.lease.Metadata.Annotations
+}
+
+func (f *fakeLeaseAPI) spec() leaseSpec {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.lease == nil {
+		return leaseSpec{}
+	}
+	return f.lease.Spec
+}
+
+func newTestElector(t *testing.T, api *fakeLeaseAPI, identity string) *elector {
+	t.Helper()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("test-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	return &elector{
+		client:     &kubeClient{host: server.URL, tokenFile: tokenFile, client: server.Client()},
+		namespace:  testNamespace,
+		name:       leaseName,
+		identity:   identity,
+		duration:   leaseDuration,
+		checkpoint: &checkpoint{},
+	}
+}
+
+func mustAcquire(t *testing.T, e *elector, expected bool) {
+	t.Helper()
+
+	leader, err := e.tryAcquireOrRenew()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader != expected {
+		t.Fatalf("%s is the leader: got %t | expected %t", e.identity, leader, expected)
+	}
+}
+
+func TestLeaseAcquire(t *testing.T) {
+	api := &fakeLeaseAPI{}
+	e := newTestElector(t, api, "agent-a")
+
+	mustAcquire(t, e, true)
+
+	spec := api.spec()
+	if spec.HolderIdentity != "agent-a" {
+		t.Errorf("holder: got %q | expected %q", spec.HolderIdentity, "agent-a")
+	}
+	if spec.LeaseDurationSeconds != int32(leaseDuration.Seconds()) {
+		t.Errorf("lease duration: got %d | expected %d", spec.LeaseDurationSeconds, int32(leaseDuration.Seconds()))
+	}
+	if spec.AcquireTime == "" || spec.AcquireTime != spec.RenewTime {
+		t.Errorf("acquire time must be equal to renew time: %q, %q", spec.AcquireTime, spec.RenewTime)
+	}
+	if spec.LeaseTransitions != 1 {
+		t.Errorf("transitions: got %d | expected 1", spec.LeaseTransitions)
+	}
+
+	other := newTestElector(t, api, "agent-b")
+	mustAcquire(t, other, false)
+
+	if holder := api.spec().HolderIdentity; holder != "agent-a" {
+		t.Errorf("the lease must not be taken over before it expires, holder: %q", holder)
+	}
+}
+
+func TestLeaseRenew(t *testing.T) {
+	api := &fakeLeaseAPI{}
+	e := newTestElector(t, api, "agent-a")
+
+	mustAcquire(t, e, true)
+	acquired := api.spec()
+
+	time.Sleep(time.Millisecond)
+	mustAcquire(t, e, true)
+
+	renewed := api.spec()
+	if renewed.RenewTime == acquired.RenewTime {
+		t.Errorf("renew time must be updated: %q", renewed.RenewTime)
+	}
+	if renewed.AcquireTime != acquired.AcquireTime {
+		t.Errorf("acquire time must not change on renewal: got %q | expected %q", renewed.AcquireTime, acquired.AcquireTime)
+	}
+	if renewed.LeaseTransitions != acquired.LeaseTransitions {
+		t.Errorf("transitions must not change on renewal: got %d | expected %d", renewed.LeaseTransitions, acquired.LeaseTransitions)
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	api := &fakeLeaseAPI{}
+	holder := newTestElector(t, api, "agent-a")
+	e := newTestElector(t, api, "agent-b")
+
+	mustAcquire(t, holder, true)
+	mustAcquire(t, e, false)
+
+	// the holder renews the lease, the expiration is measured from the observed change
+	e.observedTime = e.observedTime.Add(-leaseDuration + time.Second)
+	time.Sleep(time.Millisecond)
+	mustAcquire(t, holder, true)
+	mustAcquire(t, e, false)
+
+	// the holder stops renewing the lease
+	e.observedTime = e.observedTime.Add(-leaseDuration)
+	mustAcquire(t, e, true)
+
+	spec := api.spec()
+	if spec.HolderIdentity != "agent-b" {
+		t.Errorf("holder: got %q | expected %q", spec.HolderIdentity, "agent-b")
+	}
+	if spec.LeaseTransitions != 2 {
+		t.Errorf("transitions: got %d | expected 2", spec.LeaseTransitions)
+	}
+	if spec.AcquireTime != spec.RenewTime {
+		t.Errorf("acquire time must be updated on takeover: %q, %q", spec.AcquireTime, spec.RenewTime)
+	}
+
+	mustAcquire(t, holder, false)
+}
+
+func TestLeaseRelease(t *testing.T) {
+	api := &fakeLeaseAPI{}
+	holder := newTestElector(t, api, "agent-a")
+	e := newTestElector(t, api, "agent-b")
+
+	mustAcquire(t, holder, true)
+	mustAcquire(t, e, false)
+
+	// releasing the lease held by another agent does nothing
+	if err := e.release(); err != nil {
+		t.Fatal(err)
+	}
+	if h := api.spec().HolderIdentity; h != "agent-a" {
+		t.Fatalf("holder: got %q | expected %q", h, "agent-a")
+	}
+
+	if err := holder.release(); err != nil {
+		t.Fatal(err)
+	}
+	if h := api.spec().HolderIdentity; h != "" {
+		t.Fatalf("the released lease must have no holder, got %q", h)
+	}
+
+	// the released lease is acquired without waiting for the expiration
+	mustAcquire(t, e, true)
+}
+
+func TestLeaseCheckpoint(t *testing.T) {
+	api := &fakeLeaseAPI{}
+	holder := newTestElector(t, api, "agent-a")
+	e := newTestElector(t, api, "agent-b")
+
+	mustAcquire(t, holder, true)
+	if annotations := api.annotations(); len(annotations) != 0 {
+		t.Fatalf("the lease must have no checkpoint before events are collected, got %v", annotations)
+	}
+
+	timestamp := time.Date(2023, 6, 14, 10, 0, 30, 0, time.UTC)
+	holder.checkpoint.setResourceVersion("100")
+	holder.checkpoint.setTimestamp(timestamp)
+	mustAcquire(t, holder, true)
+
+	annotations := api.annotations()
+	if rv := annotations[resourceVersionAnnotation]; rv != "100" {
+		t.Errorf("resource version: got %q | expected %q", rv, "100")
+	}
+	if ts := annotations[timestampAnnotation]; ts != "2023-06-14T10:00:30Z" {
+		t.Errorf("timestamp: got %q | expected %q", ts, "2023-06-14T10:00:30Z")
+	}
+
+	// the new leader resumes from the checkpoint of the previous one
+	mustAcquire(t, e, false)
+	e.observedTime = e.observedTime.Add(-leaseDuration)
+	mustAcquire(t, e, true)
+
+	rv, ts := e.checkpoint.get()
+	if rv != "100" || !ts.Equal(timestamp) {
+		t.Errorf("checkpoint: got %q, %s | expected %q, %s", rv, ts, "100", timestamp)
+	}
+}
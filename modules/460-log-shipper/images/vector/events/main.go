@@ -0,0 +1,350 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The events watcher collects Kubernetes events for the KubernetesEvents log source.
+// Only the agent holding the lease watches events and writes them as JSON lines to a file read by Vector.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultOutputDir = "/var/run/log-shipper/events"
+
+	leaseName     = "log-shipper-events"
+	leaseDuration = 30 * time.Second
+	retryPeriod   = 5 * time.Second
+
+	// The file is rotated when it reaches the size, Vector keeps reading the rotated file by its fingerprint
+	maxFileSize = 10 * 1024 * 1024
+)
+
+type event struct {
+	Metadata struct {
+		Name              string `json:"name"`
+		Namespace         string `json:"namespace"`
+		UID               string `json:"uid"`
+		ResourceVersion   string `json:"resourceVersion"`
+		CreationTimestamp string `json:"creationTimestamp"`
+	} `json:"metadata"`
+	InvolvedObject struct {
+		Kind       string `json:"kind"`
+		Namespace  string `json:"namespace"`
+		Name       string `json:"name"`
+		UID        string `json:"uid"`
+		APIVersion string `json:"apiVersion"`
+		FieldPath  string `json:"fieldPath"`
+	} `json:"involvedObject"`
+	Reason         string `json:"reason"`
+	Message        string `json:"message"`
+	Type           string `json:"type"`
+	Action         string `json:"action"`
+	Count          int32  `json:"count"`
+	FirstTimestamp string `json:"firstTimestamp"`
+	LastTimestamp  string `json:"lastTimestamp"`
+	EventTime      string `json:"eventTime"`
+	Series         *struct {
+		Count            int32  `json:"count"`
+		LastObservedTime string `json:"lastObservedTime"`
+	} `json:"series"`
+	Source struct {
+		Component string `json:"component"`
+		Host      string `json:"host"`
+	} `json:"source"`
+	ReportingController string `json:"reportingComponent"`
+	ReportingInstance   string `json:"reportingInstance"`
+}
+
+// record is a log record of an event. Field names follow the naming of other log-shipper sources.
+type record struct {
+	Timestamp           string         `json:"timestamp"`
+	Message             string         `json:"message"`
+	Reason              string         `json:"reason,omitempty"`
+	Type                string         `json:"type,omitempty"`
+	Action              string         `json:"action,omitempty"`
+	Count               int32          `json:"count,omitempty"`
+	Namespace           string         `json:"namespace,omitempty"`
+	EventName           string         `json:"event_name"`
+	InvolvedObject      involvedObject `json:"involved_object"`
+	Source              *recordSource  `json:"source,omitempty"`
+	ReportingController string         `json:"reporting_controller,omitempty"`
+	ReportingInstance   string         `json:"reporting_instance,omitempty"`
+	FirstTimestamp      string         `json:"first_timestamp,omitempty"`
+	LastTimestamp       string         `json:"last_timestamp,omitempty"`
+}
+
+type involvedObject struct {
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	UID        string `json:"uid,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	FieldPath  string `json:"field_path,omitempty"`
+}
+
+type recordSource struct {
+	Component string `json:"component,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+func newRecord(ev *event) *record {
+	r := &record{
+		Message:             ev.Message,
+		Reason:              ev.Reason,
+		Type:                ev.Type,
+		Action:              ev.Action,
+		Count:               ev.Count,
+		Namespace:           ev.Metadata.Namespace,
+		EventName:           ev.Metadata.Name,
+		ReportingController: ev.ReportingController,
+		ReportingInstance:   ev.ReportingInstance,
+		FirstTimestamp:      ev.FirstTimestamp,
+		LastTimestamp:       ev.LastTimestamp,
+		InvolvedObject: involvedObject{
+			Kind:       ev.InvolvedObject.Kind,
+			Namespace:  ev.InvolvedObject.Namespace,
+			Name:       ev.InvolvedObject.Name,
+			UID:        ev.InvolvedObject.UID,
+			APIVersion: ev.InvolvedObject.APIVersion,
+			FieldPath:  ev.InvolvedObject.FieldPath,
+		},
+	}
+
+	if ev.Source.Component != "" || ev.Source.Host != "" {
+		r.Source = &recordSource{Component: ev.Source.Component, Host: ev.Source.Host}
+	}
+
+	timestamps := []string{ev.LastTimestamp, ev.EventTime, ev.FirstTimestamp, ev.Metadata.CreationTimestamp}
+	if ev.Series != nil {
+		timestamps = append([]string{ev.Series.LastObservedTime}, timestamps...)
+		if r.Count == 0 {
+			r.Count = ev.Series.Count
+		}
+	}
+	for _, ts := range timestamps {
+		if ts != "" {
+			r.Timestamp = ts
+			break
+		}
+	}
+
+	return r
+}
+
+// rotatingFile writes lines to the file and rotates it when it grows too big.
+type rotatingFile struct {
+	path string
+	file *os.File
+	size int64
+}
+
+func (f *rotatingFile) writeLine(line []byte) error {
+	if f.file == nil {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		f.file, f.size = file, info.Size()
+	}
+
+	n, err := f.file.Write(append(line, '\n'))
+	f.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if f.size >= maxFileSize {
+		f.file.Close()
+		f.file = nil
+		ext := filepath.Ext(f.path)
+		return os.Rename(f.path, f.path[:len(f.path)-len(ext)]+".1"+ext)
+	}
+	return nil
+}
+
+// collect watches events and writes them to the file until the context is canceled.
+// It starts from the checkpoint, the first leader in the cluster starts from the latest events.
+func collect(ctx context.Context, client *kubeClient, out *rotatingFile, cp *checkpoint) {
+	handle := func(ev *event) {
+		r := newRecord(ev)
+		line, err := json.Marshal(r)
+		if err != nil {
+			log.Printf("cannot encode event %s/%s: %v", ev.Metadata.Namespace, ev.Metadata.Name, err)
+			return
+		}
+		if err := out.writeLine(line); err != nil {
+			log.Printf("cannot write event: %v", err)
+			return
+		}
+		cp.setResourceVersion(ev.Metadata.ResourceVersion)
+		cp.setTimestamp(recordTime(r))
+	}
+
+	resourceVersion, _ := cp.get()
+	for ctx.Err() == nil {
+		if resourceVersion == "" {
+			rv, err := relist(client, cp, handle)
+			if err != nil {
+				log.Printf("cannot list events: %v", err)
+				sleep(ctx, retryPeriod)
+				continue
+			}
+			resourceVersion = rv
+			cp.setResourceVersion(rv)
+		}
+
+		rv, err := client.watchEvents(ctx, resourceVersion, handle)
+		resourceVersion = rv
+		cp.setResourceVersion(rv)
+		if err == errExpired {
+			log.Println("resource version expired, listing events changed since the checkpoint")
+			resourceVersion = ""
+			continue
+		}
+		if err != nil {
+			log.Printf("watch events: %v", err)
+			sleep(ctx, retryPeriod)
+		}
+	}
+}
+
+// relist returns the resource version to watch events from. Events changed since the checkpoint are written,
+// if there is no checkpoint, existing events are skipped.
+func relist(client *kubeClient, cp *checkpoint, handle func(*event)) (string, error) {
+	_, since := cp.get()
+	if since.IsZero() {
+		return client.latestResourceVersion()
+	}
+
+	// events with the same timestamp as the checkpoint can be written twice, but they are not lost
+	return client.listEvents(func(ev *event) {
+		if !recordTime(newRecord(ev)).Before(since) {
+			handle(ev)
+		}
+	})
+}
+
+func recordTime(r *record) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, r.Timestamp)
+	return t
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func main() {
+	identity := os.Getenv("VECTOR_SELF_POD_NAME")
+	namespace := os.Getenv("VECTOR_SELF_POD_NAMESPACE")
+	if identity == "" || namespace == "" {
+		log.Fatal("VECTOR_SELF_POD_NAME and VECTOR_SELF_POD_NAMESPACE must be set")
+	}
+
+	outputDir := os.Getenv("EVENTS_OUTPUT_DIR")
+	if outputDir == "" {
+		outputDir = defaultOutputDir
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := newKubeClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e := &elector{
+		client:     client,
+		namespace:  namespace,
+		name:       leaseName,
+		identity:   identity,
+		duration:   leaseDuration,
+		checkpoint: &checkpoint{},
+	}
+	out := &rotatingFile{path: filepath.Join(outputDir, "events.log")}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(retryPeriod)
+	defer ticker.Stop()
+
+	var (
+		cancel    context.CancelFunc
+		done      chan struct{}
+		lastRenew time.Time
+	)
+	stop := func() {
+		if cancel == nil {
+			return
+		}
+		cancel()
+		<-done
+		cancel = nil
+		log.Println("stopped collecting events")
+	}
+
+	for {
+		leader, err := e.tryAcquireOrRenew()
+		if err != nil {
+			log.Printf("cannot acquire or renew the lease: %v", err)
+		}
+
+		switch {
+		case leader:
+			lastRenew = time.Now()
+			if cancel == nil {
+				log.Println("the lease is acquired, collecting events")
+				var ctx context.Context
+				ctx, cancel = context.WithCancel(context.Background())
+				done = make(chan struct{})
+				go func() {
+					defer close(done)
+					collect(ctx, client, out, e.checkpoint)
+				}()
+			}
+		case err == nil || time.Since(lastRenew) > leaseDuration-retryPeriod:
+			// The lease is held by another agent or cannot be renewed in time
+			stop()
+		}
+
+		select {
+		case <-signals:
+			stop()
+			if err := e.release(); err != nil {
+				log.Printf("cannot release the lease: %v", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	logsPath = "/v1/logs"
+
+	exportTimeout  = 30 * time.Second
+	maxRequestSize = 64 * 1024 * 1024
+)
+
+// config is generated by the log-shipper hook, destinations are mapped by the ClusterLogDestination name.
+type config struct {
+	Destinations map[string]destinationConfig `json:"destinations"`
+}
+
+type destinationConfig struct {
+	// Endpoint is the host:port of the collector
+	Endpoint string            `json:"endpoint"`
+	Insecure bool              `json:"insecure,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+
+	// CA, certificate and key are PEM encoded
+	CAFile            string `json:"ca_file,omitempty"`
+	CertFile          string `json:"crt_file,omitempty"`
+	KeyFile           string `json:"key_file,omitempty"`
+	KeyPass           string `json:"key_pass,omitempty"`
+	VerifyCertificate bool   `json:"verify_certificate"`
+	VerifyHostname    bool   `json:"verify_hostname"`
+}
+
+type exporter struct {
+	config destinationConfig
+	conn   *grpc.ClientConn
+	client collogspb.LogsServiceClient
+}
+
+type forwarder struct {
+	configPath string
+
+	mu        sync.Mutex
+	exporters map[string]*exporter
+}
+
+func newForwarder(configPath string) *forwarder {
+	return &forwarder{
+		configPath: configPath,
+		exporters:  make(map[string]*exporter),
+	}
+}
+
+func (f *forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !strings.HasSuffix(r.URL.Path, logsPath) {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.Trim(strings.TrimSuffix(r.URL.Path, logsPath), "/")
+
+	exp, err := f.exporter(name)
+	if err != nil {
+		log.Printf("destination %s: %v", name, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exp == nil {
+		http.Error(w, fmt.Sprintf("destination %q is not found", name), http.StatusNotFound)
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req := &collogspb.ExportLogsServiceRequest{}
+	if err := protojson.Unmarshal(body, req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid OTLP request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), exportTimeout)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.New(exp.config.Headers))
+
+	resp, err := exp.client.Export(ctx, req)
+	if err != nil {
+		st := status.Convert(err)
+		log.Printf("destination %s: export failed: %s: %s", name, st.Code(), st.Message())
+		http.Error(w, st.Message(), httpStatus(st.Code()))
+		return
+	}
+
+	if partial := resp.GetPartialSuccess(); partial.GetRejectedLogRecords() > 0 {
+		log.Printf("destination %s: %d log records are rejected: %s", name, partial.GetRejectedLogRecords(), partial.GetErrorMessage())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("{}"))
+}
+
+// exporter returns nil if the destination is not configured.
+// The config is read on every request, it is small and changes when the secret with the config is updated.
+func (f *forwarder) exporter(name string) (*exporter, error) {
+	content, err := os.ReadFile(f.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var cfg config
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// close connections of removed destinations
+	for n, exp := range f.exporters {
+		if _, ok := cfg.Destinations[n]; !ok {
+			_ = exp.conn.Close()
+			delete(f.exporters, n)
+		}
+	}
+
+	dest, ok := cfg.Destinations[name]
+	if !ok {
+		return nil, nil
+	}
+
+	if exp, ok := f.exporters[name]; ok {
+		if reflect.DeepEqual(exp.config, dest) {
+			return exp, nil
+		}
+		_ = exp.conn.Close()
+		delete(f.exporters, name)
+	}
+
+	creds, err := transportCredentials(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(dest.Endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", dest.Endpoint, err)
+	}
+
+	exp := &exporter{
+		config: dest,
+		conn:   conn,
+		client: collogspb.NewLogsServiceClient(conn),
+	}
+	f.exporters[name] = exp
+
+	return exp, nil
+}
+
+func (f *forwarder) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for name, exp := range f.exporters {
+		_ = exp.conn.Close()
+		delete(f.exporters, name)
+	}
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxRequestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if len(body) > maxRequestSize {
+		return nil, fmt.Errorf("request is larger than %d bytes", maxRequestSize)
+	}
+
+	return body, nil
+}
+
+// httpStatus maps gRPC codes to HTTP statuses according to the OTLP specification,
+// Vector retries requests failed with 429 and 5xx statuses.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled, codes.DeadlineExceeded, codes.Aborted, codes.OutOfRange, codes.Unavailable, codes.DataLoss:
+		return http.StatusServiceUnavailable
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound, codes.Unimplemented:
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func transportCredentials(dest destinationConfig) (credentials.TransportCredentials, error) {
+	if dest.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if dest.CAFile != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(dest.CAFile)) {
+			return nil, errors.New("invalid CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if dest.CertFile != "" {
+		key, err := decryptKey([]byte(dest.KeyFile), dest.KeyPass)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair([]byte(dest.CertFile), key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch {
+	case !dest.VerifyCertificate:
+		tlsConfig.InsecureSkipVerify = true
+	case !dest.VerifyHostname:
+		// verify the chain only, the standard verification always checks the host name
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server has no certificates")
+			}
+			opts := x509.VerifyOptions{
+				Roots:         tlsConfig.RootCAs,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range state.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// decryptKey supports keys encrypted according to RFC 1423, Go doesn't support encrypted PKCS#8 keys.
+func decryptKey(key []byte, pass string) ([]byte, error) {
+	if pass == "" {
+		return key, nil
+	}
+
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("invalid private key")
+	}
+	//nolint:staticcheck // legacy PEM encryption is the only one supported by the standard library
+	if !x509.IsEncryptedPEMBlock(block) {
+		return nil, errors.New("private key is not encrypted or uses encrypted PKCS#8, which is not supported")
+	}
+	//nolint:staticcheck
+	der, err := x509.DecryptPEMBlock(block, []byte(pass))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
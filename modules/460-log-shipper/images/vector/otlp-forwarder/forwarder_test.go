@@ -0,0 +1,267 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testRequest = `{"resourceLogs":[` +
+	`{"resource":{"attributes":[{"key":"k8s.pod.name","value":{"stringValue":"app-0"}}]},"scopeLogs":[{"scope":{"name":"log-shipper"},"logRecords":[{"timeUnixNano":"1700000000000000000","body":{"stringValue":"first"}}]}]},` +
+	`{"resource":{"attributes":[{"key":"k8s.pod.name","value":{"stringValue":"app-1"}}]},"scopeLogs":[{"scope":{"name":"log-shipper"},"logRecords":[{"timeUnixNano":"1700000000000000001","body":{"stringValue":"second"}}]}]}` +
+	`]}`
+
+type testCollector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mu       sync.Mutex
+	requests []*collogspb.ExportLogsServiceRequest
+	metadata []metadata.MD
+	err      error
+}
+
+func (c *testCollector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.requests = append(c.requests, req)
+	c.metadata = append(c.metadata, md)
+
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func startCollector(t *testing.T) (*testCollector, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector := &testCollector{}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, collector)
+
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	return collector, lis.Addr().String()
+}
+
+func writeConfig(t *testing.T, path string, destinations map[string]destinationConfig) {
+	t.Helper()
+
+	content, err := json.Marshal(config{Destinations: destinations})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func gzipBody(t *testing.T, body string) *bytes.Buffer {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	if _, err := gz.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func post(t *testing.T, server *httptest.Server, path, body string) int {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+path, gzipBody(t, body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode
+}
+
+func newTestForwarder(t *testing.T, destinations map[string]destinationConfig) (*httptest.Server, string) {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "otlp-forwarder.json")
+	writeConfig(t, configPath, destinations)
+
+	fwd := newForwarder(configPath)
+	server := httptest.NewServer(fwd)
+	t.Cleanup(func() {
+		server.Close()
+		fwd.close()
+	})
+
+	return server, configPath
+}
+
+func TestForwardBatch(t *testing.T) {
+	collector, addr := startCollector(t)
+
+	server, _ := newTestForwarder(t, map[string]destinationConfig{
+		"otel": {
+			Endpoint: addr,
+			Insecure: true,
+			Headers:  map[string]string{"Authorization": "Bearer token", "X-Scope-OrgID": "main"},
+		},
+	})
+
+	if code := post(t, server, "/otel/v1/logs", testRequest); code != http.StatusOK {
+		t.Fatalf("status: got %d | expected %d", code, http.StatusOK)
+	}
+
+	if len(collector.requests) != 1 {
+		t.Fatalf("requests: got %d | expected 1", len(collector.requests))
+	}
+
+	resourceLogs := collector.requests[0].GetResourceLogs()
+	if len(resourceLogs) != 2 {
+		t.Fatalf("resource logs: got %d | expected 2", len(resourceLogs))
+	}
+	if body := resourceLogs[1].GetScopeLogs()[0].GetLogRecords()[0].GetBody().GetStringValue(); body != "second" {
+		t.Errorf("body: got %q | expected %q", body, "second")
+	}
+	if ts := resourceLogs[0].GetScopeLogs()[0].GetLogRecords()[0].GetTimeUnixNano(); ts != 1700000000000000000 {
+		t.Errorf("timestamp: got %d", ts)
+	}
+
+	md := collector.metadata[0]
+	if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer token" {
+		t.Errorf("authorization: got %v", got)
+	}
+	if got := md.Get("x-scope-orgid"); len(got) != 1 || got[0] != "main" {
+		t.Errorf("x-scope-orgid: got %v", got)
+	}
+}
+
+func TestForwardErrors(t *testing.T) {
+	collector, addr := startCollector(t)
+	collector.err = status.Error(codes.Unavailable, "collector is overloaded")
+
+	server, _ := newTestForwarder(t, map[string]destinationConfig{
+		"otel": {Endpoint: addr, Insecure: true},
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		expected int
+	}{
+		{name: "unknown destination", path: "/unknown/v1/logs", body: testRequest, expected: http.StatusNotFound},
+		{name: "unknown path", path: "/otel/v1/traces", body: testRequest, expected: http.StatusNotFound},
+		{name: "invalid request", path: "/otel/v1/logs", body: `{"resourceLogs": "invalid"}`, expected: http.StatusBadRequest},
+		{name: "retryable error", path: "/otel/v1/logs", body: testRequest, expected: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := post(t, server, tt.path, tt.body); code != tt.expected {
+				t.Errorf("status: got %d | expected %d", code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	first, firstAddr := startCollector(t)
+	second, secondAddr := startCollector(t)
+
+	server, configPath := newTestForwarder(t, map[string]destinationConfig{
+		"otel": {Endpoint: firstAddr, Insecure: true},
+	})
+
+	if code := post(t, server, "/otel/v1/logs", testRequest); code != http.StatusOK {
+		t.Fatalf("status: got %d | expected %d", code, http.StatusOK)
+	}
+
+	writeConfig(t, configPath, map[string]destinationConfig{
+		"otel": {Endpoint: secondAddr, Insecure: true},
+	})
+
+	if code := post(t, server, "/otel/v1/logs", testRequest); code != http.StatusOK {
+		t.Fatalf("status: got %d | expected %d", code, http.StatusOK)
+	}
+
+	if len(first.requests) != 1 || len(second.requests) != 1 {
+		t.Errorf("requests must be sent to the new endpoint after the config change: first %d, second %d", len(first.requests), len(second.requests))
+	}
+
+	writeConfig(t, configPath, map[string]destinationConfig{})
+
+	if code := post(t, server, "/otel/v1/logs", testRequest); code != http.StatusNotFound {
+		t.Errorf("status of the removed destination: got %d | expected %d", code, http.StatusNotFound)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		code     codes.Code
+		expected int
+	}{
+		{code: codes.ResourceExhausted, expected: http.StatusTooManyRequests},
+		{code: codes.Unavailable, expected: http.StatusServiceUnavailable},
+		{code: codes.DeadlineExceeded, expected: http.StatusServiceUnavailable},
+		{code: codes.InvalidArgument, expected: http.StatusBadRequest},
+		{code: codes.Unauthenticated, expected: http.StatusUnauthorized},
+		{code: codes.PermissionDenied, expected: http.StatusForbidden},
+		{code: codes.Internal, expected: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			if got := httpStatus(tt.code); got != tt.expected {
+				t.Errorf("got %d | expected %d", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The OTLP forwarder sends logs to OTLP destinations using the gRPC protocol, which is not supported by Vector.
+// Vector sends OTLP/HTTP JSON requests of a destination to the /<destination>/v1/logs path of the forwarder,
+// the forwarder converts them to protobuf and exports them to the collector.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultListenAddress = "127.0.0.1:4318"
+	defaultConfigPath    = "/opt/vector/otlp-forwarder.json"
+)
+
+func main() {
+	listenAddress := flag.String("listen-address", defaultListenAddress, "address to listen for OTLP/HTTP requests from Vector")
+	configPath := flag.String("config", defaultConfigPath, "path to the destinations config")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fwd := newForwarder(*configPath)
+	defer fwd.close()
+
+	server := &http.Server{
+		Addr:              *listenAddress,
+		Handler:           fwd,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", *listenAddress)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
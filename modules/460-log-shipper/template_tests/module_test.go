@@ -65,6 +65,7 @@ resourcesRequests:
 			testD := hec.KubernetesResource("DaemonSet", "d8-log-shipper", "log-shipper-agent")
 			Expect(testD.Exists()).To(BeTrue())
 			Expect(testD.Field("spec.template.spec.containers.0.resources.requests").String()).To(MatchYAML(`ephemeral-storage: 1024Mi`))
+			Expect(testD.Field(`spec.template.spec.containers.#(name=="otlp-forwarder")`).Exists()).To(BeFalse())
 
 			manVPA := hec.KubernetesResource("VerticalPodAutoscaler", "d8-log-shipper", "log-shipper-agent")
 			Expect(manVPA.Exists()).To(BeTrue())
@@ -134,4 +135,45 @@ memory: 64Mi
     memory: 25Mi`))
 		})
 	})
+
+	Context("With OTLP/gRPC destinations", func() {
+		BeforeEach(func() {
+			hec.ValuesSetFromYaml("logShipper", `
+debug: false
+internal:
+  activated: true
+  otlpForwarderEnabled: true
+resourcesRequests:
+  mode: VPA
+  static:
+    cpu: 5m
+    memory: 4Mi
+  vpa:
+    cpu:
+      max: 500m
+      min: 50m
+    memory:
+      max: 2048Mi
+      min: 64Mi
+    mode: Initial
+`)
+			hec.HelmRender()
+		})
+		It("Should add the otlp-forwarder container", func() {
+			Expect(hec.RenderError).ShouldNot(HaveOccurred())
+
+			testD := hec.KubernetesResource("DaemonSet", "d8-log-shipper", "log-shipper-agent")
+			forwarder := testD.Field(`spec.template.spec.containers.#(name=="otlp-forwarder")`)
+			Expect(forwarder.Exists()).To(BeTrue())
+			Expect(forwarder.Get("command").String()).To(MatchJSON(`["otlp-forwarder"]`))
+			Expect(forwarder.Get("volumeMounts").String()).To(MatchYAML(`
+- name: vector-dynamic-config
+  mountPath: /opt/vector/
+  readOnly: true
+`))
+
+			manVPA := hec.KubernetesResource("VerticalPodAutoscaler", "d8-log-shipper", "log-shipper-agent")
+			Expect(manVPA.Field(`spec.resourcePolicy.containerPolicies.#(containerName=="otlp-forwarder")`).Exists()).To(BeTrue())
+		})
+	})
 })
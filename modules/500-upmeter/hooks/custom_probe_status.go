@@ -0,0 +1,109 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	"sort"
+
+	"github.com/flant/addon-operator/pkg/module_manager/go_hook"
+	"github.com/flant/addon-operator/sdk"
+	"github.com/flant/shell-operator/pkg/kube/object_patch"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// This hook reports UpmeterCustomProbe objects that define the same probe as an older object. Upmeter agents
+// run only the oldest object for a probe (the first by name if they are created at the same second).
+
+var _ = sdk.RegisterFunc(&go_hook.HookConfig{
+	Queue: "/modules/upmeter/custom_probe_status",
+	Kubernetes: []go_hook.KubernetesConfig{
+		{
+			Name:       "probes",
+			ApiVersion: "deckhouse.io/v1alpha1",
+			Kind:       "UpmeterCustomProbe",
+			FilterFunc: filterCustomProbe,
+		},
+	},
+}, setCustomProbeStatus)
+
+type customProbe struct {
+	Name          string
+	Probe         string
+	Created       int64
+	ConflictsWith string
+}
+
+func filterCustomProbe(obj *unstructured.Unstructured) (go_hook.FilterResult, error) {
+	group, _, err := unstructured.NestedString(obj.Object, "spec", "group")
+	if err != nil {
+		return nil, err
+	}
+	probe, _, err := unstructured.NestedString(obj.Object, "spec", "probe")
+	if err != nil {
+		return nil, err
+	}
+	conflictsWith, _, err := unstructured.NestedString(obj.Object, "status", "conflictsWith")
+	if err != nil {
+		return nil, err
+	}
+
+	return customProbe{
+		Name:          obj.GetName(),
+		Probe:         group + "/" + probe,
+		Created:       obj.GetCreationTimestamp().Unix(),
+		ConflictsWith: conflictsWith,
+	}, nil
+}
+
+func setCustomProbeStatus(input *go_hook.HookInput) error {
+	probes := make([]customProbe, 0, len(input.Snapshots["probes"]))
+	for _, sn := range input.Snapshots["probes"] {
+		probes = append(probes, sn.(customProbe))
+	}
+
+	sort.Slice(probes, func(i, j int) bool {
+		if probes[i].Created != probes[j].Created {
+			return probes[i].Created < probes[j].Created
+		}
+		return probes[i].Name < probes[j].Name
+	})
+
+	owners := make(map[string]string, len(probes)) // object name by probe
+	for _, cp := range probes {
+		owner, ok := owners[cp.Probe]
+		if !ok {
+			owners[cp.Probe] = cp.Name
+		}
+		if owner == cp.ConflictsWith {
+			continue
+		}
+
+		var conflictsWith interface{}
+		if owner != "" {
+			conflictsWith = owner
+			input.LogEntry.Warnf("UpmeterCustomProbe %s is not run: probe %s is already defined by UpmeterCustomProbe %s", cp.Name, cp.Probe, owner)
+		}
+		patch := map[string]interface{}{
+			"status": map[string]interface{}{
+				"conflictsWith": conflictsWith,
+			},
+		}
+		input.PatchCollector.MergePatch(patch, "deckhouse.io/v1alpha1", "UpmeterCustomProbe", "", cp.Name, object_patch.WithSubresource("/status"), object_patch.IgnoreMissingObject())
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hooks
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/deckhouse/deckhouse/testing/hooks"
+)
+
+var _ = Describe("Modules :: upmeter :: hooks :: custom_probe_status ::", func() {
+	const (
+		probes = `
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: UpmeterCustomProbe
+metadata:
+  name: site-new
+  creationTimestamp: "2023-06-14T11:00:00Z"
+spec:
+  group: site
+  probe: main
+  type: TCP
+  tcp:
+    address: site.example.com:443
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: UpmeterCustomProbe
+metadata:
+  name: site-old
+  creationTimestamp: "2023-06-14T10:00:00Z"
+spec:
+  group: site
+  probe: main
+  type: TCP
+  tcp:
+    address: site.example.com:443
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: UpmeterCustomProbe
+metadata:
+  name: site-api
+  creationTimestamp: "2023-06-14T12:00:00Z"
+spec:
+  group: site
+  probe: api
+  type: TCP
+  tcp:
+    address: api.example.com:443
+`
+		resolvedConflict = `
+---
+apiVersion: deckhouse.io/v1alpha1
+kind: UpmeterCustomProbe
+metadata:
+  name: site-new
+  creationTimestamp: "2023-06-14T11:00:00Z"
+spec:
+  group: site
+  probe: main
+  type: TCP
+  tcp:
+    address: site.example.com:443
+status:
+  conflictsWith: site-old
+`
+	)
+
+	f := HookExecutionConfigInit(`{"upmeter":{"internal":{}}}`, `{}`)
+	f.RegisterCRD("deckhouse.io", "v1alpha1", "UpmeterCustomProbe", false)
+
+	Context("Probes with unique group and probe", func() {
+		BeforeEach(func() {
+			f.BindingContexts.Set(f.KubeStateSet(resolvedConflict))
+			f.RunHook()
+		})
+
+		It("Conflict must be cleared", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.KubernetesGlobalResource("UpmeterCustomProbe", "site-new").Field("status.conflictsWith").Exists()).To(BeFalse())
+		})
+	})
+
+	Context("Several probes define the same group and probe", func() {
+		BeforeEach(func() {
+			f.BindingContexts.Set(f.KubeStateSet(probes))
+			f.RunHook()
+		})
+
+		It("Newer probe must be reported as conflicting with the oldest one", func() {
+			Expect(f).To(ExecuteSuccessfully())
+			Expect(f.KubernetesGlobalResource("UpmeterCustomProbe", "site-new").Field("status.conflictsWith").String()).To(Equal("site-old"))
+			Expect(f.KubernetesGlobalResource("UpmeterCustomProbe", "site-old").Field("status.conflictsWith").Exists()).To(BeFalse())
+			Expect(f.KubernetesGlobalResource("UpmeterCustomProbe", "site-api").Field("status.conflictsWith").Exists()).To(BeFalse())
+		})
+	})
+})
@@ -70,6 +70,33 @@ func parseServerArgs(cmd *kingpin.CmdClause, config *server.Config) {
 		Envar("UPMETER_USER_AGENT").
 		Default("Upmeter/1.0").
 		StringVar(&config.UserAgent)
+
+	// Automatic incident detection
+	cmd.Flag("incident-episodes", "Consecutive 30s episodes to open or close an incident, 0 disables the detection.").
+		Envar("UPMETER_INCIDENT_EPISODES").
+		Default("3").
+		IntVar(&config.IncidentDetection.Episodes)
+
+	cmd.Flag("incident-status", "Group status to open an incident.").
+		Envar("UPMETER_INCIDENT_STATUS").
+		Default("Outage").
+		EnumVar(&config.IncidentDetection.Status, "Degraded", "Outage")
+
+	// Retention of episodes by granularity, zero keeps episodes forever
+	cmd.Flag("retention-5m", "How long to keep 5m episodes, e.g. 2160h. Zero keeps them forever.").
+		Envar("UPMETER_RETENTION_5M").
+		Default("0").
+		DurationVar(&config.Retention.Episodes5m)
+
+	cmd.Flag("retention-1h", "How long to keep hourly episodes, e.g. 8760h. Zero keeps them forever.").
+		Envar("UPMETER_RETENTION_1H").
+		Default("0").
+		DurationVar(&config.Retention.Episodes1h)
+
+	cmd.Flag("retention-1d", "How long to keep daily episodes. Zero keeps them forever.").
+		Envar("UPMETER_RETENTION_1D").
+		Default("0").
+		DurationVar(&config.Retention.Episodes1d)
 }
 
 func parseAgentArgs(cmd *kingpin.CmdClause, config *agent.Config) {
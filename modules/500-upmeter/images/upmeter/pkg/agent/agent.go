@@ -29,11 +29,13 @@ import (
 	"d8.io/upmeter/pkg/db"
 	dbcontext "d8.io/upmeter/pkg/db/context"
 	"d8.io/upmeter/pkg/kubernetes"
+	"d8.io/upmeter/pkg/monitor/customprobe"
 	"d8.io/upmeter/pkg/monitor/node"
 	"d8.io/upmeter/pkg/probe"
 	"d8.io/upmeter/pkg/probe/calculated"
 	"d8.io/upmeter/pkg/probe/checker"
 	"d8.io/upmeter/pkg/registry"
+	"d8.io/upmeter/pkg/set"
 )
 
 type Agent struct {
@@ -42,8 +44,9 @@ type Agent struct {
 
 	logger *log.Logger
 
-	sender    *sender.Sender
-	scheduler *scheduler.Scheduler
+	sender      *sender.Sender
+	scheduler   *scheduler.Scheduler
+	customProbe *customprobe.Monitor
 }
 
 type Config struct {
@@ -110,6 +113,23 @@ func (a *Agent) Start(ctx context.Context) error {
 	calcLoader := calculated.NewLoader(ftr, a.logger)
 	registry := registry.New(runnerLoader, calcLoader)
 
+	// User-defined probes are added to and removed from the registry at runtime
+	builtinProbes := set.New()
+	for _, ref := range runnerLoader.Probes() {
+		builtinProbes.Add(ref.Id())
+	}
+	a.customProbe = customprobe.NewMonitor(kubeAccess.Kubernetes(), log.NewEntry(a.logger))
+	a.customProbe.Subscribe(&customProbeHandler{
+		registry:  registry,
+		filter:    ftr,
+		builtin:   builtinProbes,
+		userAgent: a.config.UserAgent,
+		logger:    a.logger,
+	})
+	if err := a.customProbe.Start(ctx); err != nil {
+		return fmt.Errorf("starting custom probe monitor: %v", err)
+	}
+
 	// Database connection with pool
 	dbctx, err := db.Connect(a.config.DatabasePath, dbcontext.DefaultConnectionOptions())
 	if err != nil {
@@ -133,5 +153,6 @@ func (a *Agent) Start(ctx context.Context) error {
 func (a *Agent) Stop() error {
 	a.scheduler.Stop()
 	a.sender.Stop()
+	a.customProbe.Stop()
 	return nil
 }
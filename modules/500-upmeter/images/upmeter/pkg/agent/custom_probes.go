@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/monitor/customprobe"
+	"d8.io/upmeter/pkg/probe"
+	"d8.io/upmeter/pkg/registry"
+	"d8.io/upmeter/pkg/set"
+)
+
+// customProbeHandler keeps runners of user-defined probes in the registry in sync with UpmeterCustomProbe
+// objects. Only one object can define a probe: the oldest one is run, others are rejected, so agents and
+// the status reported by the module agree regardless of the order of events. Informer handlers are called
+// sequentially, so the state is not guarded.
+type customProbeHandler struct {
+	registry  *registry.Registry
+	filter    probe.Filter
+	builtin   set.StringSet
+	userAgent string
+	logger    *log.Logger
+
+	probes map[string]*customprobe.CustomProbe // by object name
+}
+
+func (h *customProbeHandler) OnAdd(cp *customprobe.CustomProbe) {
+	h.set(cp)
+}
+
+func (h *customProbeHandler) OnModify(cp *customprobe.CustomProbe) {
+	h.set(cp)
+}
+
+func (h *customProbeHandler) OnDelete(cp *customprobe.CustomProbe) {
+	name := cp.GetName()
+	prev, ok := h.probes[name]
+	delete(h.probes, name)
+
+	h.registry.DeleteRunner(name)
+	h.logger.Infof("Unregister custom probe %q", name)
+
+	if ok {
+		h.sync(probeRef(prev))
+	}
+}
+
+func (h *customProbeHandler) set(cp *customprobe.CustomProbe) {
+	if h.probes == nil {
+		h.probes = make(map[string]*customprobe.CustomProbe)
+	}
+
+	prev, ok := h.probes[cp.GetName()]
+	h.probes[cp.GetName()] = cp
+
+	// the object moved to another probe, the conflicting one can be run now
+	if ok && probeRef(prev) != probeRef(cp) {
+		h.sync(probeRef(prev))
+	}
+	h.sync(probeRef(cp))
+}
+
+// sync runs the oldest object defining the probe and stops others
+func (h *customProbeHandler) sync(ref check.ProbeRef) {
+	defined := make([]*customprobe.CustomProbe, 0, 1)
+	for _, cp := range h.probes {
+		if probeRef(cp) == ref {
+			defined = append(defined, cp)
+		}
+	}
+	if len(defined) == 0 {
+		return
+	}
+
+	owner := customprobe.Owner(defined)
+	for _, cp := range defined {
+		if cp != owner {
+			h.registry.DeleteRunner(cp.GetName())
+			h.logger.Errorf("Custom probe %q conflicts with custom probe %q defining %s", cp.GetName(), owner.GetName(), ref.Id())
+		}
+	}
+	h.register(owner)
+}
+
+func (h *customProbeHandler) register(cp *customprobe.CustomProbe) {
+	name := cp.GetName()
+	ref := probeRef(cp)
+
+	if !h.filter.Enabled(ref) {
+		h.registry.DeleteRunner(name)
+		return
+	}
+
+	if h.builtin.Has(ref.Id()) {
+		h.registry.DeleteRunner(name)
+		h.logger.Errorf("Custom probe %q conflicts with built-in probe %s", name, ref.Id())
+		return
+	}
+
+	runner, err := probe.NewCustomRunner(cp, h.userAgent, h.logger)
+	if err != nil {
+		h.registry.DeleteRunner(name)
+		h.logger.Errorf("Cannot register custom probe %q: %v", name, err)
+		return
+	}
+
+	h.registry.SetRunner(name, runner)
+	h.logger.Infof("Register custom probe %q as %s", name, ref.Id())
+}
+
+func probeRef(cp *customprobe.CustomProbe) check.ProbeRef {
+	return check.ProbeRef{Group: cp.Spec.Group, Probe: cp.Spec.Probe}
+}
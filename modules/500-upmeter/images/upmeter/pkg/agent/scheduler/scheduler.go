@@ -186,11 +186,29 @@ func (e *Scheduler) export(start time.Time) error {
 		series.Clean()
 	}
 
+	e.prune()
+
 	e.send <- episodes
 
 	return nil
 }
 
+// prune forgets results of probes that are no longer run, e.g. deleted user-defined probes
+func (e *Scheduler) prune() {
+	running := make(map[string]struct{})
+	for _, runner := range e.registry.Runners() {
+		running[runner.ProbeRef().Id()] = struct{}{}
+	}
+
+	for id := range e.results {
+		if _, ok := running[id]; ok {
+			continue
+		}
+		delete(e.results, id)
+		delete(e.series, id)
+	}
+}
+
 func (e *Scheduler) convert(start time.Time) ([]check.Episode, error) {
 	episodes := make([]check.Episode, 0, len(e.results))
 
@@ -125,6 +125,15 @@ func (d *EpisodeDao5m) Update(rowid int64, episode check.Episode) error {
 	return err
 }
 
+func (d *EpisodeDao5m) DeleteUpTo(slot time.Time) error {
+	const query = `
+	DELETE FROM episodes_5m
+	WHERE timeslot <= ?
+	`
+	_, err := d.DbCtx.StmtRunner().Exec(query, slot.Unix())
+	return err
+}
+
 // TODO (e.shevchenko): can be DRYed ? ?
 func (d *EpisodeDao5m) ListEpisodesByRange(from, to int64, ref check.ProbeRef) ([]check.Episode, error) {
 	query := selectEntityStmt + `
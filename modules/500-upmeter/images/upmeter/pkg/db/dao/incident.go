@@ -0,0 +1,213 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	dbcontext "d8.io/upmeter/pkg/db/context"
+)
+
+const incidentListSep = ":"
+
+// IncidentEntity is the automatically detected incident
+type IncidentEntity struct {
+	ID    int64
+	Start time.Time
+	// End is zero while the incident is open
+	End time.Time
+	// Groups are affected group names
+	Groups []string
+	// Probes are affected probe refs in the form of "group/probe"
+	Probes []string
+	// WorstStatus is the worst group status observed during the incident
+	WorstStatus string
+}
+
+func (e *IncidentEntity) IsOpen() bool {
+	return e.End.IsZero()
+}
+
+type IncidentDAO struct {
+	ctx *dbcontext.DbContext
+}
+
+func NewIncidentDAO(ctx *dbcontext.DbContext) *IncidentDAO {
+	return &IncidentDAO{ctx}
+}
+
+// Create saves new incident and sets its ID
+func (dao *IncidentDAO) Create(incident *IncidentEntity) error {
+	ctx := dao.ctx.Start()
+	defer ctx.Stop()
+
+	const query = `
+	INSERT INTO incidents
+		(start_time, end_time, group_names, probe_refs, worst_status)
+	VALUES
+		(@start_time, @end_time, @group_names, @probe_refs, @worst_status);
+	`
+	res, err := ctx.StmtRunner().Exec(query, incidentArgs(incident)...)
+	if err != nil {
+		return err
+	}
+
+	incident.ID, err = res.LastInsertId()
+	return err
+}
+
+func (dao *IncidentDAO) Update(incident *IncidentEntity) error {
+	ctx := dao.ctx.Start()
+	defer ctx.Stop()
+
+	const query = `
+	UPDATE incidents
+	SET
+		start_time   = @start_time,
+		end_time     = @end_time,
+		group_names  = @group_names,
+		probe_refs   = @probe_refs,
+		worst_status = @worst_status
+	WHERE
+		id = @id;
+	`
+	_, err := ctx.StmtRunner().Exec(query, incidentArgs(incident)...)
+	return err
+}
+
+// Get returns the incident by ID or ErrNotFound
+func (dao *IncidentDAO) Get(id int64) (*IncidentEntity, error) {
+	const query = selectIncidentStmt + `
+	WHERE id = @id;
+	`
+	return dao.getOne(query, sql.Named("id", id))
+}
+
+// GetOpen returns the latest open incident or ErrNotFound
+func (dao *IncidentDAO) GetOpen() (*IncidentEntity, error) {
+	const query = selectIncidentStmt + `
+	WHERE end_time = 0
+	ORDER BY start_time DESC
+	LIMIT 1;
+	`
+	return dao.getOne(query)
+}
+
+// ListByRange returns incidents overlapping with the time range ordered by start time
+func (dao *IncidentDAO) ListByRange(from, to time.Time) ([]IncidentEntity, error) {
+	ctx := dao.ctx.Start()
+	defer ctx.Stop()
+
+	const query = selectIncidentStmt + `
+	WHERE start_time < @to AND (end_time = 0 OR end_time > @from)
+	ORDER BY start_time;
+	`
+	rows, err := ctx.StmtRunner().Query(query,
+		sql.Named("from", from.Unix()),
+		sql.Named("to", to.Unix()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return parseIncidentEntities(rows)
+}
+
+const selectIncidentStmt = `
+	SELECT id, start_time, end_time, group_names, probe_refs, worst_status
+	FROM   incidents
+`
+
+func (dao *IncidentDAO) getOne(query string, args ...interface{}) (*IncidentEntity, error) {
+	ctx := dao.ctx.Start()
+	defer ctx.Stop()
+
+	rows, err := ctx.StmtRunner().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents, err := parseIncidentEntities(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(incidents) == 0 {
+		return nil, ErrNotFound
+	}
+	return &incidents[0], nil
+}
+
+func incidentArgs(incident *IncidentEntity) []interface{} {
+	var end int64
+	if !incident.IsOpen() {
+		end = incident.End.Unix()
+	}
+	return []interface{}{
+		sql.Named("id", incident.ID),
+		sql.Named("start_time", incident.Start.Unix()),
+		sql.Named("end_time", end),
+		sql.Named("group_names", joinIncidentList(incident.Groups)),
+		sql.Named("probe_refs", joinIncidentList(incident.Probes)),
+		sql.Named("worst_status", incident.WorstStatus),
+	}
+}
+
+func parseIncidentEntities(rows *sql.Rows) ([]IncidentEntity, error) {
+	incidents := make([]IncidentEntity, 0)
+
+	for rows.Next() {
+		var (
+			incident       IncidentEntity
+			start, end     int64
+			groups, probes string
+		)
+		err := rows.Scan(&incident.ID, &start, &end, &groups, &probes, &incident.WorstStatus)
+		if err != nil {
+			return nil, err
+		}
+
+		incident.Start = time.Unix(start, 0)
+		if end > 0 {
+			incident.End = time.Unix(end, 0)
+		}
+		incident.Groups = splitIncidentList(groups)
+		incident.Probes = splitIncidentList(probes)
+
+		incidents = append(incidents, incident)
+	}
+
+	return incidents, rows.Err()
+}
+
+func joinIncidentList(list []string) string {
+	sorted := make([]string, len(list))
+	copy(sorted, list)
+	sort.Strings(sorted)
+	return strings.Join(sorted, incidentListSep)
+}
+
+func splitIncidentList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, incidentListSep)
+}
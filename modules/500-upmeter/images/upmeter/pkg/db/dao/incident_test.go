@@ -0,0 +1,84 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func Test_IncidentDAO_Lifecycle(t *testing.T) {
+	g := NewWithT(t)
+	storage := NewIncidentDAO(getTestDatabase(t))
+
+	_, err := storage.GetOpen()
+	g.Expect(err).Should(Equal(ErrNotFound), "should be no open incidents in empty database")
+
+	start := time.Unix(1700000000, 0)
+	incident := &IncidentEntity{
+		Start:       start,
+		Groups:      []string{"synthetic", "control-plane"},
+		Probes:      []string{"synthetic/dns", "control-plane/apiserver"},
+		WorstStatus: "Degraded",
+	}
+	err = storage.Create(incident)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(incident.ID).ShouldNot(BeZero())
+
+	open, err := storage.GetOpen()
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(open.ID).Should(Equal(incident.ID))
+	g.Expect(open.IsOpen()).Should(BeTrue())
+	g.Expect(open.Groups).Should(Equal([]string{"control-plane", "synthetic"}))
+	g.Expect(open.Probes).Should(Equal([]string{"control-plane/apiserver", "synthetic/dns"}))
+
+	incident.End = start.Add(10 * time.Minute)
+	incident.WorstStatus = "Outage"
+	err = storage.Update(incident)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	_, err = storage.GetOpen()
+	g.Expect(err).Should(Equal(ErrNotFound), "should be no open incidents after closing")
+
+	closed, err := storage.Get(incident.ID)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(closed.End.Unix()).Should(Equal(incident.End.Unix()))
+	g.Expect(closed.WorstStatus).Should(Equal("Outage"))
+}
+
+func Test_IncidentDAO_ListByRange(t *testing.T) {
+	g := NewWithT(t)
+	storage := NewIncidentDAO(getTestDatabase(t))
+
+	base := time.Unix(1700000000, 0)
+	for _, incident := range []*IncidentEntity{
+		{Start: base, End: base.Add(time.Hour), WorstStatus: "Outage"},
+		{Start: base.Add(2 * time.Hour), End: base.Add(3 * time.Hour), WorstStatus: "Degraded"},
+		{Start: base.Add(4 * time.Hour), WorstStatus: "Degraded"},
+	} {
+		g.Expect(storage.Create(incident)).ShouldNot(HaveOccurred())
+	}
+
+	list, err := storage.ListByRange(base.Add(90*time.Minute), base.Add(5*time.Hour))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(list).Should(HaveLen(2))
+	g.Expect(list[0].Start.Unix()).Should(Equal(base.Add(2 * time.Hour).Unix()))
+	g.Expect(list[1].IsOpen()).Should(BeTrue(), "open incidents overlap any range after their start")
+	g.Expect(list[1].Groups).Should(BeEmpty())
+}
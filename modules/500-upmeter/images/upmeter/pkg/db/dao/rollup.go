@@ -0,0 +1,200 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"d8.io/upmeter/pkg/check"
+	dbcontext "d8.io/upmeter/pkg/db/context"
+	"d8.io/upmeter/pkg/server/ranges"
+)
+
+// rollupLookback is how far back rolled up episodes are recalculated on every compaction. Agents can
+// send episodes up to 24 hours late, so the source data is considered final only after this period.
+const rollupLookback = 24 * time.Hour
+
+// EpisodeRollupDao keeps episodes summed up by a longer step from the source table
+type EpisodeRollupDao struct {
+	DbCtx  *dbcontext.DbContext
+	Table  string
+	Source string
+	Step   time.Duration
+}
+
+// NewEpisodeDao1h returns DAO of hourly episodes rolled up from 5m episodes
+func NewEpisodeDao1h(dbCtx *dbcontext.DbContext) *EpisodeRollupDao {
+	return &EpisodeRollupDao{
+		DbCtx:  dbCtx,
+		Table:  "episodes_1h",
+		Source: "episodes_5m",
+		Step:   time.Hour,
+	}
+}
+
+// NewEpisodeDao1d returns DAO of daily episodes rolled up from hourly episodes
+func NewEpisodeDao1d(dbCtx *dbcontext.DbContext) *EpisodeRollupDao {
+	return &EpisodeRollupDao{
+		DbCtx:  dbCtx,
+		Table:  "episodes_1d",
+		Source: "episodes_1h",
+		Step:   24 * time.Hour,
+	}
+}
+
+// Compact sums up source episodes before the time into the table. Slots within the lookback period
+// from the latest rolled up slot are recalculated, since they could be incomplete.
+func (d *EpisodeRollupDao) Compact(to time.Time) error {
+	from, err := d.compactionStart()
+	if err != nil {
+		return err
+	}
+	if from < 0 {
+		// nothing to compact
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO %s
+		(timeslot, nano_up, nano_down, nano_unknown, nano_unmeasured, group_name, probe_name)
+	SELECT
+		timeslot - timeslot %% @step,
+		SUM(nano_up), SUM(nano_down), SUM(nano_unknown), SUM(nano_unmeasured),
+		group_name, probe_name
+	FROM
+		%s
+	WHERE
+		timeslot >= @from AND timeslot < @to
+	GROUP BY
+		timeslot - timeslot %% @step, group_name, probe_name
+	ON CONFLICT
+		(timeslot, group_name, probe_name)
+	DO UPDATE SET
+		nano_up         = excluded.nano_up,
+		nano_down       = excluded.nano_down,
+		nano_unknown    = excluded.nano_unknown,
+		nano_unmeasured = excluded.nano_unmeasured;
+	`, d.Table, d.Source)
+
+	_, err = d.DbCtx.StmtRunner().Exec(query,
+		sql.Named("step", int64(d.Step.Seconds())),
+		sql.Named("from", from),
+		sql.Named("to", to.Unix()),
+	)
+	return err
+}
+
+// compactionStart returns the slot to start the compaction from, or -1 if there is no source data
+func (d *EpisodeRollupDao) compactionStart() (int64, error) {
+	latest, err := d.queryInt64(fmt.Sprintf("SELECT MAX(timeslot) FROM %s", d.Table))
+	if err != nil {
+		return 0, err
+	}
+	if latest.Valid {
+		start := time.Unix(latest.Int64, 0).Add(-rollupLookback)
+		return start.Unix() - start.Unix()%int64(d.Step.Seconds()), nil
+	}
+
+	earliest, err := d.queryInt64(fmt.Sprintf("SELECT MIN(timeslot) FROM %s", d.Source))
+	if err != nil {
+		return 0, err
+	}
+	if !earliest.Valid {
+		return -1, nil
+	}
+	return earliest.Int64 - earliest.Int64%int64(d.Step.Seconds()), nil
+}
+
+func (d *EpisodeRollupDao) queryInt64(query string) (sql.NullInt64, error) {
+	var res sql.NullInt64
+
+	rows, err := d.DbCtx.StmtRunner().Query(query)
+	if err != nil {
+		return res, fmt.Errorf("cannot execute query: %v", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err = rows.Scan(&res)
+	}
+	return res, err
+}
+
+func (d *EpisodeRollupDao) DeleteUpTo(slot time.Time) error {
+	query := fmt.Sprintf(`
+	DELETE FROM %s
+	WHERE timeslot <= ?
+	`, d.Table)
+	_, err := d.DbCtx.StmtRunner().Exec(query, slot.Unix())
+	return err
+}
+
+// ListEpisodeSumsForRanges returns sums of episodes for each subrange grouped by group and probe. The
+// time slot of a sum is the start of the subrange.
+func (d *EpisodeRollupDao) ListEpisodeSumsForRanges(rng ranges.StepRange, ref check.ProbeRef) ([]check.Episode, error) {
+	res := make([]check.Episode, 0)
+
+	query := fmt.Sprintf(`
+	SELECT
+		SUM(nano_up), SUM(nano_down), SUM(nano_unknown), SUM(nano_unmeasured),
+		group_name, probe_name
+	FROM
+		%s
+	WHERE
+		timeslot >= @from AND timeslot < @to AND
+		(@group_name = '' OR group_name = @group_name) AND
+		(@probe_name = '%s' OR probe_name = @probe_name)
+	GROUP BY
+		group_name, probe_name
+	`, d.Table, ProbeEnumeration)
+
+	for _, subrange := range rng.Subranges {
+		episodes, err := d.sumRange(query, subrange, ref)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, episodes...)
+	}
+
+	return res, nil
+}
+
+func (d *EpisodeRollupDao) sumRange(query string, rng ranges.Range, ref check.ProbeRef) ([]check.Episode, error) {
+	rows, err := d.DbCtx.StmtRunner().Query(query,
+		sql.Named("from", rng.From),
+		sql.Named("to", rng.To),
+		sql.Named("group_name", ref.Group),
+		sql.Named("probe_name", ref.Probe),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]check.Episode, 0)
+	for rows.Next() {
+		ep := check.Episode{TimeSlot: time.Unix(rng.From, 0)}
+		err := rows.Scan(&ep.Up, &ep.Down, &ep.Unknown, &ep.NoData, &ep.ProbeRef.Group, &ep.ProbeRef.Probe)
+		if err != nil {
+			return nil, fmt.Errorf("row to episode: %v", err)
+		}
+		res = append(res, ep)
+	}
+	return res, rows.Err()
+}
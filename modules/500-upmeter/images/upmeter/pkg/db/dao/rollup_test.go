@@ -0,0 +1,98 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/server/ranges"
+)
+
+func Test_EpisodeRollupDao_Compact(t *testing.T) {
+	g := NewWithT(t)
+
+	dbCtx := getTestDatabase(t)
+	daoCtx := dbCtx.Start()
+	defer daoCtx.Stop()
+
+	dao5m := NewEpisodeDao5m(daoCtx)
+	dao1h := NewEpisodeDao1h(daoCtx)
+	dao1d := NewEpisodeDao1d(daoCtx)
+
+	ref := check.ProbeRef{Group: "control-plane", Probe: GroupAggregation}
+	day := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Two days of 5m episodes with one minute of downtime every hour
+	for slot := day; slot.Before(day.Add(48 * time.Hour)); slot = slot.Add(5 * time.Minute) {
+		ep := check.Episode{ProbeRef: ref, TimeSlot: slot, Up: 5 * time.Minute}
+		if slot.Minute() == 0 {
+			ep.Up, ep.Down = 4*time.Minute, time.Minute
+		}
+		g.Expect(dao5m.Insert(ep)).ShouldNot(HaveOccurred())
+	}
+
+	to := day.Add(48 * time.Hour)
+	g.Expect(dao1h.Compact(to)).ShouldNot(HaveOccurred())
+	g.Expect(dao1d.Compact(to)).ShouldNot(HaveOccurred())
+
+	rng := ranges.StepRange{Subranges: []ranges.Range{
+		{From: day.Unix(), To: day.Add(time.Hour).Unix()},
+		{From: day.Add(24 * time.Hour).Unix(), To: to.Unix()},
+	}}
+
+	hourly, err := dao1h.ListEpisodeSumsForRanges(rng, ref)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hourly).Should(HaveLen(2))
+	g.Expect(hourly[0].Down).Should(Equal(time.Minute))
+	g.Expect(hourly[0].Up).Should(Equal(59 * time.Minute))
+	g.Expect(hourly[1].Down).Should(Equal(24 * time.Minute))
+
+	daily, err := dao1d.ListEpisodeSumsForRanges(ranges.StepRange{Subranges: []ranges.Range{{From: day.Unix(), To: to.Unix()}}}, ref)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(daily).Should(HaveLen(1))
+	g.Expect(daily[0].Down).Should(Equal(48 * time.Minute))
+	g.Expect(daily[0].Up).Should(Equal(48*time.Hour - 48*time.Minute))
+
+	// Compacting again does not change sums
+	g.Expect(dao1h.Compact(to)).ShouldNot(HaveOccurred())
+	g.Expect(dao1d.Compact(to)).ShouldNot(HaveOccurred())
+	daily, err = dao1d.ListEpisodeSumsForRanges(ranges.StepRange{Subranges: []ranges.Range{{From: day.Unix(), To: to.Unix()}}}, ref)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(daily[0].Down).Should(Equal(48 * time.Minute))
+
+	// Source data can be deleted after the compaction
+	g.Expect(dao5m.DeleteUpTo(to)).ShouldNot(HaveOccurred())
+	g.Expect(dao1h.DeleteUpTo(day.Add(23 * time.Hour))).ShouldNot(HaveOccurred())
+	hourly, err = dao1h.ListEpisodeSumsForRanges(ranges.StepRange{Subranges: []ranges.Range{{From: day.Unix(), To: to.Unix()}}}, ref)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(hourly[0].Down).Should(Equal(24 * time.Minute))
+}
+
+func Test_EpisodeRollupDao_Compact_Empty(t *testing.T) {
+	g := NewWithT(t)
+
+	dbCtx := getTestDatabase(t)
+	daoCtx := dbCtx.Start()
+	defer daoCtx.Stop()
+
+	g.Expect(NewEpisodeDao1h(daoCtx).Compact(time.Now())).ShouldNot(HaveOccurred())
+	g.Expect(NewEpisodeDao1d(daoCtx).Compact(time.Now())).ShouldNot(HaveOccurred())
+}
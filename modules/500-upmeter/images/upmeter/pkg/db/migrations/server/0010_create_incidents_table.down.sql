@@ -0,0 +1,6 @@
+BEGIN IMMEDIATE;
+
+DROP INDEX IF EXISTS incidents_start_time;
+DROP TABLE IF EXISTS incidents;
+
+COMMIT;
@@ -0,0 +1,22 @@
+/*
+
+This migration creates the table for incidents detected automatically from episodes. An incident is open while
+"end_time" is zero. Group names and probe refs are stored as sorted colon-separated lists.
+
+*/
+
+BEGIN IMMEDIATE;
+
+CREATE TABLE IF NOT EXISTS incidents
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_time   INTEGER NOT NULL,
+    end_time     INTEGER NOT NULL DEFAULT 0,
+    group_names  TEXT    NOT NULL,
+    probe_refs   TEXT    NOT NULL,
+    worst_status TEXT    NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS incidents_start_time ON incidents (start_time);
+
+COMMIT;
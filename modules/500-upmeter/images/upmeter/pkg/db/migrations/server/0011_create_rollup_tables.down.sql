@@ -0,0 +1,8 @@
+BEGIN IMMEDIATE;
+
+DROP INDEX IF EXISTS episodes_1h_time_group_probe;
+DROP INDEX IF EXISTS episodes_1d_time_group_probe;
+DROP TABLE IF EXISTS episodes_1h;
+DROP TABLE IF EXISTS episodes_1d;
+
+COMMIT;
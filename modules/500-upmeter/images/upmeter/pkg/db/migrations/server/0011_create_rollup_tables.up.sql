@@ -0,0 +1,36 @@
+/*
+
+This migration creates tables for long-term episodes summed up by hour and by day. They are filled by the
+compactor in the background from 5m and hourly episodes respectively, so that long-term reports do not have
+to scan short episodes. Time slots are aligned to UTC.
+
+*/
+
+BEGIN IMMEDIATE;
+
+CREATE TABLE IF NOT EXISTS episodes_1h
+(
+    timeslot        INTEGER NOT NULL,
+    nano_up         INTEGER NOT NULL,
+    nano_down       INTEGER NOT NULL,
+    nano_unknown    INTEGER NOT NULL DEFAULT 0,
+    nano_unmeasured INTEGER NOT NULL DEFAULT 0,
+    group_name      TEXT    NOT NULL,
+    probe_name      TEXT    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS episodes_1d
+(
+    timeslot        INTEGER NOT NULL,
+    nano_up         INTEGER NOT NULL,
+    nano_down       INTEGER NOT NULL,
+    nano_unknown    INTEGER NOT NULL DEFAULT 0,
+    nano_unmeasured INTEGER NOT NULL DEFAULT 0,
+    group_name      TEXT    NOT NULL,
+    probe_name      TEXT    NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS episodes_1h_time_group_probe ON episodes_1h (timeslot, group_name, probe_name);
+CREATE UNIQUE INDEX IF NOT EXISTS episodes_1d_time_group_probe ON episodes_1d (timeslot, group_name, probe_name);
+
+COMMIT;
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customprobe
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ProbeType string
+
+const (
+	ProbeTypeHTTP ProbeType = "HTTP"
+	ProbeTypeTCP  ProbeType = "TCP"
+	ProbeTypeDNS  ProbeType = "DNS"
+	ProbeTypeGRPC ProbeType = "GRPC"
+)
+
+// Spec is the spec in the UpmeterCustomProbe CRD
+type Spec struct {
+	Group          string    `json:"group"`
+	Probe          string    `json:"probe"`
+	PeriodSeconds  int       `json:"periodSeconds,omitempty"`
+	TimeoutSeconds int       `json:"timeoutSeconds,omitempty"`
+	Type           ProbeType `json:"type"`
+
+	HTTP *HTTPSpec `json:"http,omitempty"`
+	TCP  *TCPSpec  `json:"tcp,omitempty"`
+	DNS  *DNSSpec  `json:"dns,omitempty"`
+	GRPC *GRPCSpec `json:"grpc,omitempty"`
+}
+
+type HTTPSpec struct {
+	URL                 string            `json:"url"`
+	Method              string            `json:"method,omitempty"`
+	Headers             map[string]string `json:"headers,omitempty"`
+	ExpectedStatusCodes []int             `json:"expectedStatusCodes,omitempty"`
+	ExpectedBody        string            `json:"expectedBody,omitempty"`
+	InsecureSkipVerify  bool              `json:"insecureSkipVerify,omitempty"`
+}
+
+type TCPSpec struct {
+	Address string `json:"address"`
+}
+
+type DNSSpec struct {
+	Name   string `json:"name"`
+	Server string `json:"server,omitempty"`
+}
+
+type GRPCSpec struct {
+	Address            string `json:"address"`
+	Service            string `json:"service,omitempty"`
+	TLS                bool   `json:"tls,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
+
+// CustomProbe is the Schema for user-defined probes
+type CustomProbe struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec Spec `json:"spec,omitempty"`
+}
+
+// Owner returns the object that defines the probe among objects with the same group and probe: the oldest
+// one, or the first by name if they are created at the same second.
+func Owner(probes []*CustomProbe) *CustomProbe {
+	var owner *CustomProbe
+	for _, cp := range probes {
+		if owner == nil || olderThan(cp, owner) {
+			owner = cp
+		}
+	}
+	return owner
+}
+
+func olderThan(a, b *CustomProbe) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// CustomProbeList contains a list of CustomProbe objects
+type CustomProbeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []CustomProbe `json:"items"`
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package customprobe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Test that the same object defines the probe regardless of the order of objects
+func TestOwner(t *testing.T) {
+	created := time.Date(2023, 6, 14, 10, 0, 0, 0, time.UTC)
+	newProbe := func(name string, age time.Duration) *CustomProbe {
+		return &CustomProbe{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created.Add(-age)),
+		}}
+	}
+
+	oldest := newProbe("b", time.Hour)
+	sameAge := newProbe("c", time.Hour)
+	newest := newProbe("a", 0)
+
+	assert.Equal(t, oldest, Owner([]*CustomProbe{newest, sameAge, oldest}))
+	assert.Equal(t, oldest, Owner([]*CustomProbe{oldest, newest, sameAge}))
+	assert.Equal(t, newest, Owner([]*CustomProbe{newest}))
+	assert.Nil(t, Owner(nil))
+}
@@ -79,10 +79,12 @@ func (m *Monitor) Stop() {
 	close(m.stopCh)
 }
 
-func (m *Monitor) List() ([]check.DowntimeIncident, error) {
+// List returns incidents overlapping with [from, to) in unix seconds, recurring downtimes are expanded
+// into occurrences
+func (m *Monitor) List(from, to int64) ([]check.DowntimeIncident, error) {
 	res := make([]check.DowntimeIncident, 0)
 	for _, obj := range m.informer.GetStore().List() {
-		incs, err := convert(obj)
+		incs, err := convert(obj, from, to)
 		if err != nil {
 			return nil, err
 		}
@@ -92,7 +94,7 @@ func (m *Monitor) List() ([]check.DowntimeIncident, error) {
 	return res, nil
 }
 
-func convert(o interface{}) ([]check.DowntimeIncident, error) {
+func convert(o interface{}, from, to int64) ([]check.DowntimeIncident, error) {
 	unstrObj, ok := o.(*unstructured.Unstructured)
 	if !ok {
 		return nil, fmt.Errorf("cannot convert object to *unstructured.Unstructured: %v", o)
@@ -102,5 +104,5 @@ func convert(o interface{}) ([]check.DowntimeIncident, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot convert unstructured to Downtime: %v", err)
 	}
-	return incidentObj.GetDowntimeIncidents(), nil
+	return incidentObj.GetDowntimeIncidents(from, to), nil
 }
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cron "gopkg.in/robfig/cron.v2"
+)
+
+// maxOccurrences limits the expansion of a recurring downtime within a single range
+const maxOccurrences = 10000
+
+// Recurrence repeats the downtime on a schedule. Either Schedule or Weekdays with Time is set.
+type Recurrence struct {
+	// Schedule is the cron spec of occurrence starts, e.g. "0 2 * * 6"
+	Schedule string `json:"schedule,omitempty"`
+	// Weekdays are the days of occurrences, e.g. "Saturday"
+	Weekdays []string `json:"weekdays,omitempty"`
+	// Time is the start time of occurrences in the form of HH:MM
+	Time string `json:"time,omitempty"`
+	// Duration is the length of every occurrence, e.g. "2h30m"
+	Duration string `json:"duration"`
+	// TimeZone is the IANA time zone name of the schedule, UTC by default
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+var weekdayNumbers = map[string]int{
+	"Sunday":    0,
+	"Monday":    1,
+	"Tuesday":   2,
+	"Wednesday": 3,
+	"Thursday":  4,
+	"Friday":    5,
+	"Saturday":  6,
+}
+
+// occurrence is the [start, end) interval in unix seconds
+type occurrence struct {
+	start, end int64
+}
+
+type recurrenceSchedule struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+func parseRecurrence(r *Recurrence) (*recurrenceSchedule, error) {
+	duration, err := time.ParseDuration(r.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q: %v", r.Duration, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive, got %q", r.Duration)
+	}
+
+	tz := r.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %v", tz, err)
+	}
+
+	spec := r.Schedule
+	if spec == "" {
+		spec, err = weekdaysToCron(r.Weekdays, r.Time)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(strings.Fields(spec)) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields", spec)
+	}
+
+	schedule, err := cron.Parse(fmt.Sprintf("TZ=%s %s", tz, spec))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %v", spec, err)
+	}
+
+	return &recurrenceSchedule{schedule: schedule, duration: duration}, nil
+}
+
+func weekdaysToCron(weekdays []string, hhmm string) (string, error) {
+	if len(weekdays) == 0 {
+		return "", fmt.Errorf("either schedule or weekdays must be set")
+	}
+
+	days := make([]string, 0, len(weekdays))
+	for _, wd := range weekdays {
+		n, ok := weekdayNumbers[wd]
+		if !ok {
+			return "", fmt.Errorf("unknown weekday %q", wd)
+		}
+		days = append(days, strconv.Itoa(n))
+	}
+
+	t, err := time.Parse("15:04", hhmm)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q, expected HH:MM: %v", hhmm, err)
+	}
+
+	return fmt.Sprintf("%d %d * * %s", t.Minute(), t.Hour(), strings.Join(days, ",")), nil
+}
+
+// occurrences returns occurrences overlapping with [from, to) that start within [notBefore, notAfter).
+// Zero bounds are ignored.
+func (r *recurrenceSchedule) occurrences(from, to, notBefore, notAfter int64) []occurrence {
+	dur := int64(r.duration.Seconds())
+
+	lookup := from - dur
+	if notBefore > 0 && lookup < notBefore {
+		lookup = notBefore
+	}
+	if notAfter > 0 && to > notAfter {
+		to = notAfter
+	}
+
+	res := make([]occurrence, 0)
+	// Next returns the time strictly after the argument, so step back a second to include the bound
+	t := time.Unix(lookup-1, 0)
+	for len(res) < maxOccurrences {
+		t = r.schedule.Next(t)
+		if t.IsZero() || t.Unix() >= to {
+			break
+		}
+		start := t.Unix()
+		if start+dur <= from {
+			continue
+		}
+		res = append(res, occurrence{start: start, end: start + dur})
+	}
+	return res
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package downtime
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_weekdaysToCron(t *testing.T) {
+	tests := []struct {
+		name     string
+		weekdays []string
+		time     string
+		want     string
+		wantErr  bool
+	}{
+		{name: "single day", weekdays: []string{"Saturday"}, time: "02:30", want: "30 2 * * 6"},
+		{name: "several days", weekdays: []string{"Monday", "Sunday"}, time: "23:00", want: "0 23 * * 1,0"},
+		{name: "no days", time: "02:30", wantErr: true},
+		{name: "unknown day", weekdays: []string{"Caturday"}, time: "02:30", wantErr: true},
+		{name: "bad time", weekdays: []string{"Monday"}, time: "2pm", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := weekdaysToCron(tt.weekdays, tt.time)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseRecurrence_Invalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+	}{
+		{name: "no duration", recurrence: Recurrence{Schedule: "0 2 * * 6"}},
+		{name: "negative duration", recurrence: Recurrence{Schedule: "0 2 * * 6", Duration: "-1h"}},
+		{name: "bad time zone", recurrence: Recurrence{Schedule: "0 2 * * 6", Duration: "1h", TimeZone: "Mars/Olympus"}},
+		{name: "seconds field", recurrence: Recurrence{Schedule: "0 0 2 * * 6", Duration: "1h"}},
+		{name: "bad schedule", recurrence: Recurrence{Schedule: "0 25 * * 6", Duration: "1h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRecurrence(&tt.recurrence); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func Test_GetDowntimeIncidents_Recurring(t *testing.T) {
+	// Saturday, 2023-01-07 00:00 UTC
+	saturday := time.Date(2023, 1, 7, 0, 0, 0, 0, time.UTC)
+
+	dt := Downtime{Spec: []Spec{
+		{
+			StartDate: "2023-01-01T00:00:00Z",
+			Type:      "Maintenance",
+			Affected:  []string{"control-plane"},
+			Recurrence: &Recurrence{
+				Weekdays: []string{"Saturday"},
+				Time:     "02:00",
+				Duration: "2h",
+				TimeZone: "Europe/Berlin", // UTC+1 in winter
+			},
+		},
+		{
+			StartDate: "2023-01-01T00:00:00Z",
+			EndDate:   "2023-01-02T00:00:00Z",
+			Type:      "Accident",
+		},
+	}}
+	dt.Name = "weekly"
+
+	// Three weeks ahead, with the range starting in the middle of the first occurrence
+	from := saturday.Add(2 * time.Hour).Unix()
+	to := saturday.Add(21 * 24 * time.Hour).Unix()
+
+	incidents := dt.GetDowntimeIncidents(from, to)
+
+	if len(incidents) != 3 {
+		t.Fatalf("expected 3 occurrences, got %d: %+v", len(incidents), incidents)
+	}
+	for i, inc := range incidents {
+		wantStart := saturday.Add(time.Duration(i)*7*24*time.Hour + time.Hour).Unix()
+		if inc.Start != wantStart || inc.End != wantStart+7200 {
+			t.Errorf("occurrence %d: got [%d, %d), want [%d, %d)", i, inc.Start, inc.End, wantStart, wantStart+7200)
+		}
+		if inc.Type != "Maintenance" || inc.DowntimeName != "weekly" || inc.Affected[0] != "control-plane" {
+			t.Errorf("occurrence %d: unexpected fields %+v", i, inc)
+		}
+	}
+}
+
+func Test_GetDowntimeIncidents_RecurrenceBounds(t *testing.T) {
+	dt := Downtime{Spec: []Spec{{
+		StartDate: "2023-01-02T00:00:00Z",
+		EndDate:   "2023-01-05T00:00:00Z",
+		Type:      "Maintenance",
+		Recurrence: &Recurrence{
+			Schedule: "0 12 * * *",
+			Duration: "30m",
+		},
+	}}}
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC).Unix()
+
+	incidents := dt.GetDowntimeIncidents(from, to)
+
+	// Jan 2, 3 and 4
+	if len(incidents) != 3 {
+		t.Fatalf("expected 3 occurrences, got %d", len(incidents))
+	}
+	if got, want := incidents[0].Start, time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC).Unix(); got != want {
+		t.Errorf("first occurrence starts at %d, want %d", got, want)
+	}
+}
@@ -28,11 +28,12 @@ import (
 )
 
 type Spec struct {
-	StartDate   string   `json:"startDate"`
-	EndDate     string   `json:"endDate"`
-	Type        string   `json:"type"`
-	Description string   `json:"description"`
-	Affected    []string `json:"affected"`
+	StartDate   string      `json:"startDate"`
+	EndDate     string      `json:"endDate"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Affected    []string    `json:"affected"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
 }
 
 // Downtime is the Schema for the downtime incidents
@@ -50,10 +51,19 @@ type DowntimeList struct {
 	Items           []Downtime `json:"items"`
 }
 
+// GetDowntimeIncidents returns incidents overlapping with [from, to). Recurring downtimes are expanded
+// into separate incidents for every occurrence, their startDate and endDate limit the period when the
+// recurrence is in effect.
+//
 // TODO use FilterFunc and store an array of DowntimeIncidents in a filterResult object.
-func (d Downtime) GetDowntimeIncidents() []check.DowntimeIncident {
+func (d Downtime) GetDowntimeIncidents(from, to int64) []check.DowntimeIncident {
 	res := make([]check.DowntimeIncident, 0)
 	for _, obj := range d.Spec {
+		if obj.Recurrence != nil {
+			res = append(res, d.getRecurringIncidents(obj, from, to)...)
+			continue
+		}
+
 		start, err := DateToSeconds(obj.StartDate)
 		if err != nil {
 			log.Errorf("convert startDate '%s' in %s: %v", obj.StartDate, d.Name, err)
@@ -64,21 +74,56 @@ func (d Downtime) GetDowntimeIncidents() []check.DowntimeIncident {
 			log.Errorf("convert endDate '%s' in %s: %v", obj.EndDate, d.Name, err)
 			continue
 		}
-		inc := check.DowntimeIncident{
-			Start:        start,
-			End:          end,
-			Duration:     0,
-			Type:         obj.Type,
-			Description:  obj.Description,
-			Affected:     obj.Affected,
-			DowntimeName: d.Name,
+		if start >= to || end <= from {
+			continue
+		}
+		res = append(res, newIncident(d.Name, obj, start, end))
+	}
+
+	return res
+}
+
+func (d Downtime) getRecurringIncidents(obj Spec, from, to int64) []check.DowntimeIncident {
+	res := make([]check.DowntimeIncident, 0)
+
+	schedule, err := parseRecurrence(obj.Recurrence)
+	if err != nil {
+		log.Errorf("parse recurrence in %s: %v", d.Name, err)
+		return res
+	}
+
+	var notBefore, notAfter int64
+	if obj.StartDate != "" {
+		if notBefore, err = DateToSeconds(obj.StartDate); err != nil {
+			log.Errorf("convert startDate '%s' in %s: %v", obj.StartDate, d.Name, err)
+			return res
+		}
+	}
+	if obj.EndDate != "" {
+		if notAfter, err = DateToSeconds(obj.EndDate); err != nil {
+			log.Errorf("convert endDate '%s' in %s: %v", obj.EndDate, d.Name, err)
+			return res
 		}
-		res = append(res, inc)
 	}
 
+	for _, occ := range schedule.occurrences(from, to, notBefore, notAfter) {
+		res = append(res, newIncident(d.Name, obj, occ.start, occ.end))
+	}
 	return res
 }
 
+func newIncident(name string, obj Spec, start, end int64) check.DowntimeIncident {
+	return check.DowntimeIncident{
+		Start:        start,
+		End:          end,
+		Duration:     0,
+		Type:         obj.Type,
+		Description:  obj.Description,
+		Affected:     obj.Affected,
+		DowntimeName: name,
+	}
+}
+
 func DateToSeconds(d string) (int64, error) {
 	t, err := time.Parse(time.RFC3339, d)
 	if err == nil {
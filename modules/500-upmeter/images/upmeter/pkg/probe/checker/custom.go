@@ -0,0 +1,266 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"d8.io/upmeter/pkg/check"
+)
+
+// maxCustomBodySize limits the response body read by user-defined HTTP probes
+const maxCustomBodySize = 1 << 20
+
+// HTTPEndpointAvailable is a checker constructor and configurator for user-defined HTTP(S) probes
+type HTTPEndpointAvailable struct {
+	URL                 string
+	Method              string
+	Headers             map[string]string
+	ExpectedStatusCodes []int
+	ExpectedBody        *regexp.Regexp
+	InsecureSkipVerify  bool
+	UserAgent           string
+	Timeout             time.Duration
+}
+
+func (c HTTPEndpointAvailable) Checker() check.Checker {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify},
+			DisableKeepAlives: true,
+		},
+		Timeout: c.Timeout,
+	}
+
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	expectedCodes := c.ExpectedStatusCodes
+	if len(expectedCodes) == 0 {
+		expectedCodes = []int{http.StatusOK}
+	}
+
+	return &httpEndpointChecker{
+		client:        client,
+		url:           c.URL,
+		method:        method,
+		headers:       c.Headers,
+		userAgent:     c.UserAgent,
+		expectedCodes: expectedCodes,
+		expectedBody:  c.ExpectedBody,
+	}
+}
+
+type httpEndpointChecker struct {
+	client        *http.Client
+	url           string
+	method        string
+	headers       map[string]string
+	userAgent     string
+	expectedCodes []int
+	expectedBody  *regexp.Regexp
+}
+
+func (c *httpEndpointChecker) Check() check.Error {
+	req, err := http.NewRequest(c.method, c.url, nil)
+	if err != nil {
+		return check.ErrUnknown("cannot create request: %v", err)
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	for name, value := range c.headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return check.ErrFail("cannot dial %q: %v", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCustomBodySize))
+	if err != nil {
+		return check.ErrFail("cannot read response body: %v", err)
+	}
+
+	if !c.isExpectedCode(resp.StatusCode) {
+		return check.ErrFail("HTTP: %s %s returned unexpected status %d", c.method, c.url, resp.StatusCode)
+	}
+
+	if c.expectedBody != nil && !c.expectedBody.Match(body) {
+		return check.ErrFail("HTTP: %s %s response body does not match %q", c.method, c.url, c.expectedBody.String())
+	}
+
+	return nil
+}
+
+func (c *httpEndpointChecker) isExpectedCode(code int) bool {
+	for _, expected := range c.expectedCodes {
+		if code == expected {
+			return true
+		}
+	}
+	return false
+}
+
+// TCPPortAvailable is a checker constructor and configurator for user-defined TCP probes
+type TCPPortAvailable struct {
+	Address string
+	Timeout time.Duration
+}
+
+func (c TCPPortAvailable) Checker() check.Checker {
+	return &tcpChecker{
+		address: c.Address,
+		timeout: c.Timeout,
+	}
+}
+
+type tcpChecker struct {
+	address string
+	timeout time.Duration
+}
+
+func (c *tcpChecker) Check() check.Error {
+	conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+	if err != nil {
+		return check.ErrFail("cannot connect to %q: %v", c.address, err)
+	}
+	_ = conn.Close()
+	return nil
+}
+
+// DNSNameResolvable is a checker constructor and configurator for user-defined DNS probes. If the
+// server is not specified, the system resolver is used.
+type DNSNameResolvable struct {
+	Name    string
+	Server  string
+	Timeout time.Duration
+}
+
+func (c DNSNameResolvable) Checker() check.Checker {
+	resolver := &net.Resolver{}
+	if c.Server != "" {
+		server := c.Server
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	return &dnsResolveChecker{
+		name:     c.Name,
+		resolver: resolver,
+		timeout:  c.Timeout,
+	}
+}
+
+type dnsResolveChecker struct {
+	name     string
+	resolver *net.Resolver
+	timeout  time.Duration
+}
+
+func (c *dnsResolveChecker) Check() check.Error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	addrs, err := c.resolver.LookupHost(ctx, c.name)
+	if err != nil {
+		return check.ErrFail("cannot resolve %q: %v", c.name, err)
+	}
+	if len(addrs) == 0 {
+		return check.ErrFail("resolved no addresses for %q", c.name)
+	}
+	return nil
+}
+
+// GRPCHealthServing is a checker constructor and configurator for user-defined gRPC probes. It uses
+// the standard gRPC health checking protocol.
+type GRPCHealthServing struct {
+	Address            string
+	Service            string
+	TLS                bool
+	InsecureSkipVerify bool
+	UserAgent          string
+	Timeout            time.Duration
+}
+
+func (c GRPCHealthServing) Checker() check.Checker {
+	transport := grpc.WithInsecure()
+	if c.TLS {
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}))
+	}
+
+	return &grpcHealthChecker{
+		address: c.Address,
+		service: c.Service,
+		timeout: c.Timeout,
+		dialOptions: []grpc.DialOption{
+			transport,
+			grpc.WithBlock(),
+			grpc.WithUserAgent(c.UserAgent),
+		},
+	}
+}
+
+type grpcHealthChecker struct {
+	address     string
+	service     string
+	timeout     time.Duration
+	dialOptions []grpc.DialOption
+}
+
+func (c *grpcHealthChecker) Check() check.Error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, c.address, c.dialOptions...)
+	if err != nil {
+		return check.ErrFail("cannot connect to %q: %v", c.address, err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: c.service})
+	if err != nil {
+		return check.ErrFail("gRPC health check of %q failed: %v", c.address, err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return check.ErrFail("gRPC health check of %q returned status %s", c.address, resp.GetStatus())
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checker
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"d8.io/upmeter/pkg/check"
+)
+
+func Test_HTTPEndpointAvailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(`{"status": "ok"}`))
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		case "/header":
+			if r.Header.Get("X-Token") != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+			}
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		config HTTPEndpointAvailable
+		status check.Status
+	}{
+		{
+			name:   "default status code",
+			config: HTTPEndpointAvailable{URL: server.URL + "/ok"},
+			status: check.Up,
+		},
+		{
+			name:   "failing status code",
+			config: HTTPEndpointAvailable{URL: server.URL + "/fail"},
+			status: check.Down,
+		},
+		{
+			name:   "expected status code",
+			config: HTTPEndpointAvailable{URL: server.URL + "/created", ExpectedStatusCodes: []int{200, 201}},
+			status: check.Up,
+		},
+		{
+			name:   "unexpected status code",
+			config: HTTPEndpointAvailable{URL: server.URL + "/ok", ExpectedStatusCodes: []int{204}},
+			status: check.Down,
+		},
+		{
+			name:   "matching body",
+			config: HTTPEndpointAvailable{URL: server.URL + "/ok", ExpectedBody: regexp.MustCompile(`"status":\s*"ok"`)},
+			status: check.Up,
+		},
+		{
+			name:   "not matching body",
+			config: HTTPEndpointAvailable{URL: server.URL + "/ok", ExpectedBody: regexp.MustCompile(`"status":\s*"degraded"`)},
+			status: check.Down,
+		},
+		{
+			name:   "headers",
+			config: HTTPEndpointAvailable{URL: server.URL + "/header", Headers: map[string]string{"X-Token": "secret"}},
+			status: check.Up,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Timeout = time.Second
+			assertCheckStatus(t, tt.status, tt.config.Checker().Check())
+		})
+	}
+}
+
+func Test_TCPPortAvailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	address := listener.Addr().String()
+
+	available := TCPPortAvailable{Address: address, Timeout: time.Second}
+	assertCheckStatus(t, check.Up, available.Checker().Check())
+
+	_ = listener.Close()
+	assertCheckStatus(t, check.Down, available.Checker().Check())
+}
+
+func Test_DNSNameResolvable(t *testing.T) {
+	resolvable := DNSNameResolvable{Name: "localhost", Timeout: time.Second}
+	assertCheckStatus(t, check.Up, resolvable.Checker().Check())
+
+	unresolvable := DNSNameResolvable{Name: "nonexistent.invalid", Timeout: time.Second}
+	assertCheckStatus(t, check.Down, unresolvable.Checker().Check())
+}
+
+func Test_GRPCHealthServing(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("serving", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("not-serving", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	address := listener.Addr().String()
+
+	serving := GRPCHealthServing{Address: address, Service: "serving", Timeout: time.Second}
+	assertCheckStatus(t, check.Up, serving.Checker().Check())
+
+	overall := GRPCHealthServing{Address: address, Timeout: time.Second}
+	assertCheckStatus(t, check.Up, overall.Checker().Check())
+
+	notServing := GRPCHealthServing{Address: address, Service: "not-serving", Timeout: time.Second}
+	assertCheckStatus(t, check.Down, notServing.Checker().Check())
+
+	unknown := GRPCHealthServing{Address: address, Service: "unknown", Timeout: time.Second}
+	assertCheckStatus(t, check.Down, unknown.Checker().Check())
+}
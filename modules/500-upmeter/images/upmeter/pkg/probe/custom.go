@@ -0,0 +1,188 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/monitor/customprobe"
+	"d8.io/upmeter/pkg/probe/checker"
+	"d8.io/upmeter/pkg/set"
+)
+
+const (
+	customProbeDefaultPeriod  = 5 * time.Second
+	customProbeDefaultTimeout = 5 * time.Second
+)
+
+// NewCustomRunner creates the runner for the user-defined probe. The object name is used as the check
+// name.
+func NewCustomRunner(cp *customprobe.CustomProbe, userAgent string, logger *logrus.Logger) (*check.Runner, error) {
+	rc, err := customRunnerConfig(cp, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	runnerLogger := logger.WithFields(map[string]interface{}{
+		"group": rc.group,
+		"probe": rc.probe,
+		"check": rc.check,
+	})
+
+	return check.NewRunner(rc.group, rc.probe, rc.check, rc.period, rc.config.Checker(), runnerLogger), nil
+}
+
+func customRunnerConfig(cp *customprobe.CustomProbe, userAgent string) (runnerConfig, error) {
+	spec := cp.Spec
+
+	if spec.Group == "" || spec.Probe == "" {
+		return runnerConfig{}, fmt.Errorf("group and probe must be specified")
+	}
+
+	period := customProbeDefaultPeriod
+	if spec.PeriodSeconds > 0 {
+		period = time.Duration(spec.PeriodSeconds) * time.Second
+	}
+
+	timeout := customProbeDefaultTimeout
+	if spec.TimeoutSeconds > 0 {
+		timeout = time.Duration(spec.TimeoutSeconds) * time.Second
+	}
+
+	var config checker.Config
+	switch spec.Type {
+	case customprobe.ProbeTypeHTTP:
+		if spec.HTTP == nil {
+			return runnerConfig{}, fmt.Errorf("http parameters must be specified for %s probe", spec.Type)
+		}
+		var expectedBody *regexp.Regexp
+		if spec.HTTP.ExpectedBody != "" {
+			re, err := regexp.Compile(spec.HTTP.ExpectedBody)
+			if err != nil {
+				return runnerConfig{}, fmt.Errorf("invalid expectedBody: %v", err)
+			}
+			expectedBody = re
+		}
+		config = checker.HTTPEndpointAvailable{
+			URL:                 spec.HTTP.URL,
+			Method:              spec.HTTP.Method,
+			Headers:             spec.HTTP.Headers,
+			ExpectedStatusCodes: spec.HTTP.ExpectedStatusCodes,
+			ExpectedBody:        expectedBody,
+			InsecureSkipVerify:  spec.HTTP.InsecureSkipVerify,
+			UserAgent:           userAgent,
+			Timeout:             timeout,
+		}
+
+	case customprobe.ProbeTypeTCP:
+		if spec.TCP == nil {
+			return runnerConfig{}, fmt.Errorf("tcp parameters must be specified for %s probe", spec.Type)
+		}
+		config = checker.TCPPortAvailable{
+			Address: spec.TCP.Address,
+			Timeout: timeout,
+		}
+
+	case customprobe.ProbeTypeDNS:
+		if spec.DNS == nil {
+			return runnerConfig{}, fmt.Errorf("dns parameters must be specified for %s probe", spec.Type)
+		}
+		config = checker.DNSNameResolvable{
+			Name:    spec.DNS.Name,
+			Server:  spec.DNS.Server,
+			Timeout: timeout,
+		}
+
+	case customprobe.ProbeTypeGRPC:
+		if spec.GRPC == nil {
+			return runnerConfig{}, fmt.Errorf("grpc parameters must be specified for %s probe", spec.Type)
+		}
+		config = checker.GRPCHealthServing{
+			Address:            spec.GRPC.Address,
+			Service:            spec.GRPC.Service,
+			TLS:                spec.GRPC.TLS,
+			InsecureSkipVerify: spec.GRPC.InsecureSkipVerify,
+			UserAgent:          userAgent,
+			Timeout:            timeout,
+		}
+
+	default:
+		return runnerConfig{}, fmt.Errorf("unsupported probe type %q", spec.Type)
+	}
+
+	return runnerConfig{
+		group:  spec.Group,
+		probe:  spec.Probe,
+		check:  cp.GetName(),
+		period: period,
+		config: config,
+	}, nil
+}
+
+// CustomProbeLister lists groups and probes of user-defined probes. Unlike the Loader, it does not
+// cache the result, because probes are added and removed at runtime.
+type CustomProbeLister struct {
+	monitor *customprobe.Monitor
+	filter  Filter
+	logger  *logrus.Logger
+}
+
+func NewCustomProbeLister(monitor *customprobe.Monitor, filter Filter, logger *logrus.Logger) *CustomProbeLister {
+	return &CustomProbeLister{
+		monitor: monitor,
+		filter:  filter,
+		logger:  logger,
+	}
+}
+
+func (l *CustomProbeLister) Groups() []string {
+	groups := set.New()
+	for _, ref := range l.Probes() {
+		groups.Add(ref.Group)
+	}
+	return groups.Slice()
+}
+
+func (l *CustomProbeLister) Probes() []check.ProbeRef {
+	cps, err := l.monitor.List()
+	if err != nil {
+		l.logger.Errorf("cannot list custom probes: %v", err)
+		return []check.ProbeRef{}
+	}
+
+	seen := set.New()
+	refs := make([]check.ProbeRef, 0)
+	for _, cp := range cps {
+		ref := check.ProbeRef{Group: cp.Spec.Group, Probe: cp.Spec.Probe}
+		if ref.Group == "" || ref.Probe == "" || !l.filter.Enabled(ref) {
+			continue
+		}
+		if seen.Has(ref.Id()) {
+			continue
+		}
+		seen.Add(ref.Id())
+		refs = append(refs, ref)
+	}
+	sort.Sort(check.ByProbeRef(refs))
+	return refs
+}
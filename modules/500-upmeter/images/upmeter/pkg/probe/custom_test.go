@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package probe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"d8.io/upmeter/pkg/monitor/customprobe"
+	"d8.io/upmeter/pkg/probe/checker"
+)
+
+func Test_customRunnerConfig(t *testing.T) {
+	newProbe := func(spec customprobe.Spec) *customprobe.CustomProbe {
+		spec.Group = "my-app"
+		spec.Probe = "api"
+		return &customprobe.CustomProbe{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-app-api"},
+			Spec:       spec,
+		}
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		rc, err := customRunnerConfig(newProbe(customprobe.Spec{
+			Type: customprobe.ProbeTypeTCP,
+			TCP:  &customprobe.TCPSpec{Address: "db:5432"},
+		}), "upmeter/test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "my-app", rc.group)
+		assert.Equal(t, "api", rc.probe)
+		assert.Equal(t, "my-app-api", rc.check)
+		assert.Equal(t, 5*time.Second, rc.period)
+		assert.Equal(t, checker.TCPPortAvailable{Address: "db:5432", Timeout: 5 * time.Second}, rc.config)
+	})
+
+	t.Run("http", func(t *testing.T) {
+		rc, err := customRunnerConfig(newProbe(customprobe.Spec{
+			Type:           customprobe.ProbeTypeHTTP,
+			PeriodSeconds:  30,
+			TimeoutSeconds: 3,
+			HTTP: &customprobe.HTTPSpec{
+				URL:                 "https://my-app.example.com/healthz",
+				ExpectedStatusCodes: []int{200, 204},
+				ExpectedBody:        `"status":\s*"ok"`,
+			},
+		}), "upmeter/test")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Second, rc.period)
+
+		config := rc.config.(checker.HTTPEndpointAvailable)
+		assert.Equal(t, 3*time.Second, config.Timeout)
+		assert.Equal(t, "upmeter/test", config.UserAgent)
+		assert.Equal(t, []int{200, 204}, config.ExpectedStatusCodes)
+		assert.True(t, config.ExpectedBody.MatchString(`{"status": "ok"}`))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []customprobe.Spec{
+			{Type: customprobe.ProbeTypeHTTP},
+			{Type: customprobe.ProbeTypeHTTP, HTTP: &customprobe.HTTPSpec{URL: "http://x", ExpectedBody: "("}},
+			{Type: customprobe.ProbeTypeDNS},
+			{Type: customprobe.ProbeTypeGRPC},
+			{Type: "ICMP"},
+		}
+		for _, spec := range invalid {
+			_, err := customRunnerConfig(newProbe(spec), "upmeter/test")
+			assert.Error(t, err, "type %s", spec.Type)
+		}
+	})
+}
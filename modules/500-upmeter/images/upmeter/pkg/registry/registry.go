@@ -18,6 +18,7 @@ package registry
 
 import (
 	"sort"
+	"sync"
 
 	"d8.io/upmeter/pkg/check"
 	"d8.io/upmeter/pkg/probe"
@@ -31,17 +32,55 @@ type Registry struct {
 
 	// calculators contains calculators probes definitions
 	calculators []*calculated.Probe
+
+	// named contains runners added at runtime, e.g. user-defined probes, by their names
+	mu    sync.RWMutex
+	named map[string]*check.Runner
 }
 
 func New(runLoader *probe.Loader, calcLoader *calculated.Loader) *Registry {
 	return &Registry{
 		runners:     runLoader.Load(),
 		calculators: calcLoader.Load(),
+		named:       make(map[string]*check.Runner),
 	}
 }
 
+// Runners returns loaded runners followed by runners added at runtime sorted by their names
 func (r *Registry) Runners() []*check.Runner {
-	return r.runners
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.named) == 0 {
+		return r.runners
+	}
+
+	names := make([]string, 0, len(r.named))
+	for name := range r.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	runners := make([]*check.Runner, 0, len(r.runners)+len(r.named))
+	runners = append(runners, r.runners...)
+	for _, name := range names {
+		runners = append(runners, r.named[name])
+	}
+	return runners
+}
+
+// SetRunner adds the runner or replaces the one previously added with the same name
+func (r *Registry) SetRunner(name string, runner *check.Runner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.named[name] = runner
+}
+
+// DeleteRunner removes the runner previously added with the name
+func (r *Registry) DeleteRunner(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.named, name)
 }
 
 func (r *Registry) Calculators() []*calculated.Probe {
@@ -67,16 +106,39 @@ type RegistryProbeLister struct {
 
 	// probes refs of contain loaded probes
 	probes []check.ProbeRef
+
+	// dynamic listers are asked on each call, since their groups and probes change at runtime
+	dynamic []ProbeLister
+}
+
+// WithDynamic adds listers of groups and probes that change at runtime, e.g. user-defined probes
+func (pl *RegistryProbeLister) WithDynamic(listers ...ProbeLister) *RegistryProbeLister {
+	pl.dynamic = append(pl.dynamic, listers...)
+	return pl
 }
 
 func (pl *RegistryProbeLister) Probes() []check.ProbeRef {
-	return pl.probes
+	if len(pl.dynamic) == 0 {
+		return pl.probes
+	}
+	return collectProbes(append([]ProbeLister{staticLister{pl.groups, pl.probes}}, pl.dynamic...)...)
 }
 
 func (pl *RegistryProbeLister) Groups() []string {
-	return pl.groups
+	if len(pl.dynamic) == 0 {
+		return pl.groups
+	}
+	return collectGroups(append([]ProbeLister{staticLister{pl.groups, pl.probes}}, pl.dynamic...)...)
 }
 
+type staticLister struct {
+	groups []string
+	probes []check.ProbeRef
+}
+
+func (l staticLister) Groups() []string         { return l.groups }
+func (l staticLister) Probes() []check.ProbeRef { return l.probes }
+
 func collectGroups(ls ...ProbeLister) []string {
 	groups := set.StringSet{}
 	for _, grouper := range ls {
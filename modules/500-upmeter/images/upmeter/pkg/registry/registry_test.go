@@ -18,6 +18,7 @@ package registry
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -63,6 +64,47 @@ func TestNewProbeLister(t *testing.T) {
 	assert.Equal(t, allGroupsSorted, pl.Groups())
 }
 
+// Test how dynamic listers are merged with the static ones on each call
+func TestRegistryProbeLister_WithDynamic(t *testing.T) {
+	static := lister{
+		groups: []string{"a"},
+		probes: []check.ProbeRef{{Group: "a", Probe: "pa"}},
+	}
+	dynamic := &lister{}
+
+	pl := NewProbeLister(static).WithDynamic(dynamic)
+	assert.Equal(t, []string{"a"}, pl.Groups())
+	assert.Equal(t, []check.ProbeRef{{Group: "a", Probe: "pa"}}, pl.Probes())
+
+	dynamic.groups = []string{"a", "b"}
+	dynamic.probes = []check.ProbeRef{{Group: "b", Probe: "pb"}, {Group: "a", Probe: "pa"}}
+	assert.Equal(t, []string{"a", "b"}, pl.Groups())
+	assert.Equal(t, []check.ProbeRef{{Group: "a", Probe: "pa"}, {Group: "b", Probe: "pb"}}, pl.Probes())
+}
+
+// Test how runners are added and removed at runtime
+func TestRegistry_SetRunner(t *testing.T) {
+	static := check.NewRunner("a", "pa", "_", time.Second, nil, nil)
+	reg := &Registry{
+		runners: []*check.Runner{static},
+		named:   make(map[string]*check.Runner),
+	}
+
+	second := check.NewRunner("z", "pz", "second", time.Second, nil, nil)
+	first := check.NewRunner("z", "pz", "first", time.Second, nil, nil)
+	reg.SetRunner("second", second)
+	reg.SetRunner("first", first)
+	assert.Equal(t, []*check.Runner{static, first, second}, reg.Runners())
+
+	replaced := check.NewRunner("x", "px", "first", time.Second, nil, nil)
+	reg.SetRunner("first", replaced)
+	assert.Equal(t, []*check.Runner{static, replaced, second}, reg.Runners())
+
+	reg.DeleteRunner("first")
+	reg.DeleteRunner("second")
+	assert.Equal(t, []*check.Runner{static}, reg.Runners())
+}
+
 type lister struct {
 	groups []string
 	probes []check.ProbeRef
@@ -0,0 +1,169 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dbcontext "d8.io/upmeter/pkg/db/context"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/monitor/downtime"
+	"d8.io/upmeter/pkg/server/incident"
+)
+
+const defaultIncidentsPeriod = 7 * 24 * time.Hour
+
+type DetectedIncident struct {
+	ID    int64 `json:"id"`
+	Start int64 `json:"start"`
+	// End is zero while the incident is open
+	End         int64    `json:"end"`
+	Open        bool     `json:"open"`
+	Duration    int64    `json:"duration"`
+	Groups      []string `json:"groups"`
+	Probes      []string `json:"probes"`
+	WorstStatus string   `json:"worstStatus"`
+}
+
+// DetectedIncidentsHandler returns the timeline of automatically detected incidents. Incidents are
+// selected by "from" and "to" unix timestamps, or by "id". With "format=downtime", closed incidents
+// are returned as a list of Downtime objects ready to be applied to the cluster.
+type DetectedIncidentsHandler struct {
+	DbCtx *dbcontext.DbContext
+}
+
+func (h *DetectedIncidentsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Infoln("DetectedIncidents", r.RemoteAddr, r.RequestURI)
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "%d GET is required\n", http.StatusMethodNotAllowed)
+		return
+	}
+
+	incidents, err := h.list(r)
+	if errors.Is(err, dao.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "%d Incident not found\n", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusBadRequest, err)
+		return
+	}
+
+	var out []byte
+	if r.URL.Query().Get("format") == "downtime" {
+		out, err = json.Marshal(toDowntimeList(incidents))
+	} else {
+		out, err = json.Marshal(toDetectedIncidents(incidents, time.Now()))
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(out)
+}
+
+func (h *DetectedIncidentsHandler) list(r *http.Request) ([]dao.IncidentEntity, error) {
+	storage := dao.NewIncidentDAO(h.DbCtx)
+	query := r.URL.Query()
+
+	if idArg := query.Get("id"); idArg != "" {
+		id, err := strconv.ParseInt(idArg, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("id=%q is not a number: %v", idArg, err)
+		}
+		found, err := storage.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		return []dao.IncidentEntity{*found}, nil
+	}
+
+	to := time.Now()
+	if toArg := query.Get("to"); toArg != "" {
+		ts, err := parseTimestamp(toArg)
+		if err != nil {
+			return nil, fmt.Errorf("to=%q is not timestamp: %v", toArg, err)
+		}
+		to = time.Unix(ts, 0)
+	}
+
+	from := to.Add(-defaultIncidentsPeriod)
+	if fromArg := query.Get("from"); fromArg != "" {
+		ts, err := parseTimestamp(fromArg)
+		if err != nil {
+			return nil, fmt.Errorf("from=%q is not timestamp: %v", fromArg, err)
+		}
+		from = time.Unix(ts, 0)
+	}
+
+	return storage.ListByRange(from, to)
+}
+
+func toDetectedIncidents(incidents []dao.IncidentEntity, now time.Time) []DetectedIncident {
+	res := make([]DetectedIncident, 0, len(incidents))
+	for _, inc := range incidents {
+		end := inc.End
+		if inc.IsOpen() {
+			end = now
+		}
+		di := DetectedIncident{
+			ID:          inc.ID,
+			Start:       inc.Start.Unix(),
+			Open:        inc.IsOpen(),
+			Duration:    int64(end.Sub(inc.Start).Seconds()),
+			Groups:      inc.Groups,
+			Probes:      inc.Probes,
+			WorstStatus: inc.WorstStatus,
+		}
+		if !inc.IsOpen() {
+			di.End = inc.End.Unix()
+		}
+		res = append(res, di)
+	}
+	return res
+}
+
+// toDowntimeList converts closed incidents to a Kubernetes list of Downtime objects
+func toDowntimeList(incidents []dao.IncidentEntity) map[string]interface{} {
+	items := make([]downtime.Downtime, 0, len(incidents))
+	for _, inc := range incidents {
+		if inc.IsOpen() {
+			continue
+		}
+		items = append(items, incident.ToDowntime(inc))
+	}
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	}
+}
@@ -25,7 +25,7 @@ import (
 )
 
 func fetchIncidents(monitor *downtime.Monitor, muteDowntimeTypes []string, group string, rng ranges.StepRange) ([]check.DowntimeIncident, error) {
-	allIncidents, err := monitor.List()
+	allIncidents, err := monitor.List(rng.From, rng.To)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get incidents: %v", err)
 	}
@@ -83,6 +83,11 @@ func (h *PublicStatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if format := r.URL.Query().Get("format"); format != "" && format != FormatJSON {
+		h.serveExport(w, r, format)
+		return
+	}
+
 	statuses, err := h.getGroupStatusList(r.URL.Query().Get("peek") == "1")
 	if err != nil {
 		log.Errorf("Cannot get status summary: %v", err)
@@ -0,0 +1,604 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/check"
+)
+
+const (
+	FormatJSON       = "json"
+	FormatStatuspage = "statuspage"
+	FormatRSS        = "rss"
+	FormatAtom       = "atom"
+	FormatHTML       = "html"
+
+	exportTitle = "Cluster status"
+
+	// exportIncidentsPeriod limits how old incidents are included in exports
+	exportIncidentsPeriod = 30 * 24 * time.Hour
+	// exportUpcomingPeriod limits how far ahead occurrences of recurring downtimes are included
+	exportUpcomingPeriod = 7 * 24 * time.Hour
+)
+
+// statusSnapshot is the data for all export formats
+type statusSnapshot struct {
+	Title     string
+	URL       string
+	Time      time.Time
+	HasData   bool
+	Status    PublicStatus
+	Rows      []GroupStatus
+	Incidents []check.DowntimeIncident
+}
+
+type exportRenderer func(*statusSnapshot) ([]byte, error)
+
+var exportFormats = map[string]struct {
+	contentType string
+	render      exportRenderer
+}{
+	FormatStatuspage: {"application/json", renderStatuspage},
+	FormatRSS:        {"application/rss+xml; charset=utf-8", renderRSS},
+	FormatAtom:       {"application/atom+xml; charset=utf-8", renderAtom},
+	FormatHTML:       {"text/html; charset=utf-8", renderHTML},
+}
+
+// serveExport writes the public status in one of the export formats
+func (h *PublicStatusHandler) serveExport(w http.ResponseWriter, r *http.Request, format string) {
+	export, ok := exportFormats[format]
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, jsonError(fmt.Sprintf("unknown format %q", format)))
+		return
+	}
+
+	snapshot, err := h.snapshot(r, time.Now())
+	if err != nil {
+		log.Errorf("Cannot get status snapshot: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, jsonError(err.Error()))
+		return
+	}
+
+	out, err := export.render(snapshot)
+	if err != nil {
+		log.Errorf("Cannot render status as %s: %v", format, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, jsonError(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", export.contentType)
+	w.Write(out)
+}
+
+func (h *PublicStatusHandler) snapshot(r *http.Request, now time.Time) (*statusSnapshot, error) {
+	snapshot := &statusSnapshot{
+		Title:   exportTitle,
+		URL:     requestBaseURL(r),
+		Time:    now,
+		HasData: true,
+		Rows:    []GroupStatus{},
+	}
+
+	statuses, err := h.getGroupStatusList(false)
+	if err != nil {
+		log.Errorf("Cannot get status summary: %v", err)
+		snapshot.HasData = false
+	} else {
+		snapshot.Rows = statuses
+		snapshot.Status = calculateTotalStatus(statuses)
+	}
+
+	since := now.Add(-exportIncidentsPeriod)
+	incidents, err := h.DowntimeMonitor.List(since.Unix(), now.Add(exportUpcomingPeriod).Unix())
+	if err != nil {
+		return nil, fmt.Errorf("cannot get incidents: %v", err)
+	}
+	snapshot.Incidents = recentIncidents(incidents, since)
+
+	return snapshot, nil
+}
+
+// recentIncidents returns incidents that ended after the time, the most recent first
+func recentIncidents(incidents []check.DowntimeIncident, since time.Time) []check.DowntimeIncident {
+	res := filterIncidents(incidents, func(inc check.DowntimeIncident) bool {
+		return inc.End > since.Unix()
+	})
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Start > res[j].Start })
+	return res
+}
+
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s/", scheme, r.Host)
+}
+
+func isMaintenance(inc check.DowntimeIncident) bool {
+	return strings.HasSuffix(inc.Type, "Maintenance")
+}
+
+func incidentTitle(inc check.DowntimeIncident) string {
+	if len(inc.Affected) == 0 {
+		return inc.Type
+	}
+	return fmt.Sprintf("%s: %s", inc.Type, strings.Join(inc.Affected, ", "))
+}
+
+func incidentID(inc check.DowntimeIncident) string {
+	return fmt.Sprintf("%s-%d", inc.DowntimeName, inc.Start)
+}
+
+func formatTime(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
+
+/*
+Statuspage format mimics the summary of Atlassian Statuspage API, see
+https://developer.statuspage.io/#operation/getPagesPageIdSummary
+
+Groups are represented as group components, their probes are components within groups. Accidents
+are incidents, and maintenance downtimes are scheduled maintenances.
+*/
+
+type statuspageSummary struct {
+	Page                  statuspagePage        `json:"page"`
+	Status                statuspageStatus      `json:"status"`
+	Components            []statuspageComponent `json:"components"`
+	Incidents             []statuspageIncident  `json:"incidents"`
+	ScheduledMaintenances []statuspageIncident  `json:"scheduled_maintenances"`
+}
+
+type statuspagePage struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type statuspageStatus struct {
+	Indicator   string `json:"indicator"`
+	Description string `json:"description"`
+}
+
+type statuspageComponent struct {
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Status             string   `json:"status"`
+	Position           int      `json:"position"`
+	Description        *string  `json:"description"`
+	Showcase           bool     `json:"showcase"`
+	Group              bool     `json:"group"`
+	GroupID            *string  `json:"group_id"`
+	OnlyShowIfDegraded bool     `json:"only_show_if_degraded"`
+	Components         []string `json:"components,omitempty"`
+	UpdatedAt          string   `json:"updated_at"`
+}
+
+type statuspageIncident struct {
+	ID              string                     `json:"id"`
+	Name            string                     `json:"name"`
+	Status          string                     `json:"status"`
+	Impact          string                     `json:"impact"`
+	CreatedAt       string                     `json:"created_at"`
+	UpdatedAt       string                     `json:"updated_at"`
+	StartedAt       string                     `json:"started_at"`
+	ResolvedAt      *string                    `json:"resolved_at"`
+	ScheduledFor    *string                    `json:"scheduled_for,omitempty"`
+	ScheduledUntil  *string                    `json:"scheduled_until,omitempty"`
+	Shortlink       string                     `json:"shortlink"`
+	Components      []statuspageComponentRef   `json:"components"`
+	IncidentUpdates []statuspageIncidentUpdate `json:"incident_updates"`
+}
+
+type statuspageComponentRef struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type statuspageIncidentUpdate struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	DisplayAt string `json:"display_at"`
+}
+
+func renderStatuspage(s *statusSnapshot) ([]byte, error) {
+	now := s.Time.UTC().Format(time.RFC3339)
+
+	summary := statuspageSummary{
+		Page: statuspagePage{
+			ID:        "upmeter",
+			Name:      s.Title,
+			URL:       s.URL,
+			UpdatedAt: now,
+		},
+		Status:                statuspageTotalStatus(s),
+		Components:            make([]statuspageComponent, 0),
+		Incidents:             make([]statuspageIncident, 0),
+		ScheduledMaintenances: make([]statuspageIncident, 0),
+	}
+
+	componentStatuses := make(map[string]string)
+	position := 0
+	for _, row := range s.Rows {
+		groupID := row.Group
+		group := statuspageComponent{
+			ID:         groupID,
+			Name:       row.Group,
+			Status:     statuspageComponentStatus(row.Status),
+			Position:   position,
+			Group:      true,
+			Components: make([]string, 0, len(row.Probes)),
+			UpdatedAt:  now,
+		}
+		componentStatuses[groupID] = group.Status
+		position++
+
+		probes := make([]statuspageComponent, 0, len(row.Probes))
+		for _, probe := range row.Probes {
+			id := row.Group + "/" + probe.Probe
+			probes = append(probes, statuspageComponent{
+				ID:        id,
+				Name:      probe.Probe,
+				Status:    statuspageComponentStatus(probe.Status),
+				Position:  position,
+				Showcase:  true,
+				GroupID:   &groupID,
+				UpdatedAt: now,
+			})
+			group.Components = append(group.Components, id)
+			position++
+		}
+
+		summary.Components = append(summary.Components, group)
+		summary.Components = append(summary.Components, probes...)
+	}
+
+	for _, inc := range s.Incidents {
+		spi := statuspageIncidentFrom(inc, s.Time, componentStatuses)
+		if isMaintenance(inc) {
+			summary.ScheduledMaintenances = append(summary.ScheduledMaintenances, spi)
+		} else {
+			summary.Incidents = append(summary.Incidents, spi)
+		}
+	}
+
+	return json.Marshal(summary)
+}
+
+func statuspageTotalStatus(s *statusSnapshot) statuspageStatus {
+	if !s.HasData {
+		return statuspageStatus{Indicator: "major", Description: "No data for last 15 min"}
+	}
+	switch s.Status {
+	case StatusOperational:
+		return statuspageStatus{Indicator: "none", Description: "All Systems Operational"}
+	case StatusDegraded:
+		return statuspageStatus{Indicator: "minor", Description: "Minor Service Outage"}
+	default:
+		return statuspageStatus{Indicator: "major", Description: "Major Service Outage"}
+	}
+}
+
+func statuspageComponentStatus(status PublicStatus) string {
+	switch status {
+	case StatusOperational:
+		return "operational"
+	case StatusDegraded:
+		return "degraded_performance"
+	default:
+		return "major_outage"
+	}
+}
+
+func statuspageIncidentFrom(inc check.DowntimeIncident, now time.Time, componentStatuses map[string]string) statuspageIncident {
+	var (
+		start    = formatTime(inc.Start)
+		end      = formatTime(inc.End)
+		finished = inc.End <= now.Unix()
+		started  = inc.Start <= now.Unix()
+	)
+
+	spi := statuspageIncident{
+		ID:         incidentID(inc),
+		Name:       incidentTitle(inc),
+		CreatedAt:  start,
+		UpdatedAt:  start,
+		StartedAt:  start,
+		Components: make([]statuspageComponentRef, 0, len(inc.Affected)),
+	}
+
+	for _, group := range inc.Affected {
+		status, ok := componentStatuses[group]
+		if !ok {
+			status = "operational"
+		}
+		spi.Components = append(spi.Components, statuspageComponentRef{ID: group, Name: group, Status: status})
+	}
+
+	if finished {
+		spi.ResolvedAt = &end
+		spi.UpdatedAt = end
+	}
+
+	if isMaintenance(inc) {
+		spi.Impact = "maintenance"
+		spi.ScheduledFor = &start
+		spi.ScheduledUntil = &end
+		switch {
+		case finished:
+			spi.Status = "completed"
+		case started:
+			spi.Status = "in_progress"
+		default:
+			spi.Status = "scheduled"
+		}
+	} else {
+		spi.Impact = "minor"
+		if inc.Type == "Accident" {
+			spi.Impact = "major"
+		}
+		spi.Status = "investigating"
+		if finished {
+			spi.Status = "resolved"
+		}
+	}
+
+	spi.IncidentUpdates = []statuspageIncidentUpdate{{
+		ID:        spi.ID,
+		Status:    spi.Status,
+		Body:      inc.Description,
+		CreatedAt: spi.UpdatedAt,
+		UpdatedAt: spi.UpdatedAt,
+		DisplayAt: spi.UpdatedAt,
+	}}
+
+	return spi
+}
+
+// RSS 2.0 feed of downtimes
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Category    []string `xml:"category"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func renderRSS(s *statusSnapshot) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         s.Title,
+			Link:          s.URL,
+			Description:   "Downtime incidents and maintenance",
+			LastBuildDate: s.Time.UTC().Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(s.Incidents)),
+		},
+	}
+
+	for _, inc := range s.Incidents {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       incidentTitle(inc),
+			Link:        s.URL,
+			Description: incidentFeedSummary(inc),
+			Category:    append([]string{inc.Type}, inc.Affected...),
+			GUID:        rssGUID{Value: incidentURN(inc)},
+			PubDate:     time.Unix(inc.Start, 0).UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshalXML(feed)
+}
+
+// Atom feed of downtimes
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title     string         `xml:"title"`
+	ID        string         `xml:"id"`
+	Published string         `xml:"published"`
+	Updated   string         `xml:"updated"`
+	Link      atomLink       `xml:"link"`
+	Summary   string         `xml:"summary"`
+	Author    atomAuthor     `xml:"author"`
+	Category  []atomCategory `xml:"category"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func renderAtom(s *statusSnapshot) ([]byte, error) {
+	feed := atomFeed{
+		Title:   s.Title,
+		ID:      s.URL,
+		Updated: s.Time.UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: s.URL},
+		Entries: make([]atomEntry, 0, len(s.Incidents)),
+	}
+
+	for _, inc := range s.Incidents {
+		updated := inc.Start
+		if inc.End <= s.Time.Unix() {
+			updated = inc.End
+		}
+
+		categories := []atomCategory{{Term: inc.Type}}
+		for _, group := range inc.Affected {
+			categories = append(categories, atomCategory{Term: group})
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     incidentTitle(inc),
+			ID:        incidentURN(inc),
+			Published: formatTime(inc.Start),
+			Updated:   formatTime(updated),
+			Link:      atomLink{Href: s.URL},
+			Summary:   incidentFeedSummary(inc),
+			Author:    atomAuthor{Name: "upmeter"},
+			Category:  categories,
+		})
+	}
+
+	return marshalXML(feed)
+}
+
+func incidentURN(inc check.DowntimeIncident) string {
+	return fmt.Sprintf("urn:upmeter:downtime:%s", incidentID(inc))
+}
+
+func incidentFeedSummary(inc check.DowntimeIncident) string {
+	period := fmt.Sprintf("From %s to %s.", formatTime(inc.Start), formatTime(inc.End))
+	if inc.Description == "" {
+		return period
+	}
+	return inc.Description + "\n\n" + period
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// Static HTML snapshot that does not depend on external resources
+
+var htmlTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"percent": func(av float64) string { return fmt.Sprintf("%.2f%%", av*100) },
+	"time":    formatTime,
+	"statusClass": func(status PublicStatus) string {
+		return strings.ToLower(string(status))
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif; max-width: 960px; margin: 2em auto; color: #333; }
+.status { display: inline-block; padding: 0.2em 0.6em; border-radius: 4px; color: #fff; }
+.operational { background: #2fcc66; }
+.degraded { background: #f1c40f; }
+.outage, .nodata { background: #e74c3c; }
+table { width: 100%; border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { text-align: left; padding: 0.4em; border-bottom: 1px solid #eee; }
+.muted { color: #888; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{- if .HasData }}
+<p><span class="status {{ statusClass .Status }}">{{ .Status }}</span></p>
+{{- else }}
+<p><span class="status nodata">No data for last 15 min</span></p>
+{{- end }}
+{{- range .Rows }}
+<h2>{{ .Group }} <span class="status {{ statusClass .Status }}">{{ .Status }}</span></h2>
+<table>
+<tr><th>Probe</th><th>Availability</th><th>Status</th></tr>
+{{- range .Probes }}
+<tr><td>{{ .Probe }}</td><td>{{ percent .Availability }}</td><td><span class="status {{ statusClass .Status }}">{{ .Status }}</span></td></tr>
+{{- end }}
+</table>
+{{- end }}
+<h2>Incidents and maintenance</h2>
+{{- if .Incidents }}
+<table>
+<tr><th>Type</th><th>Affected</th><th>Start</th><th>End</th><th>Description</th></tr>
+{{- range .Incidents }}
+<tr><td>{{ .Type }}</td><td>{{ range $i, $g := .Affected }}{{ if $i }}, {{ end }}{{ $g }}{{ end }}</td><td>{{ time .Start }}</td><td>{{ time .End }}</td><td>{{ .Description }}</td></tr>
+{{- end }}
+</table>
+{{- else }}
+<p>No incidents reported.</p>
+{{- end }}
+<p class="muted">Generated at {{ .Time.UTC.Format "2006-01-02T15:04:05Z07:00" }}</p>
+</body>
+</html>
+`))
+
+func renderHTML(s *statusSnapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
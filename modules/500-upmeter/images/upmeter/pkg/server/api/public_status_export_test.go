@@ -0,0 +1,198 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"d8.io/upmeter/pkg/check"
+)
+
+func exportTestSnapshot() *statusSnapshot {
+	now := time.Unix(1700000000, 0)
+	return &statusSnapshot{
+		Title:   exportTitle,
+		URL:     "https://status.example.com/",
+		Time:    now,
+		HasData: true,
+		Status:  StatusDegraded,
+		Rows: []GroupStatus{
+			{
+				Group:  "control-plane",
+				Status: StatusDegraded,
+				Probes: []ProbeAvailability{
+					{Probe: "apiserver", Availability: 1, Status: StatusOperational},
+					{Probe: "scheduler", Availability: 0.95, Status: StatusDegraded},
+				},
+			},
+		},
+		Incidents: []check.DowntimeIncident{
+			{
+				Start:        now.Add(time.Hour).Unix(),
+				End:          now.Add(2 * time.Hour).Unix(),
+				Type:         "Maintenance",
+				Description:  "Upgrade <control plane>",
+				Affected:     []string{"control-plane"},
+				DowntimeName: "upgrade",
+			},
+			{
+				Start:        now.Add(-time.Hour).Unix(),
+				End:          now.Add(-30 * time.Minute).Unix(),
+				Type:         "Accident",
+				Description:  "Network failure",
+				Affected:     []string{"control-plane", "unknown-group"},
+				DowntimeName: "network",
+			},
+		},
+	}
+}
+
+func Test_renderStatuspage(t *testing.T) {
+	out, err := renderStatuspage(exportTestSnapshot())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var summary statuspageSummary
+	if err := json.Unmarshal(out, &summary); err != nil {
+		t.Fatalf("cannot parse output: %v", err)
+	}
+
+	if summary.Status.Indicator != "minor" {
+		t.Errorf("expected indicator minor, got %q", summary.Status.Indicator)
+	}
+
+	if len(summary.Components) != 3 {
+		t.Fatalf("expected 3 components, got %d", len(summary.Components))
+	}
+	group := summary.Components[0]
+	if !group.Group || group.Status != "degraded_performance" || len(group.Components) != 2 {
+		t.Errorf("unexpected group component %+v", group)
+	}
+	probe := summary.Components[2]
+	if probe.GroupID == nil || *probe.GroupID != "control-plane" || probe.Status != "degraded_performance" {
+		t.Errorf("unexpected probe component %+v", probe)
+	}
+
+	if len(summary.Incidents) != 1 {
+		t.Fatalf("expected 1 incident, got %d", len(summary.Incidents))
+	}
+	incident := summary.Incidents[0]
+	if incident.Status != "resolved" || incident.Impact != "major" || incident.ResolvedAt == nil {
+		t.Errorf("unexpected incident %+v", incident)
+	}
+	if len(incident.Components) != 2 || incident.Components[1].Status != "operational" {
+		t.Errorf("unexpected incident components %+v", incident.Components)
+	}
+
+	if len(summary.ScheduledMaintenances) != 1 {
+		t.Fatalf("expected 1 maintenance, got %d", len(summary.ScheduledMaintenances))
+	}
+	maintenance := summary.ScheduledMaintenances[0]
+	if maintenance.Status != "scheduled" || maintenance.Impact != "maintenance" || maintenance.ScheduledFor == nil {
+		t.Errorf("unexpected maintenance %+v", maintenance)
+	}
+}
+
+func Test_renderStatuspage_noData(t *testing.T) {
+	snapshot := exportTestSnapshot()
+	snapshot.HasData = false
+	snapshot.Rows = []GroupStatus{}
+	snapshot.Incidents = nil
+
+	out, err := renderStatuspage(snapshot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var summary statuspageSummary
+	if err := json.Unmarshal(out, &summary); err != nil {
+		t.Fatalf("cannot parse output: %v", err)
+	}
+	if summary.Status.Indicator != "major" {
+		t.Errorf("expected indicator major, got %q", summary.Status.Indicator)
+	}
+	if summary.Components == nil || summary.Incidents == nil || summary.ScheduledMaintenances == nil {
+		t.Errorf("expected empty lists instead of nulls: %s", out)
+	}
+}
+
+func Test_renderFeeds(t *testing.T) {
+	snapshot := exportTestSnapshot()
+
+	rss, err := renderRSS(snapshot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rssParsed rssFeed
+	if err := xml.Unmarshal(rss, &rssParsed); err != nil {
+		t.Fatalf("cannot parse RSS: %v", err)
+	}
+	if len(rssParsed.Channel.Items) != 2 {
+		t.Fatalf("expected 2 RSS items, got %d", len(rssParsed.Channel.Items))
+	}
+	if title := rssParsed.Channel.Items[1].Title; title != "Accident: control-plane, unknown-group" {
+		t.Errorf("unexpected RSS item title %q", title)
+	}
+
+	atom, err := renderAtom(snapshot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var atomParsed atomFeed
+	if err := xml.Unmarshal(atom, &atomParsed); err != nil {
+		t.Fatalf("cannot parse Atom: %v", err)
+	}
+	if len(atomParsed.Entries) != 2 {
+		t.Fatalf("expected 2 Atom entries, got %d", len(atomParsed.Entries))
+	}
+	if id := atomParsed.Entries[0].ID; id != "urn:upmeter:downtime:upgrade-1700003600" {
+		t.Errorf("unexpected Atom entry id %q", id)
+	}
+}
+
+func Test_renderHTML(t *testing.T) {
+	out, err := renderHTML(exportTestSnapshot())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	html := string(out)
+	for _, expected := range []string{"control-plane", "scheduler", "95.00%", "Upgrade &lt;control plane&gt;"} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected %q in HTML", expected)
+		}
+	}
+}
+
+func Test_recentIncidents(t *testing.T) {
+	incidents := []check.DowntimeIncident{
+		{Start: 100, End: 200, DowntimeName: "old"},
+		{Start: 300, End: 400, DowntimeName: "first"},
+		{Start: 500, End: 600, DowntimeName: "second"},
+	}
+
+	got := recentIncidents(incidents, time.Unix(250, 0))
+
+	if len(got) != 2 || got[0].DowntimeName != "second" || got[1].DowntimeName != "first" {
+		t.Errorf("unexpected incidents %+v", got)
+	}
+}
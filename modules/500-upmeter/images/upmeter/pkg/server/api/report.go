@@ -0,0 +1,117 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dbcontext "d8.io/upmeter/pkg/db/context"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/monitor/downtime"
+	"d8.io/upmeter/pkg/registry"
+	"d8.io/upmeter/pkg/server/budget"
+)
+
+const (
+	defaultReportMonths = 12
+	maxReportMonths     = 120
+)
+
+// MonthlyReportHandler returns availability of groups by calendar months. It accepts "months" to
+// report (12 by default), optional "group", and "muteDowntimeTypes" like the status range handler.
+type MonthlyReportHandler struct {
+	DbCtx           *dbcontext.DbContext
+	DowntimeMonitor *downtime.Monitor
+	ProbeLister     registry.ProbeLister
+}
+
+func (h *MonthlyReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Infoln("MonthlyReport", r.RemoteAddr, r.RequestURI)
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "%d GET is required\n", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	months := defaultReportMonths
+	if arg := query.Get("months"); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > maxReportMonths {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "%d months=%q must be a number from 1 to %d\n", http.StatusBadRequest, arg, maxReportMonths)
+			return
+		}
+		months = n
+	}
+
+	groups := h.ProbeLister.Groups()
+	if group := query.Get("group"); group != "" {
+		groups = []string{group}
+	}
+
+	muteTypes := parseDowntimeTypes(query.Get("muteDowntimeTypes"))
+	if len(muteTypes) == 0 {
+		muteTypes = []string{
+			"Maintenance",
+			"InfrastructureMaintenance",
+			"InfrastructureAccident",
+		}
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, -months, 0)
+	incidents, err := h.DowntimeMonitor.List(from.Unix(), now.Unix())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusInternalServerError, err)
+		return
+	}
+
+	daoCtx := h.DbCtx.Start()
+	defer daoCtx.Stop()
+
+	lister := budget.MonthlyLister{
+		Hourly: dao.NewEpisodeDao1h(daoCtx),
+		Daily:  dao.NewEpisodeDao1d(daoCtx),
+	}
+	reports, err := budget.Monthly(lister, incidents, groups, muteTypes, months, now)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusInternalServerError, err)
+		return
+	}
+
+	out, err := json.Marshal(reports)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(out)
+}
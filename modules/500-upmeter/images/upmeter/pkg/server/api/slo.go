@@ -0,0 +1,82 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/server/budget"
+)
+
+// SLOHandler returns error budget reports for all objectives or for the one specified by the "name"
+// query parameter
+type SLOHandler struct {
+	Budgets *budget.Service
+}
+
+func (h *SLOHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Infoln("SLO", r.RemoteAddr, r.RequestURI)
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, "%d GET is required\n", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reports, err := h.Budgets.Reports(time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusInternalServerError, err)
+		return
+	}
+
+	var out []byte
+	if name := r.URL.Query().Get("name"); name != "" {
+		report := findReport(reports, name)
+		if report == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "%d SLO %q not found\n", http.StatusNotFound, name)
+			return
+		}
+		out, err = json.Marshal(report)
+	} else {
+		out, err = json.Marshal(reports)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "%d Error: %s\n", http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(out)
+}
+
+func findReport(reports []*budget.Report, name string) *budget.Report {
+	for _, r := range reports {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
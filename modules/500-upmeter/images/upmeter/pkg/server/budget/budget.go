@@ -0,0 +1,249 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"fmt"
+	"time"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/server/entity"
+	"d8.io/upmeter/pkg/server/ranges"
+)
+
+// Report is the state of the error budget of an objective
+type Report struct {
+	Name   string `json:"name"`
+	Group  string `json:"group"`
+	Probe  string `json:"probe"`
+	Window string `json:"window"`
+
+	// Target is the availability target in percent
+	Target float64 `json:"target"`
+
+	// Availability is the ratio represented as a fraction of 1 over the window, it is negative when
+	// there is no data
+	Availability float64 `json:"availability"`
+
+	ErrorBudget ErrorBudget `json:"errorBudget"`
+	BurnRates   []BurnRate  `json:"burnRates"`
+}
+
+// ErrorBudget is the allowed downtime over the window. Only measured time counts, i.e. muted and
+// not measured time neither grows nor burns the budget.
+type ErrorBudget struct {
+	Total    time.Duration `json:"total"`
+	Consumed time.Duration `json:"consumed"`
+
+	// Remaining is negative when the budget is overspent
+	Remaining time.Duration `json:"remaining"`
+
+	// RemainingRatio is the remaining budget as a fraction of the total one
+	RemainingRatio float64 `json:"remainingRatio"`
+}
+
+// BurnRate shows how fast the budget is burnt within a window. Rate 1 means that the budget is
+// spent exactly by the end of the objective window.
+type BurnRate struct {
+	Window string  `json:"window"`
+	Rate   float64 `json:"rate"`
+
+	// Exhaustion is the forecast of the budget exhaustion if it keeps burning at this rate. It is
+	// absent if the budget is not going to be exhausted.
+	Exhaustion *time.Time `json:"exhaustion,omitempty"`
+}
+
+// Evaluate calculates the error budget of the objective for the window ending at the last complete
+// 5-minute episode before now.
+func Evaluate(lister entity.RangeEpisodeLister, incidents []check.DowntimeIncident, obj *Objective, now time.Time) (*Report, error) {
+	to := now.Truncate(minWindow)
+	incidents = mutingIncidents(incidents, obj.Ref.Group, obj.MuteDowntimeTypes)
+
+	stats, err := measure(lister, incidents, obj.Ref, to.Add(-obj.Window), to)
+	if err != nil {
+		return nil, fmt.Errorf("measuring %s window: %v", FormatWindow(obj.Window), err)
+	}
+
+	allowed := 1 - obj.Target
+	budget := calcErrorBudget(stats, allowed)
+
+	report := &Report{
+		Name:         obj.Name,
+		Group:        obj.Ref.Group,
+		Probe:        obj.Ref.Probe,
+		Window:       FormatWindow(obj.Window),
+		Target:       obj.Target * 100,
+		Availability: stats.availability(),
+		ErrorBudget:  budget,
+		BurnRates:    make([]BurnRate, 0, len(obj.BurnRateWindows)),
+	}
+
+	for _, w := range obj.BurnRateWindows {
+		wstats, err := measure(lister, incidents, obj.Ref, to.Add(-w), to)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s burn rate window: %v", FormatWindow(w), err)
+		}
+
+		rate := wstats.badRatio() / allowed
+		report.BurnRates = append(report.BurnRates, BurnRate{
+			Window:     FormatWindow(w),
+			Rate:       rate,
+			Exhaustion: forecastExhaustion(budget.Remaining, rate, allowed, to),
+		})
+	}
+
+	return report, nil
+}
+
+func calcErrorBudget(stats stats, allowed float64) ErrorBudget {
+	total := time.Duration(float64(stats.measured()) * allowed)
+	remaining := total - stats.down
+
+	ratio := 1.0
+	if total > 0 {
+		ratio = float64(remaining) / float64(total)
+	}
+
+	return ErrorBudget{
+		Total:          total,
+		Consumed:       stats.down,
+		Remaining:      remaining,
+		RemainingRatio: ratio,
+	}
+}
+
+// forecastExhaustion returns the moment when the remaining budget is spent at the burn rate. The
+// budget grows along with the measured time, thus it shrinks only when the rate is greater than 1.
+func forecastExhaustion(remaining time.Duration, rate, allowed float64, now time.Time) *time.Time {
+	if remaining <= 0 {
+		return &now
+	}
+	if rate <= 1 {
+		return nil
+	}
+
+	netBurn := allowed * (rate - 1)
+	at := now.Add(time.Duration(float64(remaining) / netBurn)).Truncate(time.Second)
+	return &at
+}
+
+type stats struct {
+	up, down, unknown time.Duration
+}
+
+// add sums up episodes of the probe
+func (s *stats) add(episodes []check.Episode, ref check.ProbeRef) {
+	for _, ep := range episodes {
+		if ep.ProbeRef.Group != ref.Group || ep.ProbeRef.Probe != ref.Probe {
+			continue
+		}
+		s.up += ep.Up
+		s.down += ep.Down
+		s.unknown += ep.Unknown
+	}
+}
+
+func (s stats) measured() time.Duration {
+	return s.up + s.down + s.unknown
+}
+
+// availability is calculated the same way as the public status does it
+func (s stats) availability() float64 {
+	if s.measured() == 0 {
+		return -1
+	}
+	return float64(s.up+s.unknown) / float64(s.measured())
+}
+
+func (s stats) badRatio() float64 {
+	if s.measured() == 0 {
+		return 0
+	}
+	return float64(s.down) / float64(s.measured())
+}
+
+// measure sums up episodes of the probe within [from, to) except muted time
+func measure(lister entity.RangeEpisodeLister, incidents []check.DowntimeIncident, ref check.ProbeRef, from, to time.Time) (stats, error) {
+	var res stats
+
+	subranges := unmutedRanges(from.Unix(), to.Unix(), int64(minWindow.Seconds()), incidents)
+	if len(subranges) == 0 {
+		return res, nil
+	}
+
+	rng := ranges.StepRange{
+		From:      from.Unix(),
+		To:        to.Unix(),
+		Step:      to.Unix() - from.Unix(),
+		Subranges: subranges,
+	}
+	episodes, err := lister.ListEpisodeSumsForRanges(rng, ref)
+	if err != nil {
+		return res, err
+	}
+
+	res.add(episodes, ref)
+	return res, nil
+}
+
+// unmutedRanges returns parts of [from, to) not covered by incidents. Incidents are widened to whole
+// slots of episodes, so that an episode partially covered by an incident is muted entirely.
+func unmutedRanges(from, to, slot int64, incidents []check.DowntimeIncident) []ranges.Range {
+	result := []ranges.Range{{From: from, To: to}}
+	for _, inc := range incidents {
+		start := inc.Start - inc.Start%slot
+		end := inc.End
+		if end%slot != 0 {
+			end += slot - end%slot
+		}
+
+		cut := make([]ranges.Range, 0, len(result)+1)
+		for _, r := range result {
+			if end <= r.From || start >= r.To {
+				cut = append(cut, r)
+				continue
+			}
+			if start > r.From {
+				cut = append(cut, ranges.Range{From: r.From, To: start})
+			}
+			if end < r.To {
+				cut = append(cut, ranges.Range{From: end, To: r.To})
+			}
+		}
+		result = cut
+	}
+	return result
+}
+
+func mutingIncidents(incidents []check.DowntimeIncident, group string, muteTypes []string) []check.DowntimeIncident {
+	res := make([]check.DowntimeIncident, 0)
+	for _, inc := range incidents {
+		if hasString(inc.Affected, group) && hasString(muteTypes, inc.Type) {
+			res = append(res, inc)
+		}
+	}
+	return res
+}
+
+func hasString(xs []string, s string) bool {
+	for _, x := range xs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/monitor/slo"
+	"d8.io/upmeter/pkg/server/ranges"
+)
+
+func Test_ParseWindow(t *testing.T) {
+	valid := map[string]time.Duration{
+		"28d": 28 * 24 * time.Hour,
+		"31d": 31 * 24 * time.Hour,
+		"6h":  6 * time.Hour,
+		"30m": 30 * time.Minute,
+		"7m":  5 * time.Minute,
+	}
+	for s, expected := range valid {
+		w, err := ParseWindow(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, w, s)
+	}
+
+	for _, s := range []string{"", "d", "0d", "-1h", "3m", "10s", "1w", "32d", "745h"} {
+		_, err := ParseWindow(s)
+		assert.Error(t, err, s)
+	}
+
+	assert.Equal(t, "28d", FormatWindow(28*24*time.Hour))
+	assert.Equal(t, "36h", FormatWindow(36*time.Hour))
+	assert.Equal(t, "90m", FormatWindow(90*time.Minute))
+}
+
+func Test_NewObjective(t *testing.T) {
+	obj, err := NewObjective(&slo.SLO{
+		ObjectMeta: metav1.ObjectMeta{Name: "control-plane"},
+		Spec:       slo.Spec{Group: "control-plane", Target: 99.9},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, check.ProbeRef{Group: "control-plane", Probe: dao.GroupAggregation}, obj.Ref)
+	assert.InDelta(t, 0.999, obj.Target, 1e-9)
+	assert.Equal(t, defaultWindow, obj.Window)
+	assert.Equal(t, defaultBurnRateWindows, obj.BurnRateWindows)
+	assert.Equal(t, defaultMuteDowntimeTypes, obj.MuteDowntimeTypes)
+
+	invalid := []slo.Spec{
+		{Target: 99},
+		{Group: "g", Target: 0},
+		{Group: "g", Target: 100},
+		{Group: "g", Target: 99, Window: "1w"},
+		{Group: "g", Target: 99, BurnRateWindows: []string{"1s"}},
+	}
+	for _, spec := range invalid {
+		_, err := NewObjective(&slo.SLO{Spec: spec})
+		assert.Error(t, err, "%+v", spec)
+	}
+}
+
+func Test_unmutedRanges(t *testing.T) {
+	incidents := []check.DowntimeIncident{
+		{Start: 1000, End: 1200}, // widened to [900, 1200)
+		{Start: 2100, End: 2450}, // widened to [2100, 2700)
+		{Start: 5000, End: 6000}, // out of range
+	}
+
+	got := unmutedRanges(600, 3000, 300, incidents)
+
+	assert.Equal(t, []ranges.Range{
+		{From: 600, To: 900},
+		{From: 1200, To: 2100},
+		{From: 2700, To: 3000},
+	}, got)
+
+	assert.Empty(t, unmutedRanges(600, 900, 300, []check.DowntimeIncident{{Start: 0, End: 1000}}))
+}
+
+func Test_Evaluate(t *testing.T) {
+	now := time.Unix(100*24*3600+17, 0) // not aligned on purpose
+	to := now.Truncate(5 * time.Minute)
+	ref := check.ProbeRef{Group: "g", Probe: dao.GroupAggregation}
+
+	// One day of data: all up, except for the last hour with 30 minutes down, one of which
+	// is muted by the maintenance
+	lister := newFakeLister()
+	for slot := to.Add(-24 * time.Hour); slot.Before(to); slot = slot.Add(5 * time.Minute) {
+		up, down := 5*time.Minute, time.Duration(0)
+		if !slot.Before(to.Add(-30 * time.Minute)) {
+			up, down = 0, 5*time.Minute
+		}
+		lister.add(check.Episode{ProbeRef: ref, TimeSlot: slot, Up: up, Down: down})
+	}
+	incidents := []check.DowntimeIncident{
+		{Start: to.Add(-10 * time.Minute).Unix(), End: to.Add(-5 * time.Minute).Unix(), Type: "Maintenance", Affected: []string{"g"}},
+		{Start: to.Add(-20 * time.Minute).Unix(), End: to.Add(-15 * time.Minute).Unix(), Type: "Accident", Affected: []string{"g"}},
+		{Start: to.Add(-30 * time.Minute).Unix(), End: to.Add(-25 * time.Minute).Unix(), Type: "Maintenance", Affected: []string{"other"}},
+	}
+
+	obj := &Objective{
+		Name:              "g",
+		Ref:               ref,
+		Target:            0.99,
+		Window:            28 * 24 * time.Hour,
+		BurnRateWindows:   []time.Duration{time.Hour, 24 * time.Hour},
+		MuteDowntimeTypes: defaultMuteDowntimeTypes,
+	}
+
+	report, err := Evaluate(lister, incidents, obj, now)
+	assert.NoError(t, err)
+
+	// 24h minus 5m muted are measured, 25m are down
+	measured := 24*time.Hour - 5*time.Minute
+	down := 25 * time.Minute
+	total := time.Duration(float64(measured) * 0.01)
+
+	assert.Equal(t, "28d", report.Window)
+	assert.InDelta(t, 99.0, report.Target, 1e-9)
+	assert.InDelta(t, float64(measured-down)/float64(measured), report.Availability, 1e-9)
+	assert.Equal(t, total, report.ErrorBudget.Total)
+	assert.Equal(t, down, report.ErrorBudget.Consumed)
+	assert.Equal(t, total-down, report.ErrorBudget.Remaining)
+
+	// The budget of about 14m is overspent
+	assert.Less(t, report.ErrorBudget.RemainingRatio, 0.0)
+	assert.Len(t, report.BurnRates, 2)
+	assert.Equal(t, "1h", report.BurnRates[0].Window)
+	assert.InDelta(t, (25.0/55.0)/0.01, report.BurnRates[0].Rate, 1e-9)
+	assert.Equal(t, to, *report.BurnRates[0].Exhaustion)
+	assert.Equal(t, "1d", report.BurnRates[1].Window)
+}
+
+func Test_forecastExhaustion(t *testing.T) {
+	now := time.Unix(1000000, 0)
+
+	// not burning faster than the budget grows
+	assert.Nil(t, forecastExhaustion(time.Hour, 1, 0.01, now))
+	assert.Nil(t, forecastExhaustion(time.Hour, 0.5, 0.01, now))
+
+	// already exhausted
+	assert.Equal(t, now, *forecastExhaustion(0, 2, 0.01, now))
+
+	// the budget shrinks by 1% of time, so 1h lasts 100h
+	assert.Equal(t, now.Add(100*time.Hour), *forecastExhaustion(time.Hour, 2, 0.01, now))
+}
+
+// fakeLister sums stored 5m episodes for subranges like the 5m DAO does
+type fakeLister struct {
+	episodes []check.Episode
+}
+
+func newFakeLister() *fakeLister {
+	return &fakeLister{}
+}
+
+func (l *fakeLister) add(ep check.Episode) {
+	l.episodes = append(l.episodes, ep)
+}
+
+func (l *fakeLister) ListEpisodeSumsForRanges(rng ranges.StepRange, ref check.ProbeRef) ([]check.Episode, error) {
+	res := make([]check.Episode, 0)
+	for _, sub := range rng.Subranges {
+		sum := check.Episode{ProbeRef: ref, TimeSlot: time.Unix(sub.From, 0)}
+		for _, ep := range l.episodes {
+			ts := ep.TimeSlot.Unix()
+			if ep.ProbeRef != ref || ts < sub.From || ts >= sub.To {
+				continue
+			}
+			sum.Up += ep.Up
+			sum.Down += ep.Down
+			sum.Unknown += ep.Unknown
+		}
+		res = append(res, sum)
+	}
+	return res, nil
+}
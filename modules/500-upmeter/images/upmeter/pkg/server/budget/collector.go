@@ -0,0 +1,99 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	objectiveLabels = []string{"slo", "group", "probe", "window"}
+	burnRateLabels  = []string{"slo", "group", "probe", "window", "burn_window"}
+
+	targetDesc = prometheus.NewDesc(
+		"upmeter_slo_target_ratio",
+		"Availability target of the objective as a fraction of 1",
+		objectiveLabels, nil)
+	availabilityDesc = prometheus.NewDesc(
+		"upmeter_slo_availability_ratio",
+		"Availability over the objective window as a fraction of 1",
+		objectiveLabels, nil)
+	budgetRemainingRatioDesc = prometheus.NewDesc(
+		"upmeter_slo_error_budget_remaining_ratio",
+		"Remaining error budget as a fraction of the total one, negative when overspent",
+		objectiveLabels, nil)
+	budgetRemainingSecondsDesc = prometheus.NewDesc(
+		"upmeter_slo_error_budget_remaining_seconds",
+		"Remaining error budget in seconds, negative when overspent",
+		objectiveLabels, nil)
+	burnRateDesc = prometheus.NewDesc(
+		"upmeter_slo_error_budget_burn_rate",
+		"Error budget burn rate within the burn window, 1 means the budget is spent exactly by the end of the objective window",
+		burnRateLabels, nil)
+	exhaustionDesc = prometheus.NewDesc(
+		"upmeter_slo_error_budget_exhaustion_timestamp_seconds",
+		"Forecast of the error budget exhaustion if it keeps burning at the burn window rate, absent if the budget is not going to be exhausted",
+		burnRateLabels, nil)
+)
+
+// Collector exposes error budgets as Prometheus metrics. Budgets are evaluated on scrape.
+type Collector struct {
+	service *Service
+}
+
+func NewCollector(service *Service) *Collector {
+	return &Collector{service: service}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- targetDesc
+	ch <- availabilityDesc
+	ch <- budgetRemainingRatioDesc
+	ch <- budgetRemainingSecondsDesc
+	ch <- burnRateDesc
+	ch <- exhaustionDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	reports, err := c.service.Reports(time.Now())
+	if err != nil {
+		c.service.Logger.Errorf("Cannot evaluate error budgets: %v", err)
+		return
+	}
+
+	for _, r := range reports {
+		labels := []string{r.Name, r.Group, r.Probe, r.Window}
+
+		ch <- prometheus.MustNewConstMetric(targetDesc, prometheus.GaugeValue, r.Target/100, labels...)
+		if r.Availability >= 0 {
+			ch <- prometheus.MustNewConstMetric(availabilityDesc, prometheus.GaugeValue, r.Availability, labels...)
+		}
+		ch <- prometheus.MustNewConstMetric(budgetRemainingRatioDesc, prometheus.GaugeValue, r.ErrorBudget.RemainingRatio, labels...)
+		ch <- prometheus.MustNewConstMetric(budgetRemainingSecondsDesc, prometheus.GaugeValue, r.ErrorBudget.Remaining.Seconds(), labels...)
+
+		for _, br := range r.BurnRates {
+			brLabels := append(labels[:len(labels):len(labels)], br.Window)
+
+			ch <- prometheus.MustNewConstMetric(burnRateDesc, prometheus.GaugeValue, br.Rate, brLabels...)
+			if br.Exhaustion != nil {
+				ch <- prometheus.MustNewConstMetric(exhaustionDesc, prometheus.GaugeValue, float64(br.Exhaustion.Unix()), brLabels...)
+			}
+		}
+	}
+}
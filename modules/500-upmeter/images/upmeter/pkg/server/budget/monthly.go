@@ -0,0 +1,157 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"fmt"
+	"time"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/server/entity"
+	"d8.io/upmeter/pkg/server/ranges"
+)
+
+const (
+	hour = int64(time.Hour / time.Second)
+	day  = 24 * hour
+)
+
+// GroupMonthlyReport is the availability of a group by calendar months in UTC
+type GroupMonthlyReport struct {
+	Group  string              `json:"group"`
+	Months []MonthAvailability `json:"months"`
+}
+
+type MonthAvailability struct {
+	// Month is written as "2006-01"
+	Month string `json:"month"`
+
+	// Availability is the ratio represented as a fraction of 1, it is negative when there is no data
+	Availability float64 `json:"availability"`
+
+	Downtime time.Duration `json:"downtime"`
+	Measured time.Duration `json:"measured"`
+}
+
+// MonthlyLister lists rolled up episodes
+type MonthlyLister struct {
+	Hourly entity.RangeEpisodeLister
+	Daily  entity.RangeEpisodeLister
+}
+
+// Monthly calculates the availability of groups for the specified number of months up to the current
+// one. Hourly and daily rolled up episodes are used, so muted time is aligned to whole hours.
+func Monthly(lister MonthlyLister, incidents []check.DowntimeIncident, groups, muteTypes []string, months int, now time.Time) ([]GroupMonthlyReport, error) {
+	bounds := monthBounds(months, now)
+
+	reports := make([]GroupMonthlyReport, 0, len(groups))
+	for _, group := range groups {
+		ref := check.ProbeRef{Group: group, Probe: dao.GroupAggregation}
+		muting := mutingIncidents(incidents, group, muteTypes)
+
+		report := GroupMonthlyReport{Group: group, Months: make([]MonthAvailability, 0, len(bounds))}
+		for _, b := range bounds {
+			st, err := measureRolledUp(lister, muting, ref, b.From, b.To)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s for %s: %v", group, time.Unix(b.From, 0).UTC().Format("2006-01"), err)
+			}
+			report.Months = append(report.Months, MonthAvailability{
+				Month:        time.Unix(b.From, 0).UTC().Format("2006-01"),
+				Availability: st.availability(),
+				Downtime:     st.down,
+				Measured:     st.measured(),
+			})
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// monthBounds returns ranges of months in UTC, the last one is the current month up to the current hour
+func monthBounds(months int, now time.Time) []ranges.Range {
+	now = now.UTC().Truncate(time.Hour)
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	res := make([]ranges.Range, 0, months)
+	for i := months - 1; i >= 0; i-- {
+		start := current.AddDate(0, -i, 0)
+		end := start.AddDate(0, 1, 0)
+		if end.After(now) {
+			end = now
+		}
+		res = append(res, ranges.Range{From: start.Unix(), To: end.Unix()})
+	}
+	return res
+}
+
+// measureRolledUp sums up episodes of the probe within [from, to) except muted time. Whole days are
+// taken from daily episodes, the rest is taken from hourly ones.
+func measureRolledUp(lister MonthlyLister, incidents []check.DowntimeIncident, ref check.ProbeRef, from, to int64) (stats, error) {
+	var res stats
+
+	hourly, daily := splitByDays(unmutedRanges(from, to, hour, incidents))
+
+	for _, part := range []struct {
+		lister    entity.RangeEpisodeLister
+		subranges []ranges.Range
+	}{
+		{lister.Hourly, hourly},
+		{lister.Daily, daily},
+	} {
+		if len(part.subranges) == 0 {
+			continue
+		}
+		episodes, err := part.lister.ListEpisodeSumsForRanges(ranges.StepRange{
+			From:      from,
+			To:        to,
+			Step:      to - from,
+			Subranges: part.subranges,
+		}, ref)
+		if err != nil {
+			return res, err
+		}
+		res.add(episodes, ref)
+	}
+
+	return res, nil
+}
+
+// splitByDays splits hour-aligned ranges into whole days and the remaining hours
+func splitByDays(rs []ranges.Range) (hourly, daily []ranges.Range) {
+	for _, r := range rs {
+		firstDay := r.From
+		if firstDay%day != 0 {
+			firstDay += day - firstDay%day
+		}
+		lastDay := r.To - r.To%day
+
+		if firstDay >= lastDay {
+			hourly = append(hourly, r)
+			continue
+		}
+		if r.From < firstDay {
+			hourly = append(hourly, ranges.Range{From: r.From, To: firstDay})
+		}
+		daily = append(daily, ranges.Range{From: firstDay, To: lastDay})
+		if lastDay < r.To {
+			hourly = append(hourly, ranges.Range{From: lastDay, To: r.To})
+		}
+	}
+	return hourly, daily
+}
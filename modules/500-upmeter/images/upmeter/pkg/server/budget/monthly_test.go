@@ -0,0 +1,103 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/server/ranges"
+)
+
+func Test_monthBounds(t *testing.T) {
+	now := time.Date(2023, 3, 15, 10, 30, 0, 0, time.UTC)
+
+	got := monthBounds(3, now)
+
+	assert.Equal(t, []ranges.Range{
+		{From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), To: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC).Unix()},
+		{From: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC).Unix(), To: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC).Unix()},
+		{From: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC).Unix(), To: time.Date(2023, 3, 15, 10, 0, 0, 0, time.UTC).Unix()},
+	}, got)
+}
+
+func Test_splitByDays(t *testing.T) {
+	hourly, daily := splitByDays([]ranges.Range{
+		// 22:00 of day 0 to 02:00 of day 3
+		{From: day - 2*hour, To: 3*day + 2*hour},
+		// within a day
+		{From: 5*day + hour, To: 5*day + 3*hour},
+	})
+
+	assert.Equal(t, []ranges.Range{
+		{From: day - 2*hour, To: day},
+		{From: 3 * day, To: 3*day + 2*hour},
+		{From: 5*day + hour, To: 5*day + 3*hour},
+	}, hourly)
+	assert.Equal(t, []ranges.Range{{From: day, To: 3 * day}}, daily)
+}
+
+func Test_Monthly(t *testing.T) {
+	ref := check.ProbeRef{Group: "control-plane", Probe: dao.GroupAggregation}
+	jan := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 2, 2, 12, 0, 0, 0, time.UTC)
+
+	hourly, daily := newFakeLister(), newFakeLister()
+	for ts := jan; ts.Before(now); ts = ts.Add(time.Hour) {
+		ep := check.Episode{ProbeRef: ref, TimeSlot: ts, Up: time.Hour}
+		// an hour of downtime on Jan 10 at 03:00, muted by maintenance
+		if ts.Equal(jan.Add(9*24*time.Hour + 3*time.Hour)) {
+			ep.Up, ep.Down = 0, time.Hour
+		}
+		// and half an hour on Feb 2 at 06:00
+		if ts.Equal(feb.Add(24*time.Hour + 6*time.Hour)) {
+			ep.Up, ep.Down = 30*time.Minute, 30*time.Minute
+		}
+		hourly.add(ep)
+	}
+	for ts := jan; ts.Before(now.Truncate(24 * time.Hour)); ts = ts.Add(24 * time.Hour) {
+		// daily episodes deliberately miss the downtime: they must not be used for muted days
+		daily.add(check.Episode{ProbeRef: ref, TimeSlot: ts, Up: 24 * time.Hour})
+	}
+
+	incidents := []check.DowntimeIncident{{
+		Start:    jan.Add(9*24*time.Hour + 3*time.Hour + 10*time.Minute).Unix(),
+		End:      jan.Add(9*24*time.Hour + 3*time.Hour + 50*time.Minute).Unix(),
+		Type:     "Maintenance",
+		Affected: []string{"control-plane"},
+	}}
+
+	reports, err := Monthly(MonthlyLister{Hourly: hourly, Daily: daily}, incidents, []string{"control-plane"}, []string{"Maintenance"}, 2, now)
+
+	assert.NoError(t, err)
+	assert.Len(t, reports, 1)
+	months := reports[0].Months
+	assert.Len(t, months, 2)
+
+	assert.Equal(t, "2023-01", months[0].Month)
+	assert.Equal(t, 1.0, months[0].Availability, "muted hour should be excluded")
+	assert.Equal(t, 31*24*time.Hour-time.Hour, months[0].Measured)
+
+	assert.Equal(t, "2023-02", months[1].Month)
+	assert.Equal(t, 30*time.Minute, months[1].Downtime)
+	assert.Equal(t, 36*time.Hour, months[1].Measured)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/monitor/slo"
+)
+
+const (
+	defaultWindow = 28 * 24 * time.Hour
+
+	// minWindow is the granularity of stored episodes
+	minWindow = 5 * time.Minute
+
+	// MaxWindow is the longest window, 5-minute episodes are kept at least for this period
+	MaxWindow = 31 * 24 * time.Hour
+)
+
+var (
+	defaultBurnRateWindows   = []time.Duration{time.Hour, 6 * time.Hour, 24 * time.Hour, 3 * 24 * time.Hour}
+	defaultMuteDowntimeTypes = []string{"Maintenance", "InfrastructureMaintenance", "InfrastructureAccident"}
+)
+
+// Objective is the service level objective for a group or a probe
+type Objective struct {
+	Name string
+	Ref  check.ProbeRef
+
+	// Target is the availability target as a fraction of 1
+	Target float64
+
+	Window            time.Duration
+	BurnRateWindows   []time.Duration
+	MuteDowntimeTypes []string
+}
+
+// NewObjective validates the UpmeterSLO object and fills defaults
+func NewObjective(obj *slo.SLO) (*Objective, error) {
+	spec := obj.Spec
+
+	if spec.Group == "" {
+		return nil, fmt.Errorf("group must be specified")
+	}
+	if spec.Target <= 0 || spec.Target >= 100 {
+		return nil, fmt.Errorf("target must be between 0 and 100, got %v", spec.Target)
+	}
+
+	probe := spec.Probe
+	if probe == "" {
+		probe = dao.GroupAggregation
+	}
+
+	window := defaultWindow
+	if spec.Window != "" {
+		w, err := ParseWindow(spec.Window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window: %v", err)
+		}
+		window = w
+	}
+
+	burnRateWindows := defaultBurnRateWindows
+	if len(spec.BurnRateWindows) > 0 {
+		burnRateWindows = make([]time.Duration, 0, len(spec.BurnRateWindows))
+		for _, s := range spec.BurnRateWindows {
+			w, err := ParseWindow(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid burn rate window: %v", err)
+			}
+			burnRateWindows = append(burnRateWindows, w)
+		}
+	}
+
+	muteTypes := defaultMuteDowntimeTypes
+	if len(spec.MuteDowntimeTypes) > 0 {
+		muteTypes = spec.MuteDowntimeTypes
+	}
+
+	return &Objective{
+		Name:              obj.GetName(),
+		Ref:               check.ProbeRef{Group: spec.Group, Probe: probe},
+		Target:            spec.Target / 100,
+		Window:            window,
+		BurnRateWindows:   burnRateWindows,
+		MuteDowntimeTypes: muteTypes,
+	}, nil
+}
+
+// lookback is the longest period measured for the objective
+func (o *Objective) lookback() time.Duration {
+	lookback := o.Window
+	for _, w := range o.BurnRateWindows {
+		if w > lookback {
+			lookback = w
+		}
+	}
+	return lookback
+}
+
+// ParseWindow parses the window written as a number of days, hours or minutes, e.g. "28d", "6h"
+// or "30m". Windows are aligned to the granularity of stored episodes.
+func ParseWindow(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("cannot parse %q", s)
+	}
+
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("cannot parse %q", s)
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'd':
+		unit = 24 * time.Hour
+	case 'h':
+		unit = time.Hour
+	case 'm':
+		unit = time.Minute
+	default:
+		return 0, fmt.Errorf("unknown unit in %q", s)
+	}
+
+	w := time.Duration(n) * unit
+	if w < minWindow {
+		return 0, fmt.Errorf("%q is less than %s", s, minWindow)
+	}
+	if w > MaxWindow {
+		return 0, fmt.Errorf("%q is more than %s", s, FormatWindow(MaxWindow))
+	}
+	return w.Truncate(minWindow), nil
+}
+
+// FormatWindow is the reverse of ParseWindow
+func FormatWindow(w time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case w%day == 0:
+		return fmt.Sprintf("%dd", w/day)
+	case w%time.Hour == 0:
+		return fmt.Sprintf("%dh", w/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", w/time.Minute)
+	}
+}
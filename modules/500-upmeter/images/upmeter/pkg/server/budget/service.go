@@ -0,0 +1,78 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package budget
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/check"
+	dbcontext "d8.io/upmeter/pkg/db/context"
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/monitor/slo"
+)
+
+type ObjectiveLister interface {
+	List() ([]*slo.SLO, error)
+}
+
+type IncidentLister interface {
+	List(from, to int64) ([]check.DowntimeIncident, error)
+}
+
+// Service evaluates error budgets of all defined objectives
+type Service struct {
+	DbCtx      *dbcontext.DbContext
+	Objectives ObjectiveLister
+	Incidents  IncidentLister
+	Logger     *log.Entry
+}
+
+// Reports returns error budget reports sorted by objective name. Invalid objectives are skipped.
+func (s *Service) Reports(now time.Time) ([]*Report, error) {
+	objs, err := s.Objectives.List()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list objectives: %v", err)
+	}
+
+	daoCtx := s.DbCtx.Start()
+	defer daoCtx.Stop()
+	lister := dao.NewEpisodeDao5m(daoCtx)
+
+	reports := make([]*Report, 0, len(objs))
+	for _, obj := range objs {
+		objective, err := NewObjective(obj)
+		if err != nil {
+			s.Logger.Errorf("Skipping invalid UpmeterSLO %q: %v", obj.GetName(), err)
+			continue
+		}
+
+		incidents, err := s.Incidents.List(now.Add(-objective.lookback()).Unix(), now.Unix())
+		if err != nil {
+			return nil, fmt.Errorf("cannot list incidents: %v", err)
+		}
+
+		report, err := Evaluate(lister, incidents, objective, now)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating %q: %v", obj.GetName(), err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package incident
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/db/dao"
+)
+
+const (
+	StatusOperational = "Operational"
+	StatusDegraded    = "Degraded"
+	StatusOutage      = "Outage"
+)
+
+type Config struct {
+	// Status is the group status that is bad enough to open an incident: Degraded or Outage
+	Status string
+	// Episodes is the number of consecutive bad 30s episodes to open an incident, and the number of
+	// consecutive good episodes to close it. Zero disables the detection.
+	Episodes int
+}
+
+type Storage interface {
+	GetOpen() (*dao.IncidentEntity, error)
+	Create(*dao.IncidentEntity) error
+	Update(*dao.IncidentEntity) error
+}
+
+// Detector opens an incident when a group stays in a bad status for the configured number of
+// consecutive episodes. Failures of other groups and probes that overlap with the open incident are
+// merged into it. The incident is closed when all groups recover for the same number of episodes.
+type Detector struct {
+	config  Config
+	storage Storage
+	logger  *log.Entry
+
+	// streaks of bad episodes by group
+	streaks map[string]*streak
+
+	open        *dao.IncidentEntity
+	recovered   int
+	recoveredAt time.Time
+}
+
+type streak struct {
+	start  time.Time
+	count  int
+	worst  string
+	probes map[string]struct{}
+}
+
+func NewDetector(config Config, storage Storage, logger *log.Entry) *Detector {
+	return &Detector{
+		config:  config,
+		storage: storage,
+		logger:  logger,
+		streaks: make(map[string]*streak),
+	}
+}
+
+// Restore picks up the incident left open by the previous run
+func (d *Detector) Restore() error {
+	open, err := d.storage.GetOpen()
+	if errors.Is(err, dao.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	d.open = open
+	return nil
+}
+
+// Observe processes episodes of a single 30s slot. Slots must be observed in chronological order.
+func (d *Detector) Observe(slot time.Time, episodes []check.Episode) error {
+	groups := evaluateGroups(episodes)
+	if len(groups) == 0 {
+		// no data, nothing has changed
+		return nil
+	}
+
+	bad := make([]string, 0)
+	for group, ev := range groups {
+		if !d.isBad(ev.status) {
+			delete(d.streaks, group)
+			continue
+		}
+
+		s, ok := d.streaks[group]
+		if !ok {
+			s = &streak{start: slot, probes: make(map[string]struct{})}
+			d.streaks[group] = s
+		}
+		s.count++
+		s.worst = worseStatus(s.worst, ev.status)
+		for _, probe := range ev.probes {
+			s.probes[probe] = struct{}{}
+		}
+		bad = append(bad, group)
+	}
+	sort.Strings(bad)
+
+	if d.open == nil {
+		return d.tryOpen()
+	}
+	return d.proceed(slot, bad)
+}
+
+func (d *Detector) tryOpen() error {
+	triggered := make([]string, 0)
+	for group, s := range d.streaks {
+		if s.count >= d.config.Episodes {
+			triggered = append(triggered, group)
+		}
+	}
+	if len(triggered) == 0 {
+		return nil
+	}
+	sort.Strings(triggered)
+
+	incident := &dao.IncidentEntity{Groups: []string{}, Probes: []string{}}
+	for _, group := range triggered {
+		s := d.streaks[group]
+		if incident.Start.IsZero() || s.start.Before(incident.Start) {
+			incident.Start = s.start
+		}
+		mergeStreak(incident, group, s)
+	}
+
+	if err := d.storage.Create(incident); err != nil {
+		return fmt.Errorf("cannot create incident: %v", err)
+	}
+	d.logger.Infof("Incident %d opened: groups=%v status=%s", incident.ID, incident.Groups, incident.WorstStatus)
+
+	d.open = incident
+	d.recovered = 0
+	return nil
+}
+
+func (d *Detector) proceed(slot time.Time, bad []string) error {
+	if len(bad) > 0 {
+		for _, group := range bad {
+			mergeStreak(d.open, group, d.streaks[group])
+		}
+		d.recovered = 0
+		if err := d.storage.Update(d.open); err != nil {
+			return fmt.Errorf("cannot update incident %d: %v", d.open.ID, err)
+		}
+		return nil
+	}
+
+	d.recovered++
+	if d.recovered == 1 {
+		d.recoveredAt = slot
+	}
+	if d.recovered < d.config.Episodes {
+		return nil
+	}
+
+	d.open.End = d.recoveredAt
+	if err := d.storage.Update(d.open); err != nil {
+		return fmt.Errorf("cannot close incident %d: %v", d.open.ID, err)
+	}
+	d.logger.Infof("Incident %d closed: groups=%v status=%s", d.open.ID, d.open.Groups, d.open.WorstStatus)
+
+	d.open = nil
+	d.recovered = 0
+	return nil
+}
+
+func (d *Detector) isBad(status string) bool {
+	return severity(status) >= severity(d.config.Status)
+}
+
+func mergeStreak(incident *dao.IncidentEntity, group string, s *streak) {
+	incident.Groups = addUnique(incident.Groups, group)
+	for probe := range s.probes {
+		incident.Probes = addUnique(incident.Probes, probe)
+	}
+	incident.WorstStatus = worseStatus(incident.WorstStatus, s.worst)
+}
+
+func addUnique(list []string, s string) []string {
+	for _, x := range list {
+		if x == s {
+			return list
+		}
+	}
+	list = append(list, s)
+	sort.Strings(list)
+	return list
+}
+
+type groupEvaluation struct {
+	status string
+	// probes are refs of failed probes
+	probes []string
+}
+
+// evaluateGroups calculates statuses of groups that have data in the slot. The group status is taken
+// from the group aggregation episode if there is one, otherwise the worst probe status is used.
+func evaluateGroups(episodes []check.Episode) map[string]*groupEvaluation {
+	groups := make(map[string]*groupEvaluation)
+	totals := make(map[string]string)
+
+	for _, ep := range episodes {
+		status, ok := episodeStatus(ep)
+		if !ok {
+			continue
+		}
+
+		group := ep.ProbeRef.Group
+		if ep.ProbeRef.Probe == dao.GroupAggregation {
+			totals[group] = status
+			continue
+		}
+
+		ev, ok := groups[group]
+		if !ok {
+			ev = &groupEvaluation{status: StatusOperational, probes: []string{}}
+			groups[group] = ev
+		}
+		ev.status = worseStatus(ev.status, status)
+		if status != StatusOperational {
+			ev.probes = append(ev.probes, ep.ProbeRef.Id())
+		}
+	}
+
+	for group, status := range totals {
+		ev, ok := groups[group]
+		if !ok {
+			ev = &groupEvaluation{probes: []string{}}
+			groups[group] = ev
+		}
+		ev.status = status
+	}
+
+	return groups
+}
+
+// episodeStatus interprets the episode the same way public status does; false is returned for
+// episodes without data
+func episodeStatus(ep check.Episode) (string, bool) {
+	switch {
+	case ep.Up+ep.Down+ep.Unknown == 0:
+		return "", false
+	case ep.Down == 0:
+		return StatusOperational, true
+	case ep.Up == 0 && ep.Unknown == 0:
+		return StatusOutage, true
+	default:
+		return StatusDegraded, true
+	}
+}
+
+func severity(status string) int {
+	switch status {
+	case StatusOutage:
+		return 2
+	case StatusDegraded:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func worseStatus(a, b string) string {
+	if severity(b) > severity(a) {
+		return b
+	}
+	if a == "" {
+		return b
+	}
+	return a
+}
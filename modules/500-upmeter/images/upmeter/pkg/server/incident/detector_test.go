@@ -0,0 +1,173 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package incident
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"d8.io/upmeter/pkg/check"
+	"d8.io/upmeter/pkg/db/dao"
+)
+
+type fakeStorage struct {
+	incidents []*dao.IncidentEntity
+}
+
+func (s *fakeStorage) GetOpen() (*dao.IncidentEntity, error) {
+	for _, inc := range s.incidents {
+		if inc.IsOpen() {
+			return inc, nil
+		}
+	}
+	return nil, dao.ErrNotFound
+}
+
+func (s *fakeStorage) Create(inc *dao.IncidentEntity) error {
+	inc.ID = int64(len(s.incidents) + 1)
+	s.incidents = append(s.incidents, inc)
+	return nil
+}
+
+func (s *fakeStorage) Update(*dao.IncidentEntity) error {
+	return nil
+}
+
+func newTestDetector(config Config) (*Detector, *fakeStorage) {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	storage := &fakeStorage{}
+	return NewDetector(config, storage, log.NewEntry(logger)), storage
+}
+
+var (
+	up       = check.Episode{Up: 30 * time.Second}
+	down     = check.Episode{Down: 30 * time.Second}
+	degraded = check.Episode{Up: 20 * time.Second, Down: 10 * time.Second}
+	noData   = check.Episode{NoData: 30 * time.Second}
+)
+
+func ep(group, probe string, stats check.Episode) check.Episode {
+	stats.ProbeRef = check.ProbeRef{Group: group, Probe: probe}
+	return stats
+}
+
+func Test_Detector(t *testing.T) {
+	detector, storage := newTestDetector(Config{Status: StatusDegraded, Episodes: 2})
+	start := time.Unix(1700000000, 0)
+
+	slots := [][]check.Episode{
+		// 0: single bad episode is not enough
+		{ep("cp", "api", down), ep("cp", dao.GroupAggregation, down)},
+		// 1: recovered
+		{ep("cp", "api", up), ep("cp", dao.GroupAggregation, up)},
+		// 2, 3: two bad episodes in a row open the incident starting from slot 2
+		{ep("cp", "api", degraded), ep("cp", dao.GroupAggregation, degraded)},
+		{ep("cp", "api", down), ep("cp", dao.GroupAggregation, down)},
+		// 4: overlapping failure of another group is merged
+		{ep("cp", "api", up), ep("cp", dao.GroupAggregation, up), ep("net", "dns", degraded)},
+		// 5: good, but not enough to close
+		{ep("cp", "api", up), ep("net", "dns", up)},
+		// 6: no data does not count
+		{ep("cp", "api", noData)},
+		// 7: closes the incident as of slot 5
+		{ep("cp", "api", up), ep("net", "dns", up)},
+	}
+
+	for i, episodes := range slots {
+		if err := detector.Observe(start.Add(time.Duration(i)*slotSize), episodes); err != nil {
+			t.Fatalf("slot %d: unexpected error: %v", i, err)
+		}
+		if i == 2 && len(storage.incidents) != 0 {
+			t.Fatalf("slot %d: incident opened too early", i)
+		}
+	}
+
+	if len(storage.incidents) != 1 {
+		t.Fatalf("expected 1 incident, got %d", len(storage.incidents))
+	}
+	inc := storage.incidents[0]
+
+	if !inc.Start.Equal(start.Add(2 * slotSize)) {
+		t.Errorf("expected start at slot 2, got %s", inc.Start)
+	}
+	if !inc.End.Equal(start.Add(5 * slotSize)) {
+		t.Errorf("expected end at slot 5, got %s", inc.End)
+	}
+	if inc.WorstStatus != StatusOutage {
+		t.Errorf("expected worst status %s, got %s", StatusOutage, inc.WorstStatus)
+	}
+	if len(inc.Groups) != 2 || inc.Groups[0] != "cp" || inc.Groups[1] != "net" {
+		t.Errorf("unexpected groups %v", inc.Groups)
+	}
+	if len(inc.Probes) != 2 || inc.Probes[0] != "cp/api" || inc.Probes[1] != "net/dns" {
+		t.Errorf("unexpected probes %v", inc.Probes)
+	}
+}
+
+func Test_Detector_StatusThreshold(t *testing.T) {
+	detector, storage := newTestDetector(Config{Status: StatusOutage, Episodes: 1})
+	start := time.Unix(1700000000, 0)
+
+	_ = detector.Observe(start, []check.Episode{ep("cp", "api", degraded)})
+	if len(storage.incidents) != 0 {
+		t.Fatalf("degraded group should not open an incident for Outage threshold")
+	}
+
+	_ = detector.Observe(start.Add(slotSize), []check.Episode{ep("cp", "api", down)})
+	if len(storage.incidents) != 1 {
+		t.Fatalf("outage should open an incident")
+	}
+}
+
+func Test_Detector_Restore(t *testing.T) {
+	detector, storage := newTestDetector(Config{Status: StatusOutage, Episodes: 1})
+	start := time.Unix(1700000000, 0)
+	storage.incidents = []*dao.IncidentEntity{{ID: 1, Start: start, Groups: []string{"cp"}, WorstStatus: StatusOutage}}
+
+	if err := detector.Restore(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = detector.Observe(start.Add(slotSize), []check.Episode{ep("cp", "api", up)})
+
+	if len(storage.incidents) != 1 || storage.incidents[0].IsOpen() {
+		t.Errorf("restored incident should be closed on recovery")
+	}
+}
+
+func Test_episodeStatus(t *testing.T) {
+	tests := []struct {
+		episode check.Episode
+		status  string
+		ok      bool
+	}{
+		{up, StatusOperational, true},
+		{down, StatusOutage, true},
+		{degraded, StatusDegraded, true},
+		{check.Episode{Unknown: 10 * time.Second, Down: 20 * time.Second}, StatusDegraded, true},
+		{noData, "", false},
+	}
+	for _, tt := range tests {
+		status, ok := episodeStatus(tt.episode)
+		if status != tt.status || ok != tt.ok {
+			t.Errorf("episodeStatus(%s) = %q, %v; want %q, %v", tt.episode.String(), status, ok, tt.status, tt.ok)
+		}
+	}
+}
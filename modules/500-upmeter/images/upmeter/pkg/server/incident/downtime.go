@@ -0,0 +1,57 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package incident
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"d8.io/upmeter/pkg/db/dao"
+	"d8.io/upmeter/pkg/monitor/downtime"
+)
+
+// ToDowntime converts the closed incident to the Downtime object, so that it could be applied to the
+// cluster and annotated later
+func ToDowntime(incident dao.IncidentEntity) downtime.Downtime {
+	description := fmt.Sprintf("Detected by upmeter, worst status %s", incident.WorstStatus)
+	if len(incident.Probes) > 0 {
+		description += fmt.Sprintf(", failed probes: %s", strings.Join(incident.Probes, ", "))
+	}
+
+	return downtime.Downtime{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "deckhouse.io/v1alpha1",
+			Kind:       "Downtime",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("upmeter-incident-%d", incident.ID),
+			Labels: map[string]string{
+				"upmeter.deckhouse.io/detected": "true",
+			},
+		},
+		Spec: []downtime.Spec{{
+			StartDate:   incident.Start.UTC().Format(time.RFC3339),
+			EndDate:     incident.End.UTC().Format(time.RFC3339),
+			Type:        "Accident",
+			Description: description,
+			Affected:    incident.Groups,
+		}},
+	}
+}
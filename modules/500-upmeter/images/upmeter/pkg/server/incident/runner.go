@@ -0,0 +1,74 @@
+/*
+Copyright 2023 Flant JSC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package incident
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	dbcontext "d8.io/upmeter/pkg/db/context"
+	"d8.io/upmeter/pkg/db/dao"
+)
+
+const (
+	slotSize = 30 * time.Second
+	// fulfillmentDelay is the time to wait for all agents to send episodes of a slot
+	fulfillmentDelay = time.Minute
+)
+
+// Run feeds the detector with fulfilled 30s episodes until the context is done
+func Run(ctx context.Context, dbCtx *dbcontext.DbContext, config Config, logger *log.Entry) {
+	conn := dbCtx.Start()
+	defer conn.Stop()
+
+	detector := NewDetector(config, dao.NewIncidentDAO(conn), logger)
+	if err := detector.Restore(); err != nil {
+		logger.Errorf("cannot restore open incident: %v", err)
+	}
+
+	episodes := dao.NewEpisodeDao30s(conn)
+	next := latestFulfilledSlot(time.Now())
+
+	ticker := time.NewTicker(slotSize)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			last := latestFulfilledSlot(time.Now())
+			for ; !next.After(last); next = next.Add(slotSize) {
+				list, err := episodes.ListEpisodesBySlot(next)
+				if err != nil {
+					logger.Errorf("cannot list episodes for slot %s: %v", next.Format(time.RFC3339), err)
+					break
+				}
+				if err := detector.Observe(next, list); err != nil {
+					logger.Errorf("cannot detect incidents in slot %s: %v", next.Format(time.RFC3339), err)
+					break
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func latestFulfilledSlot(now time.Time) time.Time {
+	return now.Add(-fulfillmentDelay).Truncate(slotSize).Add(-slotSize)
+}
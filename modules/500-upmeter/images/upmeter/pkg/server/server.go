@@ -26,18 +26,24 @@ import (
 
 	kube "github.com/flant/kube-client/client"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 
 	"d8.io/upmeter/pkg/db"
 	dbcontext "d8.io/upmeter/pkg/db/context"
 	"d8.io/upmeter/pkg/db/dao"
 	"d8.io/upmeter/pkg/kubernetes"
+	"d8.io/upmeter/pkg/monitor/customprobe"
 	"d8.io/upmeter/pkg/monitor/downtime"
+	"d8.io/upmeter/pkg/monitor/slo"
 	"d8.io/upmeter/pkg/probe"
 	"d8.io/upmeter/pkg/probe/calculated"
 	"d8.io/upmeter/pkg/probe/checker"
 	"d8.io/upmeter/pkg/registry"
 	"d8.io/upmeter/pkg/server/api"
+	"d8.io/upmeter/pkg/server/budget"
+	"d8.io/upmeter/pkg/server/incident"
 	"d8.io/upmeter/pkg/server/remotewrite"
 )
 
@@ -56,6 +62,8 @@ type Server struct {
 
 	server                *http.Server
 	downtimeMonitor       *downtime.Monitor
+	customProbeMonitor    *customprobe.Monitor
+	sloMonitor            *slo.Monitor
 	remoteWriteController *remotewrite.Controller
 }
 
@@ -70,6 +78,10 @@ type Config struct {
 
 	DisabledProbes []string
 	DynamicProbes  *DynamicProbesConfig
+
+	IncidentDetection incident.Config
+
+	Retention *RetentionConfig
 }
 
 type DynamicProbesConfig struct {
@@ -77,9 +89,17 @@ type DynamicProbesConfig struct {
 	NodeGroups         []string
 }
 
+// RetentionConfig sets how long episodes of each granularity are kept. Zero means forever.
+type RetentionConfig struct {
+	Episodes5m time.Duration
+	Episodes1h time.Duration
+	Episodes1d time.Duration
+}
+
 func NewConfig() *Config {
 	return &Config{
 		DynamicProbes: &DynamicProbesConfig{},
+		Retention:     &RetentionConfig{},
 	}
 }
 
@@ -111,6 +131,18 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("cannot start downtimes.deckhouse.io monitor: %v", err)
 	}
 
+	// UpmeterCustomProbe CR monitor
+	s.customProbeMonitor, err = initCustomProbeMonitor(ctx, kubeClient, s.logger)
+	if err != nil {
+		return fmt.Errorf("cannot start upmetercustomprobes.deckhouse.io monitor: %v", err)
+	}
+
+	// UpmeterSLO CR monitor
+	s.sloMonitor, err = initSLOMonitor(ctx, kubeClient, s.logger)
+	if err != nil {
+		return fmt.Errorf("cannot start upmeterslos.deckhouse.io monitor: %v", err)
+	}
+
 	// Metrics controller
 	s.remoteWriteController, err = initRemoteWriteController(ctx, dbctx, kubeClient, s.config.OriginsCount, s.logger, s.config.UserAgent)
 	if err != nil {
@@ -119,14 +151,30 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 
 	go cleanOld30sEpisodes(ctx, dbctx)
+	go compactEpisodes(ctx, dbctx, s.config.Retention)
+
+	// Automatic incident detection
+	if s.config.IncidentDetection.Episodes > 0 {
+		go incident.Run(ctx, dbctx, s.config.IncidentDetection, log.NewEntry(s.logger).WithField("component", "incident-detector"))
+	}
 
-	// Probe lister that can only list groups and probes
-	probeLister := newProbeLister(s.config.DisabledProbes, s.config.DynamicProbes)
+	// Probe lister that can only list groups and probes, including user-defined ones
+	probeFilter := probe.NewProbeFilter(s.config.DisabledProbes)
+	probeLister := newProbeLister(s.config.DisabledProbes, s.config.DynamicProbes).
+		WithDynamic(probe.NewCustomProbeLister(s.customProbeMonitor, probeFilter, s.logger))
+
+	// Error budgets of service level objectives
+	budgets := &budget.Service{
+		DbCtx:      dbctx,
+		Objectives: s.sloMonitor,
+		Incidents:  s.downtimeMonitor,
+		Logger:     log.NewEntry(s.logger).WithField("component", "error-budget"),
+	}
 
 	// Start http server. It blocks, that's why it is the last here.
 	s.logger.Debugf("starting HTTP server")
 	listenAddr := s.config.ListenHost + ":" + s.config.ListenPort
-	s.server = initHttpServer(dbctx, s.downtimeMonitor, s.remoteWriteController, probeLister, listenAddr)
+	s.server = initHttpServer(dbctx, s.downtimeMonitor, s.remoteWriteController, probeLister, budgets, listenAddr)
 
 	err = s.server.ListenAndServe()
 	if err == http.ErrServerClosed {
@@ -143,6 +191,8 @@ func (s *Server) Stop() error {
 	}
 	s.remoteWriteController.Stop()
 	s.downtimeMonitor.Stop()
+	s.customProbeMonitor.Stop()
+	s.sloMonitor.Stop()
 
 	return nil
 }
@@ -173,7 +223,80 @@ func cleanOld30sEpisodes(ctx context.Context, dbCtx *dbcontext.DbContext) {
 	}
 }
 
-func initHttpServer(dbCtx *dbcontext.DbContext, downtimeMonitor *downtime.Monitor, controller *remotewrite.Controller, probeLister registry.ProbeLister, addr string) *http.Server {
+const (
+	// min5mRetention keeps 5m episodes for the longest SLO window and the status page range of 30 days
+	min5mRetention = budget.MaxWindow
+
+	// minRolledUpRetention keeps the source of rolled up episodes long enough to recalculate the latest
+	// daily episodes
+	minRolledUpRetention = 48 * time.Hour
+)
+
+// compactEpisodes rolls up 5m episodes to hourly and daily ones, and removes episodes that are older
+// than the retention of their granularity
+func compactEpisodes(ctx context.Context, dbCtx *dbcontext.DbContext, retention *RetentionConfig) {
+	period := 5 * time.Minute
+
+	conn := dbCtx.Start()
+	defer conn.Stop()
+
+	var (
+		storage5m = dao.NewEpisodeDao5m(conn)
+		storage1h = dao.NewEpisodeDao1h(conn)
+		storage1d = dao.NewEpisodeDao1d(conn)
+	)
+
+	type cleaner interface {
+		DeleteUpTo(time.Time) error
+	}
+	retained := []struct {
+		storage cleaner
+		name    string
+		keep    time.Duration
+		min     time.Duration
+	}{
+		{storage5m, "5m", retention.Episodes5m, min5mRetention},
+		{storage1h, "1h", retention.Episodes1h, minRolledUpRetention},
+		{storage1d, "1d", retention.Episodes1d, 0},
+	}
+
+	ticker := time.NewTicker(period)
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now().Truncate(period)
+
+			// Daily episodes are calculated from hourly ones, so the order matters
+			if err := storage1h.Compact(now); err != nil {
+				log.Errorf("cannot roll up hourly episodes: %v", err)
+				continue
+			}
+			if err := storage1d.Compact(now); err != nil {
+				log.Errorf("cannot roll up daily episodes: %v", err)
+				continue
+			}
+
+			for _, r := range retained {
+				if r.keep == 0 {
+					continue
+				}
+				keep := r.keep
+				if keep < r.min {
+					keep = r.min
+				}
+				if err := r.storage.DeleteUpTo(now.Add(-keep)); err != nil {
+					log.Errorf("cannot clean old %s episodes: %v", r.name, err)
+				}
+			}
+		case <-ctx.Done():
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+func initHttpServer(dbCtx *dbcontext.DbContext, downtimeMonitor *downtime.Monitor, controller *remotewrite.Controller, probeLister registry.ProbeLister, budgets *budget.Service, addr string) *http.Server {
 	mux := http.NewServeMux()
 
 	// API handlers
@@ -182,6 +305,13 @@ func initHttpServer(dbCtx *dbcontext.DbContext, downtimeMonitor *downtime.Monito
 	mux.Handle("/public/api/status", &api.PublicStatusHandler{DbCtx: dbCtx, DowntimeMonitor: downtimeMonitor, ProbeLister: probeLister})
 	mux.Handle("/downtime", &api.AddEpisodesHandler{DbCtx: dbCtx, RemoteWrite: controller})
 	mux.Handle("/stats", &api.StatsHandler{DbCtx: dbCtx})
+	mux.Handle("/api/slo", &api.SLOHandler{Budgets: budgets})
+	mux.Handle("/api/incidents", &api.DetectedIncidentsHandler{DbCtx: dbCtx})
+	mux.Handle("/api/report/monthly", &api.MonthlyReportHandler{DbCtx: dbCtx, DowntimeMonitor: downtimeMonitor, ProbeLister: probeLister})
+	// Prometheus metrics
+	metricsRegistry := prometheus.NewRegistry()
+	metricsRegistry.MustRegister(budget.NewCollector(budgets))
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
 	// Kubernetes probes
 	mux.HandleFunc("/healthz", writeOk)
 	mux.HandleFunc("/ready", writeOk)
@@ -219,6 +349,16 @@ func initDowntimeMonitor(ctx context.Context, kubeClient kube.Client, logger *lo
 	return m, m.Start(ctx)
 }
 
+func initCustomProbeMonitor(ctx context.Context, kubeClient kube.Client, logger *log.Logger) (*customprobe.Monitor, error) {
+	m := customprobe.NewMonitor(kubeClient, log.NewEntry(logger))
+	return m, m.Start(ctx)
+}
+
+func initSLOMonitor(ctx context.Context, kubeClient kube.Client, logger *log.Logger) (*slo.Monitor, error) {
+	m := slo.NewMonitor(kubeClient, log.NewEntry(logger))
+	return m, m.Start(ctx)
+}
+
 func newProbeLister(disabled []string, dynamic *DynamicProbesConfig) *registry.RegistryProbeLister {
 	noLogger := newDummyLogger()
 	noFilter := probe.NewProbeFilter(disabled)